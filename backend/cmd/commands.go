@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/config"
+	"github.com/BombartSimon/MissingBrick/internal/database"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"github.com/BombartSimon/MissingBrick/internal/service"
+)
+
+// runCommand dispatches a CLI command given on the command line
+func runCommand(cfg *config.Config, name string, args []string) {
+	switch name {
+	case "import-catalog":
+		importCatalog(cfg, args)
+	case "add-sets":
+		addSets(cfg, args)
+	default:
+		log.Fatalf("Unknown command %q (available: import-catalog, add-sets)", name)
+	}
+}
+
+// importCatalog loads the Rebrickable CSV downloads from a directory into the local catalog mirror
+func importCatalog(cfg *config.Config, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: import-catalog <directory containing Rebrickable .csv.gz files>")
+	}
+
+	db, err := database.NewDatabase(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	importService := service.NewCatalogImportService(repository.NewCatalogRepository(db.DB))
+
+	report, err := importService.ImportDirectory(args[0])
+	if err != nil {
+		log.Fatalf("Catalog import failed: %v", err)
+	}
+
+	names := make([]string, 0, len(report.Imported))
+	for name := range report.Imported {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Printf("Imported %d rows from %s", report.Imported[name], name)
+	}
+	for _, name := range report.Skipped {
+		log.Printf("Skipped %s: file not found", name)
+	}
+}
+
+// addSets queues the import of the sets of a set list that are not stored yet. The jobs are run by
+// the server, right away when it is running or once it starts.
+func addSets(cfg *config.Config, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		log.Fatal("Usage: add-sets <set list file, or - for stdin> [json|text|csv]")
+	}
+
+	var content []byte
+	var err error
+	if args[0] == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		log.Fatalf("Failed to read set list: %v", err)
+	}
+
+	format := ""
+	if len(args) == 2 {
+		format = strings.ToLower(args[1])
+	} else {
+		switch {
+		case strings.EqualFold(filepath.Ext(args[0]), ".json") || bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")):
+			format = service.SetListFormatJSON
+		case strings.EqualFold(filepath.Ext(args[0]), ".csv"):
+			format = service.SetListFormatCSV
+		default:
+			format = service.SetListFormatText
+		}
+	}
+
+	db, err := database.NewDatabase(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Queuing only needs the jobs and the sets; the services that run the jobs belong to the server
+	importJobService := service.NewImportJobService(repository.NewImportJobRepository(db.DB), repository.NewSetRepository(db.DB), nil, nil, nil)
+
+	report, err := importJobService.ImportSetList(format, bytes.NewReader(content))
+	if err != nil {
+		log.Fatalf("Adding sets failed: %v", err)
+	}
+
+	for _, row := range report.Sets {
+		switch {
+		case row.JobID != nil:
+			log.Printf("Line %d: %s %s (job %d)", row.Line, row.SetNum, row.Outcome, *row.JobID)
+		case row.Error != "":
+			log.Printf("Line %d: %s %s: %s", row.Line, row.SetNum, row.Outcome, row.Error)
+		default:
+			log.Printf("Line %d: %s %s", row.Line, row.SetNum, row.Outcome)
+		}
+	}
+	log.Printf("%d sets queued, %d already stored, %d failed", report.Queued, report.Existing, report.Failed)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/BombartSimon/MissingBrick/internal/config"
 	"github.com/BombartSimon/MissingBrick/internal/database"
@@ -21,8 +22,15 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Check if Rebrickable API key is provided
-	if cfg.RebrickableAPIKey == "" {
+	// Run a CLI command instead of the server when one is given
+	if len(os.Args) > 1 {
+		runCommand(cfg, os.Args[1], os.Args[2:])
+		return
+	}
+
+	// Check if Rebrickable API key is provided when data comes from rebrickable.com
+	useCatalog := cfg.RebrickableSource == config.RebrickableSourceCatalog
+	if !useCatalog && cfg.RebrickableAPIKey == "" {
 		log.Fatal("REBRICKABLE_API_KEY environment variable is required")
 	}
 
@@ -35,33 +43,107 @@ func main() {
 
 	// Initialize repositories
 	setRepo := repository.NewSetRepository(db.DB)
+	setCopyRepo := repository.NewSetCopyRepository(db.DB)
 	partRepo := repository.NewPartRepository(db.DB)
 	setPartRepo := repository.NewSetPartRepository(db.DB)
 	missingPartsRepo := repository.NewMissingPartRepository(db.DB)
+	catalogRepo := repository.NewCatalogRepository(db.DB)
+	colorRepo := repository.NewColorRepository(db.DB)
+	themeRepo := repository.NewThemeRepository(db.DB)
+	minifigRepo := repository.NewMinifigRepository(db.DB)
+	missingMinifigRepo := repository.NewMissingMinifigRepository(db.DB)
+	loosePartRepo := repository.NewLoosePartRepository(db.DB)
+	storageLocationRepo := repository.NewStorageLocationRepository(db.DB)
+	buildabilityRepo := repository.NewBuildabilityRepository(db.DB)
+	rebrickableSyncRepo := repository.NewRebrickableSyncRepository(db.DB)
+	refreshRepo := repository.NewRefreshRepository(db.DB)
+	importJobRepo := repository.NewImportJobRepository(db.DB)
+	transactor := repository.NewTransactor(db.DB)
+	searchRepo := repository.NewSearchRepository(db.DB, db.FullTextSearch)
 
 	// Initialize services
-	rebrickableService := service.NewRebrickableService(cfg.RebrickableAPIKey)
-	setPartService := service.NewSetPartService(setPartRepo, partRepo, rebrickableService)
-	setService := service.NewSetService(setRepo, setPartService, rebrickableService)
-	missingPartsService := service.NewMissingPartsService(missingPartsRepo, setPartRepo)
+	// The collection of a Rebrickable user is always synced with rebrickable.com
+	rebrickableClient := service.NewRebrickableClient(cfg.RebrickableAPIKey, cfg.RebrickableRateLimit, cfg.RebrickableMaxRetries)
+	var rebrickableService service.RebrickableService = rebrickableClient
+	if useCatalog {
+		rebrickableService = service.NewCatalogRebrickableService(catalogRepo)
+	}
+	setPartService := service.NewSetPartService(setPartRepo, partRepo, colorRepo, rebrickableService, transactor)
+	minifigService := service.NewMinifigService(minifigRepo, partRepo, colorRepo, rebrickableService)
+	themeService := service.NewThemeService(themeRepo, rebrickableService)
+	setService := service.NewSetService(setRepo, setPartService, minifigService, themeService, rebrickableService, transactor)
+	setCopyService := service.NewSetCopyService(setCopyRepo, setRepo, storageLocationRepo, transactor)
+	missingPartsService := service.NewMissingPartsService(missingPartsRepo, missingMinifigRepo, setPartRepo, minifigRepo, setCopyService, themeService, transactor)
+	exportService := service.NewMissingPartsExportService(missingPartsService)
+	colorService := service.NewColorService(colorRepo, rebrickableService)
+	partService := service.NewPartService(partRepo, searchRepo)
+	searchService := service.NewSearchService(searchRepo)
+	loosePartService := service.NewLoosePartService(loosePartRepo, partRepo, colorRepo, storageLocationRepo, rebrickableService, transactor)
+	buildabilityService := service.NewBuildabilityService(buildabilityRepo, setRepo, rebrickableService)
+	storageLocationService := service.NewStorageLocationService(storageLocationRepo, setCopyRepo, loosePartRepo, transactor)
+	fulfillmentService := service.NewFulfillmentService(missingPartsRepo, loosePartRepo, setRepo, setPartRepo, setCopyRepo, catalogRepo, transactor)
+	importJobService := service.NewImportJobService(importJobRepo, setRepo, setService, setPartService, minifigService)
+	rebrickableSyncService := service.NewRebrickableSyncService(rebrickableSyncRepo, setRepo, setCopyRepo, setPartRepo, missingPartsRepo, setService, importJobService, setCopyService, loosePartService, rebrickableClient, transactor)
+	refreshScheduler := service.NewRefreshScheduler(refreshRepo, setService, service.RefreshOptions{
+		Interval:          cfg.RefreshInterval,
+		MaxAge:            cfg.RefreshMaxAge,
+		BatchSize:         cfg.RefreshBatchSize,
+		RequestsPerSecond: cfg.RebrickableRateLimit,
+	})
 
 	// Initialize handlers
-	setHandler := handler.NewSetHandler(setService)
+	setHandler := handler.NewSetHandler(setService, importJobService)
+	setCopyHandler := handler.NewSetCopyHandler(setCopyService)
 	missingPartsHandler := handler.NewMissingPartsHandler(missingPartsService)
 	setPartsHandler := handler.NewSetPartsHandler(setPartService)
+	colorHandler := handler.NewColorHandler(colorService)
+	themeHandler := handler.NewThemeHandler(themeService)
+	minifigHandler := handler.NewMinifigHandler(minifigService)
+	exportHandler := handler.NewExportHandler(exportService)
+	partHandler := handler.NewPartHandler(partService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	loosePartHandler := handler.NewLoosePartHandler(loosePartService)
+	fulfillmentHandler := handler.NewFulfillmentHandler(fulfillmentService)
+	buildabilityHandler := handler.NewBuildabilityHandler(buildabilityService)
+	storageLocationHandler := handler.NewStorageLocationHandler(storageLocationService)
+	rebrickableSyncHandler := handler.NewRebrickableSyncHandler(rebrickableSyncService)
+	refreshHandler := handler.NewRefreshHandler(refreshScheduler)
+	importJobHandler := handler.NewImportJobHandler(importJobService)
 
 	// Initialize router
 	r := router.NewRouter(
 		setHandler,
+		setCopyHandler,
 		setPartsHandler,
 		missingPartsHandler,
+		colorHandler,
+		themeHandler,
+		minifigHandler,
+		exportHandler,
+		partHandler,
+		searchHandler,
+		loosePartHandler,
+		fulfillmentHandler,
+		buildabilityHandler,
+		storageLocationHandler,
+		rebrickableSyncHandler,
+		refreshHandler,
+		importJobHandler,
 	)
 	engine := r.SetupRoutes()
 
+	// Import sets and refresh stale sets in the background
+	importJobService.Start()
+	refreshScheduler.Start()
+
 	// Start server
 	log.Printf("Starting server on port %s", cfg.Port)
 	log.Printf("Database: %s", cfg.DatabaseURL)
-	log.Printf("Rebrickable API Key: %s...", cfg.RebrickableAPIKey[:min(10, len(cfg.RebrickableAPIKey))])
+	if useCatalog {
+		log.Printf("Rebrickable data: local catalog mirror")
+	} else {
+		log.Printf("Rebrickable API Key: %s...", cfg.RebrickableAPIKey[:min(10, len(cfg.RebrickableAPIKey))])
+	}
 
 	if err := engine.Run(":" + cfg.Port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
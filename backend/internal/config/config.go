@@ -1,14 +1,38 @@
 package config
 
-import "github.com/caarlos0/env/v11"
+import (
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
 
 // Config holds all configuration for our application
 type Config struct {
 	DatabaseURL       string `env:"DATABASE_URL"`
 	RebrickableAPIKey string `env:"REBRICKABLE_API_KEY"`
 	Port              string `env:"PORT"`
+
+	// RebrickableSource selects where set data comes from: "api" (rebrickable.com) or "catalog" (local mirror)
+	RebrickableSource string `env:"REBRICKABLE_SOURCE" envDefault:"api"`
+	// RebrickableRateLimit is the maximum number of Rebrickable API requests per second
+	RebrickableRateLimit float64 `env:"REBRICKABLE_RATE_LIMIT" envDefault:"1"`
+	// RebrickableMaxRetries is how many times a throttled or failed Rebrickable request is retried
+	RebrickableMaxRetries int `env:"REBRICKABLE_MAX_RETRIES" envDefault:"3"`
+
+	// RefreshInterval is how often stale sets are refreshed from Rebrickable in the background (0 disables it)
+	RefreshInterval time.Duration `env:"REFRESH_INTERVAL" envDefault:"1h"`
+	// RefreshMaxAge is how long a set goes without a refresh before it is stale
+	RefreshMaxAge time.Duration `env:"REFRESH_MAX_AGE" envDefault:"168h"`
+	// RefreshBatchSize is the maximum number of sets refreshed per run
+	RefreshBatchSize int `env:"REFRESH_BATCH_SIZE" envDefault:"20"`
 }
 
+// Rebrickable data sources
+const (
+	RebrickableSourceAPI     = "api"
+	RebrickableSourceCatalog = "catalog"
+)
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
 	config := &Config{}
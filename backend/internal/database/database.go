@@ -11,6 +11,9 @@ import (
 // Database holds the database connection
 type Database struct {
 	DB *gorm.DB
+
+	// FullTextSearch reports whether the FTS5 search index is available
+	FullTextSearch bool
 }
 
 // NewDatabase creates a new database connection
@@ -22,18 +25,187 @@ func NewDatabase(databaseURL string) (*Database, error) {
 
 	// Auto migrate the schema
 	err = db.AutoMigrate(
+		&entity.Color{},
+		&entity.Theme{},
 		&entity.Set{},
+		&entity.SetCopy{},
 		&entity.Part{},
 		&entity.MissingPart{},
+		&entity.MissingPartRecovery{},
 		&entity.SetPart{},
+		&entity.Minifig{},
+		&entity.MinifigPart{},
+		&entity.SetMinifig{},
+		&entity.MissingMinifig{},
+		&entity.StorageLocation{},
+		&entity.LoosePart{},
+		&entity.RebrickableAccount{},
+		&entity.RebrickableLostPart{},
+		&entity.RebrickablePartListItem{},
+		&entity.RefreshRun{},
+		&entity.SetRefresh{},
+		&entity.ImportJob{},
+		&entity.IdempotencyKey{},
+		&entity.CatalogTheme{},
+		&entity.CatalogColor{},
+		&entity.CatalogPartCategory{},
+		&entity.CatalogPart{},
+		&entity.CatalogSet{},
+		&entity.CatalogMinifig{},
+		&entity.CatalogInventory{},
+		&entity.CatalogInventoryPart{},
+		&entity.CatalogInventoryMinifig{},
+		&entity.CatalogElement{},
+		&entity.CatalogPartRelationship{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := migrateLegacyColors(db); err != nil {
+		return nil, err
+	}
+
+	if err := assignSetCopies(db); err != nil {
+		return nil, err
+	}
+
+	if err := mergeDuplicateMissingParts(db); err != nil {
+		return nil, err
+	}
+
+	if err := migrateLooseLocations(db); err != nil {
+		return nil, err
+	}
+
+	if err := dropLoosePartsColorIndex(db); err != nil {
+		return nil, err
+	}
+
+	fullTextSearch, err := setupSearchIndex(db)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Println("Database connected and migrated successfully")
 
-	return &Database{DB: db}, nil
+	return &Database{DB: db, FullTextSearch: fullTextSearch}, nil
+}
+
+// migrateLegacyColors moves the color names and hex codes that used to be copied on every
+// set part and missing part into the colors table, then drops the old columns
+func migrateLegacyColors(db *gorm.DB) error {
+	for _, model := range []interface{}{&entity.SetPart{}, &entity.MissingPart{}} {
+		if !db.Migrator().HasColumn(model, "color_name") {
+			continue
+		}
+
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+
+		err := db.Exec(`INSERT INTO colors (id, name, rgb, is_trans, created_at, updated_at)
+			SELECT color_id, MAX(color_name), MAX(color_hex), MAX(color_name) LIKE 'Trans-%', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+			FROM ` + table + `
+			GROUP BY color_id
+			ON CONFLICT(id) DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+
+		for _, column := range []string{"color_name", "color_hex"} {
+			if err := db.Migrator().DropColumn(model, column); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// assignSetCopies gives every set recorded before owned copies existed a first copy, and moves the
+// missing parts and minifigs of the set to it
+func assignSetCopies(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO set_copies (set_id, label, condition, notes, created_at, updated_at)
+			SELECT id, 'Copy 1', '', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM sets
+			WHERE NOT EXISTS (SELECT 1 FROM set_copies c WHERE c.set_id = sets.id)`).Error
+		if err != nil {
+			return err
+		}
+
+		for _, table := range []string{"missing_parts", "missing_minifigs"} {
+			err := tx.Exec(`UPDATE ` + table + `
+				SET set_copy_id = COALESCE((SELECT MIN(c.id) FROM set_copies c WHERE c.set_id = ` + table + `.set_id), 0)
+				WHERE set_copy_id IS NULL`).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// mergeDuplicateMissingParts folds the open missing parts that were recorded several times for the
+// same set copy, part, color, spare flag and minifig into the oldest row, which assignments now update
+func mergeDuplicateMissingParts(db *gorm.DB) error {
+	const sameKey = `d.set_id = missing_parts.set_id AND d.set_copy_id = missing_parts.set_copy_id AND d.part_id = missing_parts.part_id
+		AND d.color_id = missing_parts.color_id AND d.is_spare = missing_parts.is_spare
+		AND IFNULL(d.set_minifig_id, 0) = IFNULL(missing_parts.set_minifig_id, 0)
+		AND d.is_missing AND d.deleted_at IS NULL`
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE missing_parts
+			SET quantity = (SELECT SUM(d.quantity) FROM missing_parts d WHERE ` + sameKey + `)
+			WHERE is_missing AND deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM missing_parts d WHERE ` + sameKey + ` AND d.id < missing_parts.id)
+			AND EXISTS (SELECT 1 FROM missing_parts d WHERE ` + sameKey + ` AND d.id > missing_parts.id)`).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`UPDATE missing_parts SET deleted_at = CURRENT_TIMESTAMP
+			WHERE is_missing AND deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM missing_parts d WHERE ` + sameKey + ` AND d.id < missing_parts.id)`).Error
+	})
+}
+
+// migrateLooseLocations turns the free text locations of loose parts into bins at the root of the
+// storage locations, then drops the old column
+func migrateLooseLocations(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entity.LoosePart{}, "location") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO storage_locations (name, kind, path, notes, created_at, updated_at)
+			SELECT DISTINCT location, ?, location, '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM loose_parts
+			WHERE location <> '' AND NOT EXISTS (SELECT 1 FROM storage_locations l WHERE l.parent_id IS NULL AND l.name = loose_parts.location)`,
+			entity.StorageLocationKindBin).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`UPDATE loose_parts
+			SET storage_location_id = (SELECT MIN(l.id) FROM storage_locations l WHERE l.parent_id IS NULL AND l.name = loose_parts.location)
+			WHERE location <> ''`).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn(&entity.LoosePart{}, "location")
+	})
+}
+
+// dropLoosePartsColorIndex drops the unique index that allowed a single loose part entry per part and
+// color; entries are now unique per storage location too
+func dropLoosePartsColorIndex(db *gorm.DB) error {
+	if !db.Migrator().HasIndex(&entity.LoosePart{}, "idx_loose_parts_part_color") {
+		return nil
+	}
+	return db.Migrator().DropIndex(&entity.LoosePart{}, "idx_loose_parts_part_color")
 }
 
 // Close closes the database connection
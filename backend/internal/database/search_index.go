@@ -0,0 +1,81 @@
+package database
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// searchIndexTriggers keep the search_index table in sync with the parts and sets tables.
+// Rows are keyed by rowid (id*2 for parts, id*2+1 for sets) so they can be replaced without a scan.
+var searchIndexTriggers = map[string]string{
+	"search_index_parts_insert": `AFTER INSERT ON parts WHEN new.deleted_at IS NULL BEGIN
+		INSERT INTO search_index(rowid, kind, ref_id, num, name) VALUES (new.id * 2, 'part', new.id, new.part_num, new.name);
+	END`,
+	"search_index_parts_update": `AFTER UPDATE ON parts BEGIN
+		DELETE FROM search_index WHERE rowid = old.id * 2;
+		INSERT INTO search_index(rowid, kind, ref_id, num, name) SELECT new.id * 2, 'part', new.id, new.part_num, new.name WHERE new.deleted_at IS NULL;
+	END`,
+	"search_index_parts_delete": `AFTER DELETE ON parts BEGIN
+		DELETE FROM search_index WHERE rowid = old.id * 2;
+	END`,
+	"search_index_sets_insert": `AFTER INSERT ON sets WHEN new.deleted_at IS NULL BEGIN
+		INSERT INTO search_index(rowid, kind, ref_id, num, name) VALUES (new.id * 2 + 1, 'set', new.id, new.set_num, new.name);
+	END`,
+	"search_index_sets_update": `AFTER UPDATE ON sets BEGIN
+		DELETE FROM search_index WHERE rowid = old.id * 2 + 1;
+		INSERT INTO search_index(rowid, kind, ref_id, num, name) SELECT new.id * 2 + 1, 'set', new.id, new.set_num, new.name WHERE new.deleted_at IS NULL;
+	END`,
+	"search_index_sets_delete": `AFTER DELETE ON sets BEGIN
+		DELETE FROM search_index WHERE rowid = old.id * 2 + 1;
+	END`,
+}
+
+// setupSearchIndex creates the FTS5 index over part and set numbers and names, rebuilds it and
+// installs the triggers keeping it in sync. It reports false when SQLite was built without FTS5
+// (build with -tags sqlite_fts5), in which case the triggers are removed so writes keep working.
+func setupSearchIndex(db *gorm.DB) (bool, error) {
+	var fts5 int64
+	err := db.Raw("SELECT COUNT(*) FROM pragma_compile_options WHERE compile_options = 'ENABLE_FTS5'").Scan(&fts5).Error
+	if err != nil {
+		return false, err
+	}
+
+	if fts5 == 0 {
+		for name := range searchIndexTriggers {
+			if err := db.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
+				return false, err
+			}
+		}
+		log.Printf("Warning: SQLite was built without FTS5, search falls back to LIKE queries (build with -tags sqlite_fts5)")
+		return false, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+			kind UNINDEXED, ref_id UNINDEXED, num, name, tokenize = 'unicode61 remove_diacritics 2')`).Error
+		if err != nil {
+			return err
+		}
+
+		for name, trigger := range searchIndexTriggers {
+			if err := tx.Exec("CREATE TRIGGER IF NOT EXISTS " + name + " " + trigger).Error; err != nil {
+				return err
+			}
+		}
+
+		// Rebuild in case parts or sets were written while the triggers were missing
+		statements := []string{
+			"DELETE FROM search_index",
+			"INSERT INTO search_index(rowid, kind, ref_id, num, name) SELECT id * 2, 'part', id, part_num, name FROM parts WHERE deleted_at IS NULL",
+			"INSERT INTO search_index(rowid, kind, ref_id, num, name) SELECT id * 2 + 1, 'set', id, set_num, name FROM sets WHERE deleted_at IS NULL",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err == nil, err
+}
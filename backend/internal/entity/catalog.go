@@ -0,0 +1,159 @@
+package entity
+
+// CatalogTheme represents a theme from the Rebrickable themes.csv download
+type CatalogTheme struct {
+	ID       int    `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Name     string `gorm:"not null" json:"name"`
+	ParentID *int   `gorm:"index" json:"parent_id"`
+}
+
+// CatalogColor represents a color from the Rebrickable colors.csv download
+type CatalogColor struct {
+	ID      int    `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Name    string `gorm:"not null" json:"name"`
+	RGB     string `json:"rgb"`
+	IsTrans bool   `json:"is_trans"`
+}
+
+// CatalogPartCategory represents a part category from the Rebrickable part_categories.csv download
+type CatalogPartCategory struct {
+	ID   int    `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Name string `gorm:"not null" json:"name"`
+}
+
+// CatalogPart represents a part from the Rebrickable parts.csv download
+type CatalogPart struct {
+	PartNum      string `gorm:"primaryKey" json:"part_num"`
+	Name         string `gorm:"not null" json:"name"`
+	PartCatID    int    `gorm:"index" json:"part_cat_id"`
+	PartMaterial string `json:"part_material"`
+}
+
+// CatalogSet represents a set from the Rebrickable sets.csv download
+type CatalogSet struct {
+	SetNum   string `gorm:"primaryKey" json:"set_num"`
+	Name     string `gorm:"not null" json:"name"`
+	Year     int    `gorm:"index" json:"year"`
+	ThemeID  int    `gorm:"index" json:"theme_id"`
+	NumParts int    `json:"num_parts"`
+	ImageURL string `json:"img_url"`
+}
+
+// CatalogMinifig represents a minifigure from the Rebrickable minifigs.csv download
+type CatalogMinifig struct {
+	FigNum   string `gorm:"primaryKey" json:"fig_num"`
+	Name     string `gorm:"not null" json:"name"`
+	NumParts int    `json:"num_parts"`
+	ImageURL string `json:"img_url"`
+}
+
+// CatalogInventory represents an inventory version of a set or minifig from inventories.csv
+type CatalogInventory struct {
+	ID      int    `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Version int    `json:"version"`
+	SetNum  string `gorm:"index" json:"set_num"`
+}
+
+// CatalogInventoryPart represents a part line of an inventory from inventory_parts.csv
+type CatalogInventoryPart struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	InventoryID int    `gorm:"index" json:"inventory_id"`
+	PartNum     string `gorm:"index" json:"part_num"`
+	ColorID     int    `json:"color_id"`
+	Quantity    int    `json:"quantity"`
+	IsSpare     bool   `json:"is_spare"`
+	ImageURL    string `json:"img_url"`
+
+	// Relations
+	Part  CatalogPart  `gorm:"foreignKey:PartNum;references:PartNum" json:"part"`
+	Color CatalogColor `gorm:"foreignKey:ColorID" json:"color"`
+}
+
+// CatalogInventoryMinifig represents a minifig line of an inventory from inventory_minifigs.csv
+type CatalogInventoryMinifig struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	InventoryID int    `gorm:"index" json:"inventory_id"`
+	FigNum      string `gorm:"index" json:"fig_num"`
+	Quantity    int    `json:"quantity"`
+
+	// Relations
+	Minifig CatalogMinifig `gorm:"foreignKey:FigNum;references:FigNum" json:"minifig"`
+}
+
+// CatalogElement represents a LEGO element (part in a given color) from elements.csv
+type CatalogElement struct {
+	ElementID string `gorm:"primaryKey" json:"element_id"`
+	PartNum   string `gorm:"index" json:"part_num"`
+	ColorID   int    `json:"color_id"`
+	DesignID  string `json:"design_id"`
+}
+
+// Relationship types of part_relationships.csv used to find alternates of a part
+const (
+	CatalogRelPrint = "P"
+	CatalogRelMold  = "M"
+)
+
+// CatalogPartRelationship relates a child part to its parent from part_relationships.csv,
+// such as a print (P) of a plain part or a mold variant (M) of the same part
+type CatalogPartRelationship struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	RelType       string `gorm:"index" json:"rel_type"`
+	ChildPartNum  string `gorm:"index" json:"child_part_num"`
+	ParentPartNum string `gorm:"index" json:"parent_part_num"`
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogTheme) TableName() string {
+	return "catalog_themes"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogColor) TableName() string {
+	return "catalog_colors"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogPartCategory) TableName() string {
+	return "catalog_part_categories"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogPart) TableName() string {
+	return "catalog_parts"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogSet) TableName() string {
+	return "catalog_sets"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogMinifig) TableName() string {
+	return "catalog_minifigs"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogInventory) TableName() string {
+	return "catalog_inventories"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogInventoryPart) TableName() string {
+	return "catalog_inventory_parts"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogInventoryMinifig) TableName() string {
+	return "catalog_inventory_minifigs"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogElement) TableName() string {
+	return "catalog_elements"
+}
+
+// TableName overrides the table name used by GORM
+func (CatalogPartRelationship) TableName() string {
+	return "catalog_part_relationships"
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// Color represents a LEGO color from Rebrickable API.
+// The primary key is the Rebrickable color ID, where 0 is Black and -1 is Unknown.
+type Color struct {
+	ID          int       `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	RGB         string    `json:"rgb"`
+	IsTrans     bool      `gorm:"index" json:"is_trans"`
+	BrickLinkID *int      `gorm:"column:bricklink_id" json:"bricklink_id"`
+	LDrawID     *int      `gorm:"column:ldraw_id" json:"ldraw_id"`
+	LEGOID      *int      `gorm:"column:lego_id" json:"lego_id"`
+	ExternalIDs string    `gorm:"type:text" json:"external_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name used by GORM
+func (Color) TableName() string {
+	return "colors"
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// IdempotencyKey records the outcome of a request sent with an Idempotency-Key header,
+// so that a retried request returns the same result instead of being applied twice
+type IdempotencyKey struct {
+	Key         string    `gorm:"primaryKey" json:"key"`
+	Scope       string    `gorm:"not null" json:"scope"`
+	RequestHash string    `gorm:"not null" json:"request_hash"`
+	Response    string    `gorm:"type:text" json:"response"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// Import job statuses and steps. A job imports a set, then its parts, then its minifigs, and
+// resumes from the step that failed when retried.
+const (
+	ImportJobStatusQueued  = "queued"
+	ImportJobStatusRunning = "running"
+	ImportJobStatusFailed  = "failed"
+	ImportJobStatusDone    = "done"
+
+	ImportJobStepSet      = "set"
+	ImportJobStepParts    = "parts"
+	ImportJobStepMinifigs = "minifigs"
+)
+
+// ImportJob is the background import of a set from Rebrickable. Copies is the number of copies of
+// the set created along with it.
+type ImportJob struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	SetNum        string     `gorm:"not null;index" json:"set_num"`
+	SetID         *uint      `gorm:"index" json:"set_id"`
+	Status        string     `gorm:"not null;index" json:"status"`
+	Step          string     `gorm:"not null" json:"step"`
+	Copies        int        `gorm:"not null;default:1" json:"copies"`
+	Progress      int        `json:"progress"`
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"max_attempts"`
+	Error         string     `gorm:"type:text" json:"error,omitempty"`
+	NextAttemptAt *time.Time `gorm:"index" json:"next_attempt_at"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	Set *Set `gorm:"foreignKey:SetID" json:"set,omitempty"`
+}
+
+// TableName overrides the table name used by GORM
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// LoosePart represents owned pieces of a part in one color that do not belong to any set, kept in
+// one storage location
+type LoosePart struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	PartID            uint      `gorm:"not null;uniqueIndex:idx_loose_parts_part_color_location" json:"part_id"`
+	ColorID           int       `gorm:"not null;uniqueIndex:idx_loose_parts_part_color_location;index" json:"color_id"`
+	Quantity          int       `gorm:"not null" json:"quantity"`
+	StorageLocationID *uint     `gorm:"uniqueIndex:idx_loose_parts_part_color_location;index" json:"storage_location_id"`
+	Notes             string    `gorm:"type:text" json:"notes"`
+	CreatedAt         time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// Relations
+	Part            Part             `gorm:"foreignKey:PartID" json:"part,omitempty"`
+	Color           Color            `gorm:"foreignKey:ColorID" json:"color"`
+	StorageLocation *StorageLocation `gorm:"foreignKey:StorageLocationID" json:"storage_location,omitempty"`
+}
+
+// TableName overrides the table name used by GORM
+func (LoosePart) TableName() string {
+	return "loose_parts"
+}
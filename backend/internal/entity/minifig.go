@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Minifig represents a LEGO minifigure from Rebrickable API
+type Minifig struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	FigNum    string         `gorm:"uniqueIndex;not null" json:"fig_num"`
+	Name      string         `gorm:"not null" json:"name"`
+	NumParts  int            `json:"num_parts"`
+	ImageURL  string         `json:"img_url"`
+	URL       string         `json:"url"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Parts []MinifigPart `gorm:"foreignKey:MinifigID" json:"parts,omitempty"`
+}
+
+// MinifigPart represents a part of a minifigure with quantity and color
+type MinifigPart struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	MinifigID uint      `gorm:"not null;index" json:"minifig_id"`
+	PartID    uint      `gorm:"not null;index" json:"part_id"`
+	ColorID   int       `gorm:"not null;index" json:"color_id"`
+	Quantity  int       `gorm:"not null;default:1" json:"quantity"`
+	IsSpare   bool      `gorm:"default:false" json:"is_spare"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Part  Part  `gorm:"foreignKey:PartID" json:"part,omitempty"`
+	Color Color `gorm:"foreignKey:ColorID" json:"color"`
+}
+
+// SetMinifig represents a minifigure that belongs to a specific set with quantity
+type SetMinifig struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	SetID     uint           `gorm:"not null;index" json:"set_id"`
+	MinifigID uint           `gorm:"not null;index" json:"minifig_id"`
+	Quantity  int            `gorm:"not null;default:1" json:"quantity"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Set     Set     `gorm:"foreignKey:SetID" json:"-"`
+	Minifig Minifig `gorm:"foreignKey:MinifigID" json:"minifig,omitempty"`
+}
+
+// MissingMinifig represents a whole minifigure missing from a specific set
+type MissingMinifig struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	SetID        uint           `gorm:"not null;index" json:"set_id"`
+	SetCopyID    uint           `gorm:"index" json:"set_copy_id"`
+	SetMinifigID uint           `gorm:"not null;index" json:"set_minifig_id"`
+	MinifigID    uint           `gorm:"not null;index" json:"minifig_id"`
+	Quantity     int            `gorm:"not null;default:1" json:"quantity"`
+	IsMissing    bool           `gorm:"default:true" json:"is_missing"`
+	Notes        string         `gorm:"type:text" json:"notes"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Set     Set     `gorm:"foreignKey:SetID" json:"set,omitempty"`
+	Minifig Minifig `gorm:"foreignKey:MinifigID" json:"minifig,omitempty"`
+}
+
+// TableName overrides the table name used by GORM
+func (Minifig) TableName() string {
+	return "minifigs"
+}
+
+// TableName overrides the table name used by GORM
+func (MinifigPart) TableName() string {
+	return "minifig_parts"
+}
+
+// TableName overrides the table name used by GORM
+func (SetMinifig) TableName() string {
+	return "set_minifigs"
+}
+
+// TableName overrides the table name used by GORM
+func (MissingMinifig) TableName() string {
+	return "missing_minifigs"
+}
@@ -23,22 +23,43 @@ type Part struct {
 
 // MissingPart represents a missing part for a specific set
 type MissingPart struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	SetID     uint           `gorm:"not null;index" json:"set_id"`
-	PartID    uint           `gorm:"not null;index" json:"part_id"`
-	ColorID   int            `gorm:"not null" json:"color_id"`
-	ColorName string         `json:"color_name"`
-	ColorHex  string         `json:"color_hex"`
-	Quantity  int            `gorm:"not null;default:1" json:"quantity"`
-	IsMissing bool           `gorm:"default:true" json:"is_missing"`
-	Notes     string         `gorm:"type:text" json:"notes"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	SetID        uint           `gorm:"not null;index" json:"set_id"`
+	SetCopyID    uint           `gorm:"index" json:"set_copy_id"`
+	PartID       uint           `gorm:"not null;index" json:"part_id"`
+	ColorID      int            `gorm:"not null;index" json:"color_id"`
+	Quantity     int            `gorm:"not null;default:1" json:"quantity"`
+	IsMissing    bool           `gorm:"default:true" json:"is_missing"`
+	IsSpare      bool           `gorm:"default:false" json:"is_spare"`
+	SetMinifigID *uint          `gorm:"index" json:"set_minifig_id,omitempty"`
+	Orphaned     bool           `gorm:"default:false;index" json:"orphaned"`
+	Notes        string         `gorm:"type:text" json:"notes"`
+	CreatedAt    time.Time      `gorm:"index" json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Set  Set  `gorm:"foreignKey:SetID" json:"set,omitempty"`
-	Part Part `gorm:"foreignKey:PartID" json:"part,omitempty"`
+	Set        Set                   `gorm:"foreignKey:SetID" json:"set,omitempty"`
+	SetCopy    *SetCopy              `gorm:"foreignKey:SetCopyID" json:"set_copy,omitempty"`
+	Part       Part                  `gorm:"foreignKey:PartID" json:"part,omitempty"`
+	Color      Color                 `gorm:"foreignKey:ColorID" json:"color"`
+	Recoveries []MissingPartRecovery `gorm:"foreignKey:MissingPartID" json:"recoveries,omitempty"`
+}
+
+// Recovery actions recorded in the history of a missing part
+const (
+	RecoveryActionFound    = "found"
+	RecoveryActionReopened = "reopened"
+)
+
+// MissingPartRecovery records pieces of a missing part that were found, or reopened as missing again
+type MissingPartRecovery struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	MissingPartID uint      `gorm:"not null;index" json:"missing_part_id"`
+	Action        string    `gorm:"not null" json:"action"`
+	Quantity      int       `gorm:"not null" json:"quantity"`
+	Notes         string    `gorm:"type:text" json:"notes"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // SetPart represents a part that belongs to a specific set with quantity and color
@@ -46,18 +67,17 @@ type SetPart struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	SetID     uint           `gorm:"not null;index" json:"set_id"`
 	PartID    uint           `gorm:"not null;index" json:"part_id"`
-	ColorID   int            `gorm:"not null" json:"color_id"`
-	ColorName string         `json:"color_name"`
-	ColorHex  string         `json:"color_hex"`
+	ColorID   int            `gorm:"not null;index" json:"color_id"`
 	Quantity  int            `gorm:"not null;default:1" json:"quantity"`
 	IsSpare   bool           `gorm:"default:false" json:"is_spare"`
-	CreatedAt time.Time      `json:"created_at"`
+	CreatedAt time.Time      `gorm:"index" json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Set  Set  `gorm:"foreignKey:SetID" json:"-"`
-	Part Part `gorm:"foreignKey:PartID" json:"part,omitempty"`
+	Set   Set   `gorm:"foreignKey:SetID" json:"-"`
+	Part  Part  `gorm:"foreignKey:PartID" json:"part,omitempty"`
+	Color Color `gorm:"foreignKey:ColorID" json:"color"`
 }
 
 // TableName overrides the table name used by GORM
@@ -0,0 +1,61 @@
+package entity
+
+import "time"
+
+// RebrickableAccount is the Rebrickable user whose collection is synced. There is at most one.
+type RebrickableAccount struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Username     string     `json:"username"`
+	UserToken    string     `gorm:"not null" json:"-"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RebrickableLostPart links a lost part on Rebrickable to a local missing part. SyncedQuantity is
+// the quantity both sides agreed on at the last sync, so that a change on either side can be told
+// apart from a conflicting change on both.
+type RebrickableLostPart struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	LostPartID     int       `gorm:"index" json:"lost_part_id"`
+	InvPartID      int       `gorm:"index" json:"inv_part_id"`
+	MissingPartID  uint      `gorm:"not null;index" json:"missing_part_id"`
+	SetNum         string    `json:"set_num"`
+	PartNum        string    `json:"part_num"`
+	ColorID        int       `json:"color_id"`
+	IsSpare        bool      `json:"is_spare"`
+	SyncedQuantity int       `json:"synced_quantity"`
+	Conflict       bool      `gorm:"default:false;index" json:"conflict"`
+	LocalQuantity  int       `json:"local_quantity"`
+	RemoteQuantity int       `json:"remote_quantity"`
+	SyncedAt       time.Time `json:"synced_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// RebrickablePartListItem remembers how many pieces of a part list line were already added to the
+// loose parts, so that a sync only adds what was added to the list since
+type RebrickablePartListItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ListID    int       `gorm:"not null;uniqueIndex:idx_rebrickable_part_list_items_line" json:"list_id"`
+	PartNum   string    `gorm:"not null;uniqueIndex:idx_rebrickable_part_list_items_line" json:"part_num"`
+	ColorID   int       `gorm:"not null;uniqueIndex:idx_rebrickable_part_list_items_line" json:"color_id"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name used by GORM
+func (RebrickableAccount) TableName() string {
+	return "rebrickable_accounts"
+}
+
+// TableName overrides the table name used by GORM
+func (RebrickableLostPart) TableName() string {
+	return "rebrickable_lost_parts"
+}
+
+// TableName overrides the table name used by GORM
+func (RebrickablePartListItem) TableName() string {
+	return "rebrickable_part_list_items"
+}
@@ -0,0 +1,51 @@
+package entity
+
+import "time"
+
+// Refresh run triggers and statuses
+const (
+	RefreshTriggerScheduled = "scheduled"
+	RefreshTriggerManual    = "manual"
+
+	RefreshStatusRunning = "running"
+	RefreshStatusDone    = "done"
+	RefreshStatusFailed  = "failed"
+)
+
+// RefreshRun is one pass of the background refresh of stale sets from Rebrickable
+type RefreshRun struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Trigger      string     `gorm:"not null" json:"trigger"`
+	Status       string     `gorm:"not null;index" json:"status"`
+	SetsChecked  int        `json:"sets_checked"`
+	SetsModified int        `json:"sets_modified"`
+	SetsFailed   int        `json:"sets_failed"`
+	Error        string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt    time.Time  `gorm:"index" json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+// SetRefresh is the state of the background refresh of a set. Failures counts the refreshes that
+// failed in a row, and is reset by the next successful one.
+type SetRefresh struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	SetID       uint       `gorm:"not null;uniqueIndex" json:"set_id"`
+	CheckedAt   *time.Time `gorm:"index" json:"checked_at"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at"`
+	Failures    int        `gorm:"default:0" json:"failures"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relations
+	Set *Set `gorm:"foreignKey:SetID" json:"set,omitempty"`
+}
+
+// TableName overrides the table name used by GORM
+func (RefreshRun) TableName() string {
+	return "refresh_runs"
+}
+
+// TableName overrides the table name used by GORM
+func (SetRefresh) TableName() string {
+	return "set_refreshes"
+}
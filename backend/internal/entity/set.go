@@ -10,22 +10,50 @@ import (
 type Set struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
 	SetNum       string         `gorm:"uniqueIndex;not null" json:"set_num"`
-	Name         string         `gorm:"not null" json:"name"`
-	Year         int            `json:"year"`
-	ThemeID      int            `json:"theme_id"`
-	NumParts     int            `json:"num_parts"`
+	Name         string         `gorm:"not null;index" json:"name"`
+	Year         int            `gorm:"index" json:"year"`
+	ThemeID      int            `gorm:"index" json:"theme_id"`
+	NumParts     int            `gorm:"index" json:"num_parts"`
 	SetImageURL  string         `json:"set_img_url"`
 	SetURL       string         `json:"set_url"`
 	LastModified time.Time      `json:"last_modified_dt"`
-	CreatedAt    time.Time      `json:"created_at"`
+	CheckedAt    *time.Time     `json:"checked_at"`
+	IsDonor      bool           `gorm:"default:false;index" json:"is_donor"`
+	ImportStatus string         `gorm:"default:done;index" json:"import_status"`
+	CreatedAt    time.Time      `gorm:"index" json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Completeness of all owned copies, computed from set_parts and missing_parts when the set is loaded
+	Copies         int     `gorm:"->;-:migration" json:"copies"`
+	PiecesRequired int     `gorm:"->;-:migration" json:"pieces_required"`
+	PiecesMissing  int     `gorm:"->;-:migration" json:"pieces_missing"`
+	MissingLots    int     `gorm:"->;-:migration" json:"missing_lots"`
+	Completeness   float64 `gorm:"->;-:migration" json:"completeness"`
+	Status         string  `gorm:"->;-:migration" json:"status"`
+
 	// Relations
-	MissingParts []MissingPart `gorm:"foreignKey:SetID" json:"missing_parts,omitempty"`
-	SetParts     []SetPart     `gorm:"foreignKey:SetID" json:"set_parts,omitempty"`
+	Theme           *Theme           `gorm:"foreignKey:ThemeID" json:"theme,omitempty"`
+	MissingParts    []MissingPart    `gorm:"foreignKey:SetID" json:"missing_parts,omitempty"`
+	SetParts        []SetPart        `gorm:"foreignKey:SetID" json:"set_parts,omitempty"`
+	SetMinifigs     []SetMinifig     `gorm:"foreignKey:SetID" json:"set_minifigs,omitempty"`
+	MissingMinifigs []MissingMinifig `gorm:"foreignKey:SetID" json:"missing_minifigs,omitempty"`
 }
 
+// Set statuses derived from the completeness of a set
+const (
+	SetStatusComplete   = "complete"
+	SetStatusIncomplete = "incomplete"
+	SetStatusUnchecked  = "unchecked"
+)
+
+// Set import statuses: parts and minifigs of a set are imported in the background after the set
+const (
+	SetImportStatusImporting = "importing"
+	SetImportStatusFailed    = "failed"
+	SetImportStatusDone      = "done"
+)
+
 // TableName overrides the table name used by GORM
 func (Set) TableName() string {
 	return "sets"
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SetCopy represents an owned copy of a set. Each copy records its own missing parts and minifigs.
+type SetCopy struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	SetID      uint           `gorm:"not null;index" json:"set_id"`
+	Label      string         `json:"label"`
+	Condition  string         `json:"condition"`
+	AcquiredAt *time.Time     `json:"acquired_at"`
+	Notes      string         `gorm:"type:text" json:"notes"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Where the copy is stored
+	StorageLocationID *uint `gorm:"index" json:"storage_location_id"`
+
+	// Missing pieces of the copy, computed when the copy is loaded
+	PiecesMissing int `gorm:"->;-:migration" json:"pieces_missing"`
+	MissingLots   int `gorm:"->;-:migration" json:"missing_lots"`
+
+	// Relations
+	Set             *Set             `gorm:"foreignKey:SetID" json:"set,omitempty"`
+	StorageLocation *StorageLocation `gorm:"foreignKey:StorageLocationID" json:"storage_location,omitempty"`
+	MissingParts    []MissingPart    `gorm:"foreignKey:SetCopyID" json:"missing_parts,omitempty"`
+	MissingMinifigs []MissingMinifig `gorm:"foreignKey:SetCopyID" json:"missing_minifigs,omitempty"`
+}
+
+// Conditions of an owned set copy
+const (
+	SetCopyConditionSealed = "sealed"
+	SetCopyConditionNew    = "new"
+	SetCopyConditionUsed   = "used"
+)
+
+// TableName overrides the table name used by GORM
+func (SetCopy) TableName() string {
+	return "set_copies"
+}
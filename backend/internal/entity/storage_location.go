@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// StorageLocation represents a place where owned sets and loose parts are stored. Locations nest
+// from rooms down to shelves, drawers and bins.
+type StorageLocation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ParentID  *uint     `gorm:"index" json:"parent_id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Kind      string    `json:"kind"`
+	Path      string    `gorm:"index" json:"path"`
+	Notes     string    `gorm:"type:text" json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Children []StorageLocation `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+}
+
+// Kinds of storage location, from the outermost to the innermost
+const (
+	StorageLocationKindRoom   = "room"
+	StorageLocationKindShelf  = "shelf"
+	StorageLocationKindDrawer = "drawer"
+	StorageLocationKindBin    = "bin"
+)
+
+// StorageLocationPathSeparator separates the names of the ancestors of a location in its path
+const StorageLocationPathSeparator = " > "
+
+// TableName overrides the table name used by GORM
+func (StorageLocation) TableName() string {
+	return "storage_locations"
+}
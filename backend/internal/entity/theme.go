@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Theme represents a LEGO theme from Rebrickable API. Themes form a tree through ParentID.
+type Theme struct {
+	ID        int       `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	ParentID  *int      `gorm:"index" json:"parent_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Parent   *Theme  `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	Children []Theme `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+}
+
+// TableName overrides the table name used by GORM
+func (Theme) TableName() string {
+	return "themes"
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Number of ranked buildable sets returned by default and at most
+const (
+	defaultBuildableSetsLimit = 20
+	maxBuildableSetsLimit     = 100
+)
+
+// BuildabilityHandler handles HTTP requests comparing the parts we own with set inventories
+type BuildabilityHandler struct {
+	buildabilityService service.BuildabilityService
+}
+
+// NewBuildabilityHandler creates a new buildability handler
+func NewBuildabilityHandler(buildabilityService service.BuildabilityService) *BuildabilityHandler {
+	return &BuildabilityHandler{
+		buildabilityService: buildabilityService,
+	}
+}
+
+// GetBuildability handles GET /buildability/:set_num?include_sets=
+func (h *BuildabilityHandler) GetBuildability(c *gin.Context) {
+	includeSets, ok := bindIncludeSets(c)
+	if !ok {
+		return
+	}
+
+	buildability, err := h.buildabilityService.GetBuildability(c.Param("set_num"), includeSets)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, buildability)
+}
+
+// RankBuildableSets handles GET /buildability?limit=&min_completeness=&include_sets=
+func (h *BuildabilityHandler) RankBuildableSets(c *gin.Context) {
+	includeSets, ok := bindIncludeSets(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultBuildableSetsLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	limit = min(limit, maxBuildableSetsLimit)
+
+	var minCompleteness *float64
+	if !bindOptionalFloat(c, "min_completeness", &minCompleteness) {
+		return
+	}
+	if minCompleteness == nil {
+		minCompleteness = new(float64)
+	}
+
+	sets, err := h.buildabilityService.RankBuildableSets(includeSets, *minCompleteness, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sets": sets, "limit": limit})
+}
+
+// bindIncludeSets reads the include_sets query parameter, true by default.
+// It writes a 400 response and returns false when the value is invalid.
+func bindIncludeSets(c *gin.Context) (bool, bool) {
+	var includeSets *bool
+	if !bindOptionalBool(c, "include_sets", &includeSets) {
+		return false, false
+	}
+	return includeSets == nil || *includeSets, true
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ColorHandler handles HTTP requests for colors
+type ColorHandler struct {
+	colorService service.ColorService
+}
+
+// NewColorHandler creates a new color handler
+func NewColorHandler(colorService service.ColorService) *ColorHandler {
+	return &ColorHandler{
+		colorService: colorService,
+	}
+}
+
+// GetAllColors handles GET /colors, optionally filtered with ?is_trans=true|false
+func (h *ColorHandler) GetAllColors(c *gin.Context) {
+	var isTrans *bool
+	if isTransStr := c.Query("is_trans"); isTransStr != "" {
+		value, err := strconv.ParseBool(isTransStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid is_trans value"})
+			return
+		}
+		isTrans = &value
+	}
+
+	colors, err := h.colorService.GetAllColors(isTrans)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"colors": colors})
+}
+
+// GetColorByID handles GET /colors/:id
+func (h *ColorHandler) GetColorByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color ID"})
+		return
+	}
+
+	color, err := h.colorService.GetColorByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Color not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, color)
+}
+
+// SyncColors handles POST /colors/sync
+func (h *ColorHandler) SyncColors(c *gin.Context) {
+	count, err := h.colorService.SyncColorsFromRebrickable()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Colors synchronized successfully", "count": count})
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errorStatus maps a service error to the HTTP status code returned to clients
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRebrickableNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRebrickableUnauthorized):
+		return http.StatusBadGateway
+	case errors.Is(err, service.ErrRebrickableThrottled):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrInvalidMissingQuantity), errors.Is(err, service.ErrMissingQuantityExceeded),
+		errors.Is(err, service.ErrInvalidLoosePart):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrInvalidSearch), errors.Is(err, repository.ErrInvalidListQuery),
+		errors.Is(err, service.ErrInvalidSetCopy), errors.Is(err, service.ErrInvalidStorageLocation),
+		errors.Is(err, service.ErrRebrickableNotLinked), errors.Is(err, service.ErrInvalidRebrickableSync),
+		errors.Is(err, service.ErrInvalidSetList), errors.Is(err, service.ErrInvalidMissingPart),
+		errors.Is(err, service.ErrInvalidLoosePartsImport), errors.Is(err, service.ErrInvalidMissingPartsImport):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrIdempotencyKeyReused), errors.Is(err, service.ErrStorageLocationNotEmpty),
+		errors.Is(err, service.ErrRefreshRunning), errors.Is(err, service.ErrSetAlreadyExists),
+		errors.Is(err, service.ErrSetInventoryMissing):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes err as a JSON error response with the matching HTTP status
+func respondError(c *gin.Context, err error) {
+	var rbErr *service.RebrickableError
+	if errors.As(err, &rbErr) && rbErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(rbErr.RetryAfter.Seconds()))))
+	}
+
+	c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles HTTP requests exporting missing parts to brick marketplaces
+type ExportHandler struct {
+	exportService service.MissingPartsExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService service.MissingPartsExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportBrickLinkWantedList handles GET /missing-parts/export/bricklink
+func (h *ExportHandler) ExportBrickLinkWantedList(c *gin.Context) {
+	filter, ok := bindMissingPartsSummaryFilter(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.ExportBrickLinkWantedList(filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondExport(c, export)
+}
+
+// ExportRebrickableCSV handles GET /missing-parts/export/rebrickable
+func (h *ExportHandler) ExportRebrickableCSV(c *gin.Context) {
+	filter, ok := bindMissingPartsSummaryFilter(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.ExportRebrickableCSV(filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondExport(c, export)
+}
+
+// ExportBrickOwlWishlist handles GET /missing-parts/export/brickowl
+func (h *ExportHandler) ExportBrickOwlWishlist(c *gin.Context) {
+	filter, ok := bindMissingPartsSummaryFilter(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.ExportBrickOwlWishlist(filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondExport(c, export)
+}
+
+// respondExport returns the export as JSON, or as a file download when ?download=true.
+// Downloads report the number of unmapped rows in the X-Unmapped-Rows header.
+func respondExport(c *gin.Context, export *service.MissingPartsExport) {
+	if download, _ := strconv.ParseBool(c.Query("download")); !download {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName))
+	c.Header("X-Unmapped-Rows", strconv.Itoa(len(export.Unmapped)))
+	c.Data(http.StatusOK, export.ContentType, []byte(export.Content))
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// FulfillmentHandler handles HTTP requests for covering missing parts from loose parts and donor sets
+type FulfillmentHandler struct {
+	fulfillmentService service.FulfillmentService
+}
+
+// NewFulfillmentHandler creates a new fulfillment handler
+func NewFulfillmentHandler(fulfillmentService service.FulfillmentService) *FulfillmentHandler {
+	return &FulfillmentHandler{
+		fulfillmentService: fulfillmentService,
+	}
+}
+
+// GetFulfillmentPlan handles GET /missing-parts/fulfillment. Supports ?set_id=, ?missing_part_id=
+// and ?donor_set_id= (all repeatable) and ?alternates=true.
+func (h *FulfillmentHandler) GetFulfillmentPlan(c *gin.Context) {
+	var options service.FulfillmentOptions
+	if !bindUintArray(c, "set_id", &options.SetIDs) || !bindUintArray(c, "missing_part_id", &options.MissingPartIDs) ||
+		!bindUintArray(c, "donor_set_id", &options.DonorSetIDs) {
+		return
+	}
+
+	var alternates *bool
+	if !bindOptionalBool(c, "alternates", &alternates) {
+		return
+	}
+	options.Alternates = alternates != nil && *alternates
+
+	plan, err := h.fulfillmentService.PlanFulfillment(options)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// ApplyFulfillment handles POST /missing-parts/fulfillment/apply
+func (h *FulfillmentHandler) ApplyFulfillment(c *gin.Context) {
+	var options service.FulfillmentOptions
+	if err := c.ShouldBindJSON(&options); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.fulfillmentService.ApplyFulfillment(options)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ImportJobHandler handles HTTP requests for import jobs
+type ImportJobHandler struct {
+	importJobService service.ImportJobService
+}
+
+// NewImportJobHandler creates a new import job handler
+func NewImportJobHandler(importJobService service.ImportJobService) *ImportJobHandler {
+	return &ImportJobHandler{
+		importJobService: importJobService,
+	}
+}
+
+// GetJob handles GET /jobs/:id
+func (h *ImportJobHandler) GetJob(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.importJobService.GetJob(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
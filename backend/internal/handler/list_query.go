@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// bindListQuery reads the limit, cursor, sort and order query parameters.
+// It writes a 400 response and returns false when one of them is invalid.
+func bindListQuery(c *gin.Context) (repository.ListQuery, bool) {
+	query := repository.ListQuery{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return query, false
+	}
+	query.Limit = limit
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order, expected asc or desc"})
+		return query, false
+	}
+
+	return query, true
+}
+
+// bindOptionalInt reads an optional integer query parameter into target.
+// It writes a 400 response and returns false when the value is invalid.
+func bindOptionalInt(c *gin.Context, name string, target **int) bool {
+	value := c.Query(name)
+	if value == "" {
+		return true
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return false
+	}
+	*target = &n
+	return true
+}
+
+// bindOptionalBool reads an optional boolean query parameter into target.
+// It writes a 400 response and returns false when the value is invalid.
+func bindOptionalBool(c *gin.Context, name string, target **bool) bool {
+	value := c.Query(name)
+	if value == "" {
+		return true
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return false
+	}
+	*target = &b
+	return true
+}
+
+// bindOptionalFloat reads an optional decimal query parameter into target.
+// It writes a 400 response and returns false when the value is invalid.
+func bindOptionalFloat(c *gin.Context, name string, target **float64) bool {
+	value := c.Query(name)
+	if value == "" {
+		return true
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return false
+	}
+	*target = &f
+	return true
+}
+
+// bindOptionalUint reads an optional ID query parameter into target.
+// It writes a 400 response and returns false when the value is invalid.
+func bindOptionalUint(c *gin.Context, name string, target **uint) bool {
+	value := c.Query(name)
+	if value == "" {
+		return true
+	}
+
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return false
+	}
+	id := uint(n)
+	*target = &id
+	return true
+}
+
+// bindUintArray reads a repeatable ID query parameter into target.
+// It writes a 400 response and returns false when one of the values is invalid.
+func bindUintArray(c *gin.Context, name string, target *[]uint) bool {
+	for _, value := range c.QueryArray(name) {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return false
+		}
+		*target = append(*target, uint(id))
+	}
+	return true
+}
+
+// pageResponse wraps the items of a page under key, together with the pagination fields
+func pageResponse[T any](key string, page *repository.Page[T]) gin.H {
+	return gin.H{
+		key:           page.Items,
+		"total":       page.Total,
+		"limit":       page.Limit,
+		"next_cursor": page.NextCursor,
+	}
+}
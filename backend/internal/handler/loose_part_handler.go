@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// LoosePartHandler handles HTTP requests for the loose parts inventory
+type LoosePartHandler struct {
+	loosePartService service.LoosePartService
+}
+
+// NewLoosePartHandler creates a new loose part handler
+func NewLoosePartHandler(loosePartService service.LoosePartService) *LoosePartHandler {
+	return &LoosePartHandler{
+		loosePartService: loosePartService,
+	}
+}
+
+// GetLooseParts handles GET /loose-parts
+func (h *LoosePartHandler) GetLooseParts(c *gin.Context) {
+	query, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.LoosePartFilter{
+		PartNum: c.Query("part_num"),
+	}
+	if !bindOptionalInt(c, "color_id", &filter.ColorID) || !bindOptionalUint(c, "storage_location_id", &filter.StorageLocationID) {
+		return
+	}
+
+	page, err := h.loosePartService.GetLooseParts(filter, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse("loose_parts", page))
+}
+
+// GetLoosePart handles GET /loose-parts/:id
+func (h *LoosePartHandler) GetLoosePart(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loose part ID"})
+		return
+	}
+
+	loosePart, err := h.loosePartService.GetLoosePart(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loosePart)
+}
+
+// AddLoosePart handles POST /loose-parts
+func (h *LoosePartHandler) AddLoosePart(c *gin.Context) {
+	var req service.LoosePartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loosePart, err := h.loosePartService.AddLoosePart(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loosePart)
+}
+
+// UpdateLoosePart handles PUT /loose-parts/:id
+func (h *LoosePartHandler) UpdateLoosePart(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loose part ID"})
+		return
+	}
+
+	var req service.LoosePartUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loosePart, err := h.loosePartService.UpdateLoosePart(uint(id), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loosePart)
+}
+
+// DeleteLoosePart handles DELETE /loose-parts/:id
+func (h *LoosePartHandler) DeleteLoosePart(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loose part ID"})
+		return
+	}
+
+	if err := h.loosePartService.DeleteLoosePart(uint(id)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Loose part deleted successfully"})
+}
+
+// ImportLooseParts handles POST /loose-parts/import?format=&storage_location_id=
+func (h *LoosePartHandler) ImportLooseParts(c *gin.Context) {
+	content, fileName, err := readImportFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := detectLoosePartsImportFormat(c.Query("format"), c.ContentType(), fileName, content)
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown import format, use ?format=json, ?format=rebrickable or ?format=bricklink"})
+		return
+	}
+
+	var storageLocationID *uint
+	if !bindOptionalUint(c, "storage_location_id", &storageLocationID) {
+		return
+	}
+
+	report, err := h.loosePartService.ImportLooseParts(format, storageLocationID, bytes.NewReader(content))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// detectLoosePartsImportFormat recognizes JSON lists before falling back to the missing parts import formats
+func detectLoosePartsImportFormat(format, contentType, fileName string, content []byte) string {
+	if strings.ToLower(format) == service.ImportFormatJSON {
+		return service.ImportFormatJSON
+	}
+	if format == "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/json" {
+			return service.ImportFormatJSON
+		}
+		if strings.HasSuffix(strings.ToLower(fileName), ".json") || bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+			return service.ImportFormatJSON
+		}
+	}
+	return detectImportFormat(format, contentType, fileName, content)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MinifigHandler handles HTTP requests for minifigs
+type MinifigHandler struct {
+	minifigService service.MinifigService
+}
+
+// NewMinifigHandler creates a new minifig handler
+func NewMinifigHandler(minifigService service.MinifigService) *MinifigHandler {
+	return &MinifigHandler{
+		minifigService: minifigService,
+	}
+}
+
+// GetSetMinifigs handles GET /set-minifigs/:id
+func (h *MinifigHandler) GetSetMinifigs(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	setMinifigs, err := h.minifigService.GetSetMinifigs(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"set_minifigs": setMinifigs})
+}
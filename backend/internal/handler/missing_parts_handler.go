@@ -1,9 +1,16 @@
 package handler
 
 import (
+	"bytes"
+	"io"
+	"mime"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
 	"github.com/BombartSimon/MissingBrick/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -18,10 +25,12 @@ func NewMissingPartsHandler(missingPartsService service.MissingPartsService) *Mi
 	}
 }
 
-// AssignMissingPartsToSet handles the assignment of missing parts to a set with specific quantities
+// AssignMissingPartsToSet handles the assignment of missing parts to a set with specific quantities.
+// Retried requests sent with the same Idempotency-Key header are only applied once.
 func (h *MissingPartsHandler) AssignMissingPartsToSet(c *gin.Context) {
 	var req struct {
 		SetID        int                          `json:"set_id" binding:"required"`
+		SetCopyID    uint                         `json:"set_copy_id"`
 		PartRequests []service.MissingPartRequest `json:"part_requests" binding:"required"`
 	}
 
@@ -30,16 +39,134 @@ func (h *MissingPartsHandler) AssignMissingPartsToSet(c *gin.Context) {
 		return
 	}
 
-	missingParts, err := h.missingPartsService.AssignMissingPartsToSet(req.SetID, req.PartRequests)
+	missingParts, err := h.missingPartsService.AssignMissingPartsToSet(req.SetID, req.SetCopyID, req.PartRequests, c.GetHeader("Idempotency-Key"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, missingParts)
 }
 
-// GetMissingPartsBySetID handles GET
+// AssignMissingMinifigsToSet handles the assignment of whole missing minifigs to a set
+func (h *MissingPartsHandler) AssignMissingMinifigsToSet(c *gin.Context) {
+	var req struct {
+		SetID           int                             `json:"set_id" binding:"required"`
+		SetCopyID       uint                            `json:"set_copy_id"`
+		MinifigRequests []service.MissingMinifigRequest `json:"minifig_requests" binding:"required,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	missingMinifigs, err := h.missingPartsService.AssignMissingMinifigsToSet(req.SetID, req.SetCopyID, req.MinifigRequests)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, missingMinifigs)
+}
+
+// ImportMissingParts handles POST /sets/:id/missing-parts/import, into the copy ?set_copy_id= or the
+// oldest copy. The file is sent either as the multipart field "file" or as the raw request body.
+func (h *MissingPartsHandler) ImportMissingParts(c *gin.Context) {
+	setIDStr := c.Param("id")
+	setID, err := strconv.Atoi(setIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	setCopyID, err := strconv.ParseUint(c.DefaultQuery("set_copy_id", "0"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set copy ID"})
+		return
+	}
+
+	content, fileName, err := readImportFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := detectImportFormat(c.Query("format"), c.ContentType(), fileName, content)
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown import format, use ?format=rebrickable or ?format=bricklink"})
+		return
+	}
+
+	report, err := h.missingPartsService.ImportMissingParts(setID, uint(setCopyID), format, bytes.NewReader(content))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// recoveryRequest is the optional body of the found and reopen endpoints
+type recoveryRequest struct {
+	Quantity *int   `json:"quantity"`
+	Notes    string `json:"notes"`
+}
+
+// MarkPartAsFound handles PUT /missing-parts/:missing_part_id/found
+func (h *MissingPartsHandler) MarkPartAsFound(c *gin.Context) {
+	h.recordRecovery(c, h.missingPartsService.MarkPartAsFound)
+}
+
+// ReopenMissingPart handles PUT /missing-parts/:missing_part_id/reopen
+func (h *MissingPartsHandler) ReopenMissingPart(c *gin.Context) {
+	h.recordRecovery(c, h.missingPartsService.ReopenMissingPart)
+}
+
+// recordRecovery binds a recovery request and applies it with record
+func (h *MissingPartsHandler) recordRecovery(c *gin.Context, record func(missingPartID int, quantity *int, notes string) (*entity.MissingPart, error)) {
+	missingPartIDStr := c.Param("missing_part_id")
+	missingPartID, err := strconv.Atoi(missingPartIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid missing part ID"})
+		return
+	}
+
+	var req recoveryRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	missingPart, err := record(missingPartID, req.Quantity, req.Notes)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, missingPart)
+}
+
+// GetMissingPartsSummary handles GET /missing-parts/summary
+func (h *MissingPartsHandler) GetMissingPartsSummary(c *gin.Context) {
+	filter, ok := bindMissingPartsSummaryFilter(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.missingPartsService.GetMissingPartsSummary(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetMissingPartsBySetID handles GET /missing-parts/:set_id. Supports ?set_copy_id=, ?color_id=,
+// ?is_spare=, ?orphaned= and the list parameters limit, cursor, sort and order.
 func (h *MissingPartsHandler) GetMissingPartsBySetID(c *gin.Context) {
 	setIDStr := c.Param("set_id")
 	setID, err := strconv.Atoi(setIDStr)
@@ -48,13 +175,32 @@ func (h *MissingPartsHandler) GetMissingPartsBySetID(c *gin.Context) {
 		return
 	}
 
-	missingParts, err := h.missingPartsService.GetMissingPartsBySetID(setID)
+	query, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	var filter repository.MissingPartFilter
+	if !bindOptionalInt(c, "color_id", &filter.ColorID) || !bindOptionalBool(c, "is_spare", &filter.IsSpare) ||
+		!bindOptionalBool(c, "orphaned", &filter.Orphaned) {
+		return
+	}
+	if setCopyIDStr := c.Query("set_copy_id"); setCopyIDStr != "" {
+		setCopyID, err := strconv.ParseUint(setCopyIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set copy ID"})
+			return
+		}
+		filter.SetCopyIDs = []uint{uint(setCopyID)}
+	}
+
+	page, err := h.missingPartsService.GetMissingPartsBySetID(setID, filter, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, missingParts)
+	c.JSON(http.StatusOK, pageResponse("missing_parts", page))
 }
 
 func (h *MissingPartsHandler) DeleteMissingPart(c *gin.Context) {
@@ -73,3 +219,130 @@ func (h *MissingPartsHandler) DeleteMissingPart(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Missing part deleted successfully"})
 }
+
+// GetMissingMinifigsBySetID handles GET /missing-parts/minifigs/:set_id
+func (h *MissingPartsHandler) GetMissingMinifigsBySetID(c *gin.Context) {
+	setIDStr := c.Param("set_id")
+	setID, err := strconv.Atoi(setIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	missingMinifigs, err := h.missingPartsService.GetMissingMinifigsBySetID(setID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, missingMinifigs)
+}
+
+// DeleteMissingMinifig handles DELETE /missing-parts/minifigs/:missing_minifig_id
+func (h *MissingPartsHandler) DeleteMissingMinifig(c *gin.Context) {
+	missingMinifigIDStr := c.Param("missing_minifig_id")
+	missingMinifigID, err := strconv.Atoi(missingMinifigIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid missing minifig ID"})
+		return
+	}
+
+	err = h.missingPartsService.DeleteMissingMinifig(missingMinifigID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Missing minifig deleted successfully"})
+}
+
+// bindMissingPartsSummaryFilter reads the theme_id, set_id (repeatable) and part_cat_id query parameters.
+// It writes a 400 response and returns false when one of them is invalid.
+func bindMissingPartsSummaryFilter(c *gin.Context) (service.MissingPartsSummaryFilter, bool) {
+	var filter service.MissingPartsSummaryFilter
+
+	if themeIDStr := c.Query("theme_id"); themeIDStr != "" {
+		themeID, err := strconv.Atoi(themeIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid theme ID"})
+			return filter, false
+		}
+		filter.ThemeID = themeID
+	}
+
+	for _, setIDStr := range c.QueryArray("set_id") {
+		setID, err := strconv.ParseUint(setIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+			return filter, false
+		}
+		filter.SetIDs = append(filter.SetIDs, uint(setID))
+	}
+
+	if partCatIDStr := c.Query("part_cat_id"); partCatIDStr != "" {
+		partCatID, err := strconv.Atoi(partCatIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid part category ID"})
+			return filter, false
+		}
+		filter.PartCatID = partCatID
+	}
+
+	return filter, true
+}
+
+// readImportFile returns the uploaded file content and name, from a multipart form or the raw body
+func readImportFile(c *gin.Context) ([]byte, string, error) {
+	if c.ContentType() == "multipart/form-data" {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		return content, fileHeader.Filename, err
+	}
+
+	content, err := io.ReadAll(c.Request.Body)
+	return content, "", err
+}
+
+// detectImportFormat picks the import format from the explicit format parameter, the content type,
+// the file extension, or finally the content itself
+func detectImportFormat(format, contentType, fileName string, content []byte) string {
+	switch strings.ToLower(format) {
+	case service.ImportFormatRebrickableCSV, "csv":
+		return service.ImportFormatRebrickableCSV
+	case service.ImportFormatBrickLinkXML, "xml":
+		return service.ImportFormatBrickLinkXML
+	case "":
+	default:
+		return ""
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "text/csv":
+			return service.ImportFormatRebrickableCSV
+		case "application/xml", "text/xml":
+			return service.ImportFormatBrickLinkXML
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return service.ImportFormatRebrickableCSV
+	case ".xml":
+		return service.ImportFormatBrickLinkXML
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		return service.ImportFormatBrickLinkXML
+	}
+	return service.ImportFormatRebrickableCSV
+}
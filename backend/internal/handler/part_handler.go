@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// PartHandler handles HTTP requests for parts
+type PartHandler struct {
+	partService service.PartService
+}
+
+// NewPartHandler creates a new part handler
+func NewPartHandler(partService service.PartService) *PartHandler {
+	return &PartHandler{
+		partService: partService,
+	}
+}
+
+// GetAllParts handles GET /parts?offset=&limit=
+func (h *PartHandler) GetAllParts(c *gin.Context) {
+	offset, limit, ok := bindPartPage(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.partService.GetParts(offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetPartByID handles GET /parts/:id
+func (h *PartHandler) GetPartByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid part ID"})
+		return
+	}
+
+	part, err := h.partService.GetPartByID(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, part)
+}
+
+// SearchParts handles GET /parts/search?q=
+func (h *PartHandler) SearchParts(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	offset, limit, ok := bindPartPage(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.partService.SearchParts(query, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// bindPartPage reads the offset and limit query parameters.
+// It writes a 400 response and returns false when one of them is invalid.
+func bindPartPage(c *gin.Context) (int, int, bool) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return 0, 0, false
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return 0, 0, false
+	}
+
+	return offset, limit, true
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RebrickableSyncHandler handles HTTP requests for the sync with a Rebrickable account
+type RebrickableSyncHandler struct {
+	rebrickableSyncService service.RebrickableSyncService
+}
+
+// NewRebrickableSyncHandler creates a new Rebrickable sync handler
+func NewRebrickableSyncHandler(rebrickableSyncService service.RebrickableSyncService) *RebrickableSyncHandler {
+	return &RebrickableSyncHandler{
+		rebrickableSyncService: rebrickableSyncService,
+	}
+}
+
+// conflictResolution is the body of POST /rebrickable/conflicts/:id/resolve
+type conflictResolution struct {
+	Keep string `json:"keep" binding:"required"`
+}
+
+// GetAccount handles GET /rebrickable/account
+func (h *RebrickableSyncHandler) GetAccount(c *gin.Context) {
+	account, err := h.rebrickableSyncService.GetAccount()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// LinkAccount handles PUT /rebrickable/account
+func (h *RebrickableSyncHandler) LinkAccount(c *gin.Context) {
+	var req service.RebrickableAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.rebrickableSyncService.LinkAccount(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// UnlinkAccount handles DELETE /rebrickable/account
+func (h *RebrickableSyncHandler) UnlinkAccount(c *gin.Context) {
+	if err := h.rebrickableSyncService.UnlinkAccount(); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rebrickable account unlinked successfully"})
+}
+
+// Sync handles POST /rebrickable/sync
+func (h *RebrickableSyncHandler) Sync(c *gin.Context) {
+	report, err := h.rebrickableSyncService.Sync()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetConflicts handles GET /rebrickable/conflicts
+func (h *RebrickableSyncHandler) GetConflicts(c *gin.Context) {
+	conflicts, err := h.rebrickableSyncService.GetConflicts()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+}
+
+// ResolveConflict handles POST /rebrickable/conflicts/:id/resolve
+func (h *RebrickableSyncHandler) ResolveConflict(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conflict ID"})
+		return
+	}
+
+	var req conflictResolution
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lostPart, err := h.rebrickableSyncService.ResolveConflict(uint(id), req.Keep)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lostPart)
+}
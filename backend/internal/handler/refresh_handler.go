@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRefreshRunsLimit = 20
+	maxRefreshRunsLimit     = 100
+)
+
+// RefreshHandler handles HTTP requests for the background refresh of sets
+type RefreshHandler struct {
+	refreshScheduler service.RefreshScheduler
+}
+
+// NewRefreshHandler creates a new refresh handler
+func NewRefreshHandler(refreshScheduler service.RefreshScheduler) *RefreshHandler {
+	return &RefreshHandler{
+		refreshScheduler: refreshScheduler,
+	}
+}
+
+// GetStatus handles GET /refresh
+func (h *RefreshHandler) GetStatus(c *gin.Context) {
+	status, err := h.refreshScheduler.Status()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Trigger handles POST /refresh/trigger. The run goes on in the background.
+func (h *RefreshHandler) Trigger(c *gin.Context) {
+	run, err := h.refreshScheduler.Trigger()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// Pause handles POST /refresh/pause
+func (h *RefreshHandler) Pause(c *gin.Context) {
+	status, err := h.refreshScheduler.Pause()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Resume handles POST /refresh/resume
+func (h *RefreshHandler) Resume(c *gin.Context) {
+	status, err := h.refreshScheduler.Resume()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetRuns handles GET /refresh/runs?limit=
+func (h *RefreshHandler) GetRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRefreshRunsLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	limit = min(limit, maxRefreshRunsLimit)
+
+	runs, err := h.refreshScheduler.GetRuns(limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs, "limit": limit})
+}
+
+// GetSetRefreshes handles GET /refresh/sets. Supports ?failed=true to only list the sets whose last
+// refresh failed.
+func (h *RefreshHandler) GetSetRefreshes(c *gin.Context) {
+	var failed *bool
+	if !bindOptionalBool(c, "failed", &failed) {
+		return
+	}
+
+	setRefreshes, err := h.refreshScheduler.GetSetRefreshes(failed != nil && *failed)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sets": setRefreshes})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SetCopyHandler handles HTTP requests for owned set copies
+type SetCopyHandler struct {
+	setCopyService service.SetCopyService
+}
+
+// NewSetCopyHandler creates a new set copy handler
+func NewSetCopyHandler(setCopyService service.SetCopyService) *SetCopyHandler {
+	return &SetCopyHandler{
+		setCopyService: setCopyService,
+	}
+}
+
+// CreateCopy handles POST /sets/:id/copies
+func (h *SetCopyHandler) CreateCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	var req service.SetCopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setCopy, err := h.setCopyService.CreateCopy(uint(id), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, setCopy)
+}
+
+// GetCopies handles GET /sets/:id/copies
+func (h *SetCopyHandler) GetCopies(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	copies, err := h.setCopyService.GetCopies(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"copies": copies})
+}
+
+// GetCopy handles GET /copies/:id
+func (h *SetCopyHandler) GetCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set copy ID"})
+		return
+	}
+
+	setCopy, err := h.setCopyService.GetCopy(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setCopy)
+}
+
+// DeleteCopy handles DELETE /copies/:id
+func (h *SetCopyHandler) DeleteCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set copy ID"})
+		return
+	}
+
+	if err := h.setCopyService.DeleteCopy(uint(id)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Set copy deleted successfully"})
+}
@@ -1,26 +1,36 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"github.com/BombartSimon/MissingBrick/internal/entity"
 	"github.com/BombartSimon/MissingBrick/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 // SetHandler handles HTTP requests for sets
 type SetHandler struct {
-	setService service.SetService
+	setService       service.SetService
+	importJobService service.ImportJobService
 }
 
 // NewSetHandler creates a new set handler
-func NewSetHandler(setService service.SetService) *SetHandler {
+func NewSetHandler(setService service.SetService, importJobService service.ImportJobService) *SetHandler {
 	return &SetHandler{
-		setService: setService,
+		setService:       setService,
+		importJobService: importJobService,
 	}
 }
 
-// CreateSet handles POST /sets
+// CreateSet handles POST /sets. The set is imported in the background: the response is the import
+// job, to follow with GET /jobs/:id.
 func (h *SetHandler) CreateSet(c *gin.Context) {
 	var req struct {
 		SetNum string `json:"set_num" binding:"required"`
@@ -31,13 +41,86 @@ func (h *SetHandler) CreateSet(c *gin.Context) {
 		return
 	}
 
-	set, err := h.setService.CreateSetWithParts(req.SetNum)
+	h.enqueueSetImport(c, req.SetNum)
+}
+
+// enqueueSetImport queues the import of a set and responds with the job
+func (h *SetHandler) enqueueSetImport(c *gin.Context, setNum string) {
+	job, err := h.importJobService.EnqueueSetImport(setNum, 1)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ImportSetList handles POST /sets/bulk?format=. Takes a JSON array of set numbers, plain text or a
+// Rebrickable or BrickLink set list CSV, as a multipart file or the raw body.
+func (h *SetHandler) ImportSetList(c *gin.Context) {
+	content, fileName, err := readImportFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := detectSetListFormat(c.Query("format"), c.ContentType(), fileName, content)
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown set list format, use ?format=json, ?format=text or ?format=csv"})
+		return
+	}
+
+	report, err := h.importJobService.ImportSetList(format, bytes.NewReader(content))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, set)
+	c.JSON(http.StatusAccepted, report)
+}
+
+// detectSetListFormat picks the set list format from the explicit format parameter, the content
+// type, the file extension, or finally the content itself
+func detectSetListFormat(format, contentType, fileName string, content []byte) string {
+	switch strings.ToLower(format) {
+	case service.SetListFormatJSON:
+		return service.SetListFormatJSON
+	case service.SetListFormatText, "txt":
+		return service.SetListFormatText
+	case service.SetListFormatCSV, service.SetListFormatRebrickable, service.SetListFormatBrickLink:
+		return service.SetListFormatCSV
+	case "":
+	default:
+		return ""
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/json":
+			return service.SetListFormatJSON
+		case "text/csv":
+			return service.SetListFormatCSV
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		return service.SetListFormatJSON
+	case ".csv":
+		return service.SetListFormatCSV
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return service.SetListFormatJSON
+	}
+	// A CSV export starts with a header naming its set number column
+	header, _, _ := bytes.Cut(bytes.ToLower(trimmed), []byte("\n"))
+	if bytes.Contains(header, []byte("set number")) || bytes.Contains(header, []byte("set_num")) || bytes.Contains(header, []byte("item")) {
+		return service.SetListFormatCSV
+	}
+	return service.SetListFormatText
 }
 
 // GetSetByID handles GET /sets/:id
@@ -71,15 +154,43 @@ func (h *SetHandler) GetSetBySetNum(c *gin.Context) {
 	c.JSON(http.StatusOK, set)
 }
 
-// GetAllSets handles GET /sets
+// GetAllSets handles GET /sets. Supports ?year_min=, ?year_max=, ?theme_id= (sub-themes included),
+// ?has_missing_parts=, ?is_donor=, ?status=, ?completeness_min=, ?completeness_max=, ?include_spares= and the
+// list parameters limit, cursor, sort and order.
 func (h *SetHandler) GetAllSets(c *gin.Context) {
-	sets, err := h.setService.GetAllSets()
+	query, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	var filter service.SetListFilter
+	if !bindOptionalInt(c, "theme_id", &filter.ThemeID) || !bindOptionalInt(c, "year_min", &filter.YearMin) ||
+		!bindOptionalInt(c, "year_max", &filter.YearMax) || !bindOptionalBool(c, "has_missing_parts", &filter.HasMissingParts) ||
+		!bindOptionalBool(c, "is_donor", &filter.IsDonor) ||
+		!bindOptionalFloat(c, "completeness_min", &filter.CompletenessMin) || !bindOptionalFloat(c, "completeness_max", &filter.CompletenessMax) {
+		return
+	}
+
+	var includeSpares *bool
+	if !bindOptionalBool(c, "include_spares", &includeSpares) {
+		return
+	}
+	filter.IncludeSpares = includeSpares != nil && *includeSpares
+
+	switch filter.Status = c.Query("status"); filter.Status {
+	case "", entity.SetStatusComplete, entity.SetStatusIncomplete, entity.SetStatusUnchecked:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, expected complete, incomplete or unchecked"})
+		return
+	}
+
+	page, err := h.setService.GetAllSets(filter, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"sets": sets})
+	c.JSON(http.StatusOK, pageResponse("sets", page))
 }
 
 // UpdateSet handles PUT /sets/:id
@@ -110,6 +221,13 @@ func (h *SetHandler) UpdateSet(c *gin.Context) {
 		return
 	}
 
+	// Reload so the embedded theme matches the updated theme_id
+	set, err = h.setService.GetSetByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, set)
 }
 
@@ -131,7 +249,8 @@ func (h *SetHandler) DeleteSet(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Set deleted successfully"})
 }
 
-// SyncSetFromRebrickable handles POST /sets/sync
+// SyncSetFromRebrickable handles POST /sets/sync. A set that is not stored yet is imported in the
+// background, as with POST /sets.
 func (h *SetHandler) SyncSetFromRebrickable(c *gin.Context) {
 	var req struct {
 		SetNum string `json:"set_num" binding:"required"`
@@ -143,14 +262,42 @@ func (h *SetHandler) SyncSetFromRebrickable(c *gin.Context) {
 	}
 
 	set, err := h.setService.SyncSetFromRebrickable(req.SetNum)
+	if errors.Is(err, service.ErrSetNotStored) {
+		h.enqueueSetImport(c, req.SetNum)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, set)
 }
 
+// ResyncSet handles POST /sets/:id/resync. Supports ?force=true to diff the inventory even when
+// Rebrickable did not modify the set.
+func (h *SetHandler) ResyncSet(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	var force *bool
+	if !bindOptionalBool(c, "force", &force) {
+		return
+	}
+
+	resync, err := h.setService.ResyncSet(uint(id), force != nil && *force)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resync)
+}
+
 // GetSetWithMissingParts handles GET /sets/:id/missing-parts
 func (h *SetHandler) GetSetWithMissingParts(c *gin.Context) {
 	idStr := c.Param("id")
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
 	"github.com/BombartSimon/MissingBrick/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -21,7 +22,8 @@ func NewSetPartsHandler(setPartService service.SetPartService) *SetPartsHandler
 	}
 }
 
-// GetSetParts handles GET /sets/:id/parts
+// GetSetParts handles GET /set-parts/:id. Supports ?color_id=, ?is_spare= and the list
+// parameters limit, cursor, sort and order.
 func (h *SetPartsHandler) GetSetParts(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -30,13 +32,23 @@ func (h *SetPartsHandler) GetSetParts(c *gin.Context) {
 		return
 	}
 
-	setParts, err := h.setPartService.GetSetParts(uint(id))
+	query, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	var filter repository.SetPartFilter
+	if !bindOptionalInt(c, "color_id", &filter.ColorID) || !bindOptionalBool(c, "is_spare", &filter.IsSpare) {
+		return
+	}
+
+	page, err := h.setPartService.ListSetParts(uint(id), filter, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"set_parts": setParts})
+	c.JSON(http.StatusOK, pageResponse("set_parts", page))
 }
 
 // SyncSetParts handles POST /sets/:id/sync-parts
@@ -57,25 +69,23 @@ func (h *SetPartsHandler) SyncSetParts(c *gin.Context) {
 		return
 	}
 
-	err = h.setPartService.ReplaceSetParts(uint(id), req.SetNum)
+	drift, err := h.setPartService.ResyncSetParts(uint(id), req.SetNum)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Set parts synchronized successfully"})
+	c.JSON(http.StatusOK, drift)
 }
 
 // CreateSetPart handles POST /set-parts
 func (h *SetPartsHandler) CreateSetPart(c *gin.Context) {
 	var req struct {
-		SetID     uint   `json:"set_id" binding:"required"`
-		PartID    uint   `json:"part_id" binding:"required"`
-		ColorID   int    `json:"color_id" binding:"required"`
-		ColorName string `json:"color_name"`
-		ColorHex  string `json:"color_hex"`
-		Quantity  int    `json:"quantity" binding:"required"`
-		IsSpare   bool   `json:"is_spare"`
+		SetID    uint `json:"set_id" binding:"required"`
+		PartID   uint `json:"part_id" binding:"required"`
+		ColorID  int  `json:"color_id"`
+		Quantity int  `json:"quantity" binding:"required"`
+		IsSpare  bool `json:"is_spare"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -84,13 +94,11 @@ func (h *SetPartsHandler) CreateSetPart(c *gin.Context) {
 	}
 
 	setPart := &entity.SetPart{
-		SetID:     req.SetID,
-		PartID:    req.PartID,
-		ColorID:   req.ColorID,
-		ColorName: req.ColorName,
-		ColorHex:  req.ColorHex,
-		Quantity:  req.Quantity,
-		IsSpare:   req.IsSpare,
+		SetID:    req.SetID,
+		PartID:   req.PartID,
+		ColorID:  req.ColorID,
+		Quantity: req.Quantity,
+		IsSpare:  req.IsSpare,
 	}
 
 	err := h.setPartService.CreateSetPart(setPart)
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// StorageLocationHandler handles HTTP requests for storage locations and the items they hold
+type StorageLocationHandler struct {
+	storageLocationService service.StorageLocationService
+}
+
+// NewStorageLocationHandler creates a new storage location handler
+func NewStorageLocationHandler(storageLocationService service.StorageLocationService) *StorageLocationHandler {
+	return &StorageLocationHandler{
+		storageLocationService: storageLocationService,
+	}
+}
+
+// GetStorageLocations handles GET /locations
+func (h *StorageLocationHandler) GetStorageLocations(c *gin.Context) {
+	locations, err := h.storageLocationService.GetStorageLocations()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+// GetStorageLocation handles GET /locations/:id. Supports ?recursive=true to include the items of sub-locations.
+func (h *StorageLocationHandler) GetStorageLocation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid storage location ID"})
+		return
+	}
+
+	var recursive *bool
+	if !bindOptionalBool(c, "recursive", &recursive) {
+		return
+	}
+
+	contents, err := h.storageLocationService.GetStorageLocation(uint(id), recursive != nil && *recursive)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contents)
+}
+
+// CreateStorageLocation handles POST /locations
+func (h *StorageLocationHandler) CreateStorageLocation(c *gin.Context) {
+	var req service.StorageLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := h.storageLocationService.CreateStorageLocation(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+}
+
+// UpdateStorageLocation handles PUT /locations/:id
+func (h *StorageLocationHandler) UpdateStorageLocation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid storage location ID"})
+		return
+	}
+
+	var req service.StorageLocationUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := h.storageLocationService.UpdateStorageLocation(uint(id), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// DeleteStorageLocation handles DELETE /locations/:id
+func (h *StorageLocationHandler) DeleteStorageLocation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid storage location ID"})
+		return
+	}
+
+	if err := h.storageLocationService.DeleteStorageLocation(uint(id)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Storage location deleted successfully"})
+}
+
+// MoveItems handles POST /locations/move
+func (h *StorageLocationHandler) MoveItems(c *gin.Context) {
+	var req service.StorageLocationMove
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.storageLocationService.MoveItems(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ThemeHandler handles HTTP requests for themes
+type ThemeHandler struct {
+	themeService service.ThemeService
+}
+
+// NewThemeHandler creates a new theme handler
+func NewThemeHandler(themeService service.ThemeService) *ThemeHandler {
+	return &ThemeHandler{
+		themeService: themeService,
+	}
+}
+
+// GetThemeTree handles GET /themes
+func (h *ThemeHandler) GetThemeTree(c *gin.Context) {
+	themes, err := h.themeService.GetThemeTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"themes": themes})
+}
+
+// GetThemeByID handles GET /themes/:id
+func (h *ThemeHandler) GetThemeByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid theme ID"})
+		return
+	}
+
+	theme, err := h.themeService.GetThemeByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Theme not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, theme)
+}
+
+// SyncThemes handles POST /themes/sync
+func (h *ThemeHandler) SyncThemes(c *gin.Context) {
+	count, err := h.themeService.SyncThemesFromRebrickable()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Themes synchronized successfully", "count": count})
+}
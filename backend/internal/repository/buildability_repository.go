@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// BuildabilityRepository defines the interface for comparing the parts we own with set inventories
+type BuildabilityRepository interface {
+	GetOwnedParts(includeSets bool) ([]OwnedPart, error)
+	RankCatalogSets(includeSets bool, minCompleteness float64, limit int) ([]BuildableSet, error)
+}
+
+// OwnedPart is the quantity of a part, in one color, that we own
+type OwnedPart struct {
+	PartNum  string
+	ColorID  int
+	Quantity int
+}
+
+// BuildableSet is a catalog set together with the share of its pieces that we own
+type BuildableSet struct {
+	SetNum         string  `json:"set_num"`
+	Name           string  `json:"name"`
+	Year           int     `json:"year"`
+	ThemeID        int     `json:"theme_id"`
+	ImageURL       string  `json:"img_url"`
+	PiecesRequired int     `json:"pieces_required"`
+	PiecesOwned    int     `json:"pieces_owned"`
+	Completeness   float64 `json:"completeness"`
+	Owned          bool    `json:"owned"`
+}
+
+// buildabilityRepository implements BuildabilityRepository interface
+type buildabilityRepository struct {
+	db *gorm.DB
+}
+
+// NewBuildabilityRepository creates a new buildability repository
+func NewBuildabilityRepository(db *gorm.DB) BuildabilityRepository {
+	return &buildabilityRepository{db: db}
+}
+
+// ownedPartsSQL sums the loose parts and, when includeSets is set, the parts of every owned copy
+// of our sets that are not missing from it. Minifig parts are left out on both sides.
+func ownedPartsSQL(includeSets bool) string {
+	sources := `SELECT p.part_num, lp.color_id, lp.quantity
+		FROM loose_parts lp
+		JOIN parts p ON p.id = lp.part_id`
+	if includeSets {
+		sources += `
+		UNION ALL
+		SELECT p.part_num, sp.color_id, sp.quantity * (SELECT COUNT(*) FROM set_copies c WHERE c.set_id = sp.set_id AND c.deleted_at IS NULL)
+		FROM set_parts sp
+		JOIN sets s ON s.id = sp.set_id AND s.deleted_at IS NULL
+		JOIN parts p ON p.id = sp.part_id
+		WHERE sp.deleted_at IS NULL
+		UNION ALL
+		SELECT p.part_num, mp.color_id, -mp.quantity
+		FROM missing_parts mp
+		JOIN sets s ON s.id = mp.set_id AND s.deleted_at IS NULL
+		JOIN parts p ON p.id = mp.part_id
+		WHERE mp.deleted_at IS NULL AND mp.is_missing AND mp.set_minifig_id IS NULL`
+	}
+
+	return `SELECT part_num, color_id, SUM(quantity) AS quantity
+		FROM (` + sources + `)
+		GROUP BY part_num, color_id
+		HAVING SUM(quantity) > 0`
+}
+
+// GetOwnedParts retrieves the quantity we own of each part and color
+func (r *buildabilityRepository) GetOwnedParts(includeSets bool) ([]OwnedPart, error) {
+	var owned []OwnedPart
+	err := r.db.Raw(ownedPartsSQL(includeSets)).Scan(&owned).Error
+	return owned, err
+}
+
+// RankCatalogSets ranks the catalog sets by the share of the pieces of their latest inventory that we
+// own, spares excluded. Only sets sharing at least one part and color with us are considered.
+func (r *buildabilityRepository) RankCatalogSets(includeSets bool, minCompleteness float64, limit int) ([]BuildableSet, error) {
+	var sets []BuildableSet
+	err := r.db.Raw(`WITH owned AS (`+ownedPartsSQL(includeSets)+`),
+		latest AS (
+			SELECT i.id, i.set_num
+			FROM catalog_inventories i
+			JOIN catalog_sets cs ON cs.set_num = i.set_num
+			WHERE i.version = (SELECT MAX(i2.version) FROM catalog_inventories i2 WHERE i2.set_num = i.set_num)
+		),
+		lots AS (
+			SELECT l.set_num, ip.part_num, ip.color_id, SUM(ip.quantity) AS quantity
+			FROM latest l
+			JOIN catalog_inventory_parts ip ON ip.inventory_id = l.id AND NOT ip.is_spare
+			WHERE l.id IN (
+				SELECT ip2.inventory_id FROM catalog_inventory_parts ip2
+				JOIN owned o2 ON o2.part_num = ip2.part_num AND o2.color_id = ip2.color_id
+			)
+			GROUP BY l.set_num, ip.part_num, ip.color_id
+		),
+		ranked AS (
+			SELECT lots.set_num, SUM(lots.quantity) AS pieces_required,
+				SUM(MIN(lots.quantity, COALESCE(o.quantity, 0))) AS pieces_owned
+			FROM lots
+			LEFT JOIN owned o ON o.part_num = lots.part_num AND o.color_id = lots.color_id
+			GROUP BY lots.set_num
+		)
+		SELECT cs.set_num, cs.name, cs.year, cs.theme_id, cs.image_url,
+			ranked.pieces_required, ranked.pieces_owned,
+			ROUND(100.0 * ranked.pieces_owned / ranked.pieces_required, 2) AS completeness,
+			EXISTS (SELECT 1 FROM sets s WHERE s.set_num = cs.set_num AND s.deleted_at IS NULL) AS owned
+		FROM ranked
+		JOIN catalog_sets cs ON cs.set_num = ranked.set_num
+		WHERE ranked.pieces_owned > 0 AND 100.0 * ranked.pieces_owned / ranked.pieces_required >= ?
+		ORDER BY completeness DESC, ranked.pieces_owned DESC, cs.set_num
+		LIMIT ?`, minCompleteness, limit).Scan(&sets).Error
+	return sets, err
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// CatalogRepository defines the interface for the local Rebrickable catalog mirror
+type CatalogRepository interface {
+	Replace(model interface{}, load func(insert func(rows interface{}) error) error) error
+	GetSet(setNum string) (*entity.CatalogSet, error)
+	GetPart(partNum string) (*entity.CatalogPart, error)
+	GetColors() ([]entity.CatalogColor, error)
+	GetThemes() ([]entity.CatalogTheme, error)
+	GetTheme(id int) (*entity.CatalogTheme, error)
+	GetLatestInventory(setNum string) (*entity.CatalogInventory, error)
+	GetInventoryParts(inventoryID int) ([]entity.CatalogInventoryPart, error)
+	GetInventoryMinifigs(inventoryID int) ([]entity.CatalogInventoryMinifig, error)
+	GetDesignIDs(partNums []string) (map[string][]string, error)
+	GetPartRelationships(partNums []string, relTypes []string) ([]entity.CatalogPartRelationship, error)
+}
+
+// catalogRepository implements CatalogRepository interface
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+// NewCatalogRepository creates a new catalog repository
+func NewCatalogRepository(db *gorm.DB) CatalogRepository {
+	return &catalogRepository{db: db}
+}
+
+// Replace empties the table of model and refills it within a single transaction.
+// load is called with an insert function that writes a slice of rows in batches.
+func (r *catalogRepository) Replace(model interface{}, load func(insert func(rows interface{}) error) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model).Error; err != nil {
+			return err
+		}
+
+		return load(func(rows interface{}) error {
+			return tx.CreateInBatches(rows, 500).Error
+		})
+	})
+}
+
+// GetSet retrieves a catalog set by its set number
+func (r *catalogRepository) GetSet(setNum string) (*entity.CatalogSet, error) {
+	var set entity.CatalogSet
+	err := r.db.Where("set_num = ?", setNum).First(&set).Error
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// GetPart retrieves a catalog part by its part number
+func (r *catalogRepository) GetPart(partNum string) (*entity.CatalogPart, error) {
+	var part entity.CatalogPart
+	err := r.db.Where("part_num = ?", partNum).First(&part).Error
+	if err != nil {
+		return nil, err
+	}
+	return &part, nil
+}
+
+// GetColors retrieves all catalog colors
+func (r *catalogRepository) GetColors() ([]entity.CatalogColor, error) {
+	var colors []entity.CatalogColor
+	err := r.db.Order("id").Find(&colors).Error
+	return colors, err
+}
+
+// GetThemes retrieves all catalog themes
+func (r *catalogRepository) GetThemes() ([]entity.CatalogTheme, error) {
+	var themes []entity.CatalogTheme
+	err := r.db.Order("id").Find(&themes).Error
+	return themes, err
+}
+
+// GetTheme retrieves a catalog theme by its ID
+func (r *catalogRepository) GetTheme(id int) (*entity.CatalogTheme, error) {
+	var theme entity.CatalogTheme
+	err := r.db.First(&theme, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &theme, nil
+}
+
+// GetLatestInventory retrieves the most recent inventory version of a set or minifig
+func (r *catalogRepository) GetLatestInventory(setNum string) (*entity.CatalogInventory, error) {
+	var inventory entity.CatalogInventory
+	err := r.db.Where("set_num = ?", setNum).Order("version DESC").First(&inventory).Error
+	if err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+// GetInventoryParts retrieves the part lines of an inventory with their part and color.
+// Relations are joined rather than preloaded because color 0 (Black) is a valid key.
+func (r *catalogRepository) GetInventoryParts(inventoryID int) ([]entity.CatalogInventoryPart, error) {
+	var parts []entity.CatalogInventoryPart
+	err := r.db.Joins("Part").Joins("Color").Where("catalog_inventory_parts.inventory_id = ?", inventoryID).Find(&parts).Error
+	return parts, err
+}
+
+// GetInventoryMinifigs retrieves the minifig lines of an inventory with their minifig
+func (r *catalogRepository) GetInventoryMinifigs(inventoryID int) ([]entity.CatalogInventoryMinifig, error) {
+	var minifigs []entity.CatalogInventoryMinifig
+	err := r.db.Joins("Minifig").Where("catalog_inventory_minifigs.inventory_id = ?", inventoryID).Find(&minifigs).Error
+	return minifigs, err
+}
+
+// GetDesignIDs retrieves the distinct LEGO design IDs known for each of the given part numbers
+func (r *catalogRepository) GetDesignIDs(partNums []string) (map[string][]string, error) {
+	designIDs := make(map[string][]string)
+	if len(partNums) == 0 {
+		return designIDs, nil
+	}
+
+	var rows []struct {
+		PartNum  string
+		DesignID string
+	}
+	err := r.db.Model(&entity.CatalogElement{}).
+		Distinct("part_num", "design_id").
+		Where("part_num IN ? AND design_id <> ''", partNums).
+		Order("part_num, design_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		designIDs[row.PartNum] = append(designIDs[row.PartNum], row.DesignID)
+	}
+	return designIDs, nil
+}
+
+// GetPartRelationships retrieves the relationships of the given types in which one of the parts is
+// the child or the parent
+func (r *catalogRepository) GetPartRelationships(partNums []string, relTypes []string) ([]entity.CatalogPartRelationship, error) {
+	var relationships []entity.CatalogPartRelationship
+	if len(partNums) == 0 {
+		return relationships, nil
+	}
+
+	err := r.db.Where("rel_type IN ? AND (child_part_num IN ? OR parent_part_num IN ?)", relTypes, partNums, partNums).
+		Find(&relationships).Error
+	return relationships, err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ColorRepository defines the interface for color data operations
+type ColorRepository interface {
+	UpsertBatch(colors []entity.Color) error
+	GetByID(id int) (*entity.Color, error)
+	GetAll(isTrans *bool) ([]entity.Color, error)
+}
+
+// colorRepository implements ColorRepository interface
+type colorRepository struct {
+	db *gorm.DB
+}
+
+// NewColorRepository creates a new color repository
+func NewColorRepository(db *gorm.DB) ColorRepository {
+	return &colorRepository{db: db}
+}
+
+// UpsertBatch creates colors or updates them when their ID already exists. External IDs are only
+// overwritten when given, since the colors of inventory lines come without them.
+func (r *colorRepository) UpsertBatch(colors []entity.Color) error {
+	if len(colors) == 0 {
+		return nil
+	}
+
+	updates := clause.AssignmentColumns([]string{"name", "rgb", "is_trans", "updated_at"})
+	updates = append(updates,
+		clause.Assignment{Column: clause.Column{Name: "bricklink_id"}, Value: gorm.Expr("COALESCE(excluded.bricklink_id, colors.bricklink_id)")},
+		clause.Assignment{Column: clause.Column{Name: "ldraw_id"}, Value: gorm.Expr("COALESCE(excluded.ldraw_id, colors.ldraw_id)")},
+		clause.Assignment{Column: clause.Column{Name: "lego_id"}, Value: gorm.Expr("COALESCE(excluded.lego_id, colors.lego_id)")},
+		clause.Assignment{Column: clause.Column{Name: "external_ids"}, Value: gorm.Expr("COALESCE(NULLIF(excluded.external_ids, ''), colors.external_ids)")},
+	)
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: updates,
+	}).CreateInBatches(colors, 100).Error
+}
+
+// GetByID retrieves a color by its Rebrickable ID
+func (r *colorRepository) GetByID(id int) (*entity.Color, error) {
+	var color entity.Color
+	err := r.db.Where("id = ?", id).First(&color).Error
+	if err != nil {
+		return nil, err
+	}
+	return &color, nil
+}
+
+// GetAll retrieves all colors, optionally filtered by transparency
+func (r *colorRepository) GetAll(isTrans *bool) ([]entity.Color, error) {
+	var colors []entity.Color
+	query := r.db.Order("name")
+	if isTrans != nil {
+		query = query.Where("is_trans = ?", *isTrans)
+	}
+	err := query.Find(&colors).Error
+	return colors, err
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyRepository defines the interface for idempotency key data operations
+type IdempotencyKeyRepository interface {
+	Create(key *entity.IdempotencyKey) error
+	GetByKey(key string) (*entity.IdempotencyKey, error)
+}
+
+// idempotencyKeyRepository implements IdempotencyKeyRepository interface
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyKeyRepository creates a new idempotency key repository
+func NewIdempotencyKeyRepository(db *gorm.DB) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{db: db}
+}
+
+// Create stores the outcome of an idempotent request
+func (r *idempotencyKeyRepository) Create(key *entity.IdempotencyKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetByKey retrieves a stored idempotency key
+func (r *idempotencyKeyRepository) GetByKey(key string) (*entity.IdempotencyKey, error) {
+	var idempotencyKey entity.IdempotencyKey
+	err := r.db.Where(&entity.IdempotencyKey{Key: key}).First(&idempotencyKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return &idempotencyKey, nil
+}
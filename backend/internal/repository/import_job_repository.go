@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// ImportJobRepository defines the interface for import job data operations
+type ImportJobRepository interface {
+	Create(job *entity.ImportJob) error
+	Save(job *entity.ImportJob) error
+	GetByID(id uint) (*entity.ImportJob, error)
+	GetActiveBySetNum(setNum string) (*entity.ImportJob, error)
+	GetNextDue(now time.Time) (*entity.ImportJob, error)
+	RequeueRunning() error
+}
+
+// importJobRepository implements ImportJobRepository interface
+type importJobRepository struct {
+	db *gorm.DB
+}
+
+// NewImportJobRepository creates a new import job repository
+func NewImportJobRepository(db *gorm.DB) ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+// Create creates a new import job
+func (r *importJobRepository) Create(job *entity.ImportJob) error {
+	return r.db.Omit("Set").Create(job).Error
+}
+
+// Save updates an import job
+func (r *importJobRepository) Save(job *entity.ImportJob) error {
+	return r.db.Omit("Set").Save(job).Error
+}
+
+// GetByID retrieves an import job with its set
+func (r *importJobRepository) GetByID(id uint) (*entity.ImportJob, error) {
+	var job entity.ImportJob
+	err := r.db.Preload("Set").First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetActiveBySetNum retrieves the queued or running import job of a set number
+func (r *importJobRepository) GetActiveBySetNum(setNum string) (*entity.ImportJob, error) {
+	var job entity.ImportJob
+	err := r.db.Where("set_num = ? AND status IN ?", setNum, []string{entity.ImportJobStatusQueued, entity.ImportJobStatusRunning}).
+		Order("id").First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetNextDue retrieves the oldest queued job whose next attempt, stored in UTC, is due, or nil when none is
+func (r *importJobRepository) GetNextDue(now time.Time) (*entity.ImportJob, error) {
+	var jobs []entity.ImportJob
+	err := r.db.Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", entity.ImportJobStatusQueued, now.UTC()).
+		Order("id").Limit(1).Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// RequeueRunning puts the jobs left running, by a server that stopped during them, back in the queue
+func (r *importJobRepository) RequeueRunning() error {
+	return r.db.Model(&entity.ImportJob{}).Where("status = ?", entity.ImportJobStatusRunning).
+		Updates(map[string]interface{}{"status": entity.ImportJobStatusQueued, "next_attempt_at": nil}).Error
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// Page sizes used by the paginated list queries
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// ErrInvalidListQuery is returned when a list query has an unknown sort key or a malformed cursor
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+// ListQuery describes the page of a list to return. Cursor is the NextCursor of the previous
+// page and must be used with the same sort key and direction.
+type ListQuery struct {
+	Limit  int
+	Cursor string
+	Sort   string
+	Desc   bool
+}
+
+// Page is one page of a list together with the total number of rows matching its filters
+type Page[T any] struct {
+	Items      []T
+	Total      int64
+	Limit      int
+	NextCursor string
+}
+
+// sortKey maps a public sort key to an indexed column and reads the column value of a row
+type sortKey[T any] struct {
+	column string
+	value  func(row T) interface{}
+}
+
+// listOrder describes how the rows of a table can be sorted. Rows are always tie-broken by
+// idColumn so that the keyset cursor is stable.
+type listOrder[T any] struct {
+	idColumn    string
+	id          func(row T) uint
+	defaultSort string
+	keys        map[string]sortKey[T]
+}
+
+// listCursor is the position after the last row of a page, encoded as base64 JSON
+type listCursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d,omitempty"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// paginate runs a keyset paginated query. filtered must only hold the model and its filters,
+// since it is also used to count the matching rows; load adds joins and preloads to the page query.
+func paginate[T any](filtered *gorm.DB, order listOrder[T], query ListQuery, load func(db *gorm.DB) *gorm.DB) (*Page[T], error) {
+	sortName := query.Sort
+	if sortName == "" {
+		sortName = order.defaultSort
+	}
+	key, ok := order.keys[sortName]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort key %q: %w", sortName, ErrInvalidListQuery)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	direction, compare := "ASC", ">"
+	if query.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	db := load(filtered.Session(&gorm.Session{}))
+	if query.Cursor != "" {
+		value, id, err := decodeListCursor(query.Cursor, sortName, query.Desc, key)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("(%[1]s %[3]s ? OR (%[1]s = ? AND %[2]s %[3]s ?))", key.column, order.idColumn, compare), value, value, id)
+	}
+
+	items := make([]T, 0)
+	err := db.Order(fmt.Sprintf("%s %s, %s %s", key.column, direction, order.idColumn, direction)).
+		Limit(limit + 1).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Items: items, Total: total, Limit: limit}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor, err = encodeListCursor(sortName, query.Desc, key.value(last), order.id(last))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// encodeListCursor encodes the position of a row as an opaque cursor
+func encodeListCursor(sort string, desc bool, value interface{}, id uint) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(listCursor{Sort: sort, Desc: desc, Value: raw, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeListCursor decodes a cursor into the sort column value and ID of the row it points after.
+// The value is decoded into the Go type of the column so that times and numbers compare correctly.
+func decodeListCursor[T any](cursor, sort string, desc bool, key sortKey[T]) (interface{}, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed cursor: %w", ErrInvalidListQuery)
+	}
+
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, 0, fmt.Errorf("malformed cursor: %w", ErrInvalidListQuery)
+	}
+	if c.Sort != sort || c.Desc != desc {
+		return nil, 0, fmt.Errorf("cursor was issued for another sort order: %w", ErrInvalidListQuery)
+	}
+
+	var zero T
+	value := reflect.New(reflect.TypeOf(key.value(zero)))
+	if err := json.Unmarshal(c.Value, value.Interface()); err != nil {
+		return nil, 0, fmt.Errorf("malformed cursor: %w", ErrInvalidListQuery)
+	}
+	return value.Elem().Interface(), c.ID, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/database"
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newTestDB opens a migrated in-memory database private to the test
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.NewDatabase(fmt.Sprintf("file:%s?mode=memory&cache=shared", url.PathEscape(t.Name())))
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("failed to get test database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db.DB
+}
+
+// mustCreate inserts rows without their relations, failing the test on error
+func mustCreate(t *testing.T, db *gorm.DB, rows interface{}) {
+	t.Helper()
+
+	if err := db.Omit(clause.Associations).Create(rows).Error; err != nil {
+		t.Fatalf("failed to create %T: %v", rows, err)
+	}
+}
+
+// listPages follows the cursors of a set list and returns the IDs of every page
+func listPages(t *testing.T, repo SetRepository, query ListQuery) [][]uint {
+	t.Helper()
+
+	var pages [][]uint
+	for {
+		page, err := repo.List(SetFilter{}, query)
+		if err != nil {
+			t.Fatalf("failed to list sets: %v", err)
+		}
+
+		ids := make([]uint, 0, len(page.Items))
+		for _, set := range page.Items {
+			ids = append(ids, set.ID)
+		}
+		pages = append(pages, ids)
+
+		if page.NextCursor == "" {
+			return pages
+		}
+		if len(pages) > 10 {
+			t.Fatalf("pagination did not end after %d pages", len(pages))
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func TestPaginateKeysetCursor(t *testing.T) {
+	db := newTestDB(t)
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mustCreate(t, db, []entity.Set{
+		{ID: 1, SetNum: "1-1", Name: "Castle", Year: 2001, CreatedAt: created},
+		{ID: 2, SetNum: "2-1", Name: "Pirates", Year: 2000, CreatedAt: created},
+		{ID: 3, SetNum: "3-1", Name: "Castle", Year: 2001, CreatedAt: created.Add(time.Hour)},
+		{ID: 4, SetNum: "4-1", Name: "Space", Year: 2001, CreatedAt: created},
+		{ID: 5, SetNum: "5-1", Name: "Arctic", Year: 2002, CreatedAt: created.Add(-time.Hour)},
+	})
+	repo := NewSetRepository(db)
+
+	tests := []struct {
+		name  string
+		query ListQuery
+		want  [][]uint
+	}{
+		{
+			name:  "ties broken by id",
+			query: ListQuery{Limit: 2, Sort: "year"},
+			want:  [][]uint{{2, 1}, {3, 4}, {5}},
+		},
+		{
+			name:  "ties broken by id when descending",
+			query: ListQuery{Limit: 2, Sort: "year", Desc: true},
+			want:  [][]uint{{5, 4}, {3, 1}, {2}},
+		},
+		{
+			name:  "cursor inside a run of ties",
+			query: ListQuery{Limit: 1, Sort: "name"},
+			want:  [][]uint{{5}, {1}, {3}, {2}, {4}},
+		},
+		{
+			name:  "time cursor",
+			query: ListQuery{Limit: 2, Sort: "created_at"},
+			want:  [][]uint{{5, 1}, {2, 4}, {3}},
+		},
+		{
+			name:  "descending time cursor",
+			query: ListQuery{Limit: 3, Sort: "created_at", Desc: true},
+			want:  [][]uint{{3, 4, 2}, {1, 5}},
+		},
+		{
+			name:  "exact last page has no cursor",
+			query: ListQuery{Limit: 5, Sort: "year"},
+			want:  [][]uint{{2, 1, 3, 4, 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listPages(t, repo, tt.query)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginateInvalidQuery(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, []entity.Set{
+		{ID: 1, SetNum: "1-1", Name: "Castle", Year: 2000},
+		{ID: 2, SetNum: "2-1", Name: "Pirates", Year: 2001},
+	})
+	repo := NewSetRepository(db)
+
+	page, err := repo.List(SetFilter{}, ListQuery{Limit: 1, Sort: "year"})
+	if err != nil {
+		t.Fatalf("failed to list sets: %v", err)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	tests := []struct {
+		name  string
+		query ListQuery
+	}{
+		{name: "unknown sort key", query: ListQuery{Sort: "color"}},
+		{name: "malformed cursor", query: ListQuery{Sort: "year", Cursor: "not a cursor"}},
+		{name: "cursor of another sort key", query: ListQuery{Sort: "name", Cursor: page.NextCursor}},
+		{name: "cursor of another direction", query: ListQuery{Sort: "year", Desc: true, Cursor: page.NextCursor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.List(SetFilter{}, tt.query)
+			if !errors.Is(err, ErrInvalidListQuery) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidListQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// LoosePartRepository defines the interface for loose part inventory data operations
+type LoosePartRepository interface {
+	Create(loosePart *entity.LoosePart) error
+	GetByID(id uint) (*entity.LoosePart, error)
+	GetByKey(partID uint, colorID int, storageLocationID *uint) (*entity.LoosePart, error)
+	List(filter LoosePartFilter, query ListQuery) (*Page[entity.LoosePart], error)
+	GetAll() ([]entity.LoosePart, error)
+	GetByStorageLocationIDs(locationIDs []uint) ([]entity.LoosePart, error)
+	Update(loosePart *entity.LoosePart) error
+	Delete(id uint) error
+}
+
+// LoosePartFilter restricts the loose parts returned by List. Empty fields are ignored.
+type LoosePartFilter struct {
+	PartNum           string
+	ColorID           *int
+	StorageLocationID *uint
+}
+
+// loosePartOrder lists the columns loose parts can be sorted by
+var loosePartOrder = listOrder[entity.LoosePart]{
+	idColumn:    "loose_parts.id",
+	id:          func(loosePart entity.LoosePart) uint { return loosePart.ID },
+	defaultSort: "created_at",
+	keys: map[string]sortKey[entity.LoosePart]{
+		"created_at":          {column: "loose_parts.created_at", value: func(loosePart entity.LoosePart) interface{} { return loosePart.CreatedAt }},
+		"part_id":             {column: "loose_parts.part_id", value: func(loosePart entity.LoosePart) interface{} { return loosePart.PartID }},
+		"color_id":            {column: "loose_parts.color_id", value: func(loosePart entity.LoosePart) interface{} { return loosePart.ColorID }},
+		"storage_location_id": {column: "loose_parts.storage_location_id", value: func(loosePart entity.LoosePart) interface{} { return loosePart.StorageLocationID }},
+	},
+}
+
+// loosePartRepository implements LoosePartRepository interface
+type loosePartRepository struct {
+	db *gorm.DB
+}
+
+// NewLoosePartRepository creates a new loose part repository
+func NewLoosePartRepository(db *gorm.DB) LoosePartRepository {
+	return &loosePartRepository{db: db}
+}
+
+// Create creates a new loose part entry
+func (r *loosePartRepository) Create(loosePart *entity.LoosePart) error {
+	return r.db.Omit("Part", "Color", "StorageLocation").Create(loosePart).Error
+}
+
+// GetByID retrieves a loose part entry with its part, color and storage location
+func (r *loosePartRepository) GetByID(id uint) (*entity.LoosePart, error) {
+	var loosePart entity.LoosePart
+	err := r.db.Joins("Color").Preload("Part").Preload("StorageLocation").First(&loosePart, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loosePart, nil
+}
+
+// GetByKey retrieves the loose part entry of a part in a color kept in a storage location, or in no
+// location when storageLocationID is nil
+func (r *loosePartRepository) GetByKey(partID uint, colorID int, storageLocationID *uint) (*entity.LoosePart, error) {
+	query := r.db.Where("part_id = ? AND color_id = ?", partID, colorID)
+	if storageLocationID == nil {
+		query = query.Where("storage_location_id IS NULL")
+	} else {
+		query = query.Where("storage_location_id = ?", *storageLocationID)
+	}
+
+	var loosePart entity.LoosePart
+	err := query.First(&loosePart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loosePart, nil
+}
+
+// List retrieves a page of loose parts with their part and color
+func (r *loosePartRepository) List(filter LoosePartFilter, query ListQuery) (*Page[entity.LoosePart], error) {
+	filtered := r.db.Model(&entity.LoosePart{})
+	if filter.PartNum != "" {
+		filtered = filtered.Where("loose_parts.part_id IN (SELECT id FROM parts WHERE part_num = ?)", filter.PartNum)
+	}
+	if filter.ColorID != nil {
+		filtered = filtered.Where("loose_parts.color_id = ?", *filter.ColorID)
+	}
+	if filter.StorageLocationID != nil {
+		filtered = filtered.Where("loose_parts.storage_location_id = ?", *filter.StorageLocationID)
+	}
+
+	return paginate(filtered, loosePartOrder, query, func(db *gorm.DB) *gorm.DB {
+		return db.Joins("Color").Preload("Part").Preload("StorageLocation")
+	})
+}
+
+// GetAll retrieves every loose part entry with its part, color and storage location, oldest first
+func (r *loosePartRepository) GetAll() ([]entity.LoosePart, error) {
+	var looseParts []entity.LoosePart
+	err := r.db.Joins("Color").Preload("Part").Preload("StorageLocation").Order("loose_parts.id").Find(&looseParts).Error
+	return looseParts, err
+}
+
+// GetByStorageLocationIDs retrieves the loose part entries stored in any of the given locations, with their location
+func (r *loosePartRepository) GetByStorageLocationIDs(locationIDs []uint) ([]entity.LoosePart, error) {
+	var looseParts []entity.LoosePart
+	err := r.db.Joins("Color").Preload("Part").Preload("StorageLocation").
+		Where("loose_parts.storage_location_id IN ?", locationIDs).
+		Order("loose_parts.id").Find(&looseParts).Error
+	return looseParts, err
+}
+
+// Update updates a loose part entry
+func (r *loosePartRepository) Update(loosePart *entity.LoosePart) error {
+	return r.db.Omit("Part", "Color", "StorageLocation").Save(loosePart).Error
+}
+
+// Delete deletes a loose part entry
+func (r *loosePartRepository) Delete(id uint) error {
+	return r.db.Delete(&entity.LoosePart{}, id).Error
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MinifigRepository defines the interface for minifig data operations
+type MinifigRepository interface {
+	Create(minifig *entity.Minifig) error
+	GetByFigNum(figNum string) (*entity.Minifig, error)
+	GetPartByID(id uint) (*entity.MinifigPart, error)
+	CreateSetMinifigs(setMinifigs []entity.SetMinifig) error
+	GetSetMinifigsBySetID(setID uint) ([]entity.SetMinifig, error)
+	GetSetMinifigByID(id uint) (*entity.SetMinifig, error)
+	DeleteSetMinifigsBySetID(setID uint) error
+}
+
+// minifigRepository implements MinifigRepository interface
+type minifigRepository struct {
+	db *gorm.DB
+}
+
+// NewMinifigRepository creates a new minifig repository
+func NewMinifigRepository(db *gorm.DB) MinifigRepository {
+	return &minifigRepository{db: db}
+}
+
+// Create creates a new minifig together with its parts
+func (r *minifigRepository) Create(minifig *entity.Minifig) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(minifig).Error; err != nil {
+			return err
+		}
+
+		for i := range minifig.Parts {
+			minifig.Parts[i].MinifigID = minifig.ID
+		}
+		if len(minifig.Parts) == 0 {
+			return nil
+		}
+		return tx.Omit(clause.Associations).CreateInBatches(minifig.Parts, 100).Error
+	})
+}
+
+// GetByFigNum retrieves a minifig by its figure number
+func (r *minifigRepository) GetByFigNum(figNum string) (*entity.Minifig, error) {
+	var minifig entity.Minifig
+	err := r.db.Where("fig_num = ?", figNum).First(&minifig).Error
+	if err != nil {
+		return nil, err
+	}
+	return &minifig, nil
+}
+
+// GetPartByID retrieves a minifig part by its ID
+func (r *minifigRepository) GetPartByID(id uint) (*entity.MinifigPart, error) {
+	var minifigPart entity.MinifigPart
+	err := r.db.Joins("Color").Preload("Part").First(&minifigPart, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &minifigPart, nil
+}
+
+// CreateSetMinifigs creates multiple set minifigs in a batch
+func (r *minifigRepository) CreateSetMinifigs(setMinifigs []entity.SetMinifig) error {
+	return r.db.Omit(clause.Associations).CreateInBatches(setMinifigs, 100).Error
+}
+
+// GetSetMinifigsBySetID retrieves all minifigs of a set with their parts
+func (r *minifigRepository) GetSetMinifigsBySetID(setID uint) ([]entity.SetMinifig, error) {
+	var setMinifigs []entity.SetMinifig
+	err := r.db.Where("set_id = ?", setID).
+		Preload("Minifig.Parts", func(db *gorm.DB) *gorm.DB {
+			return db.Joins("Color")
+		}).
+		Preload("Minifig.Parts.Part").
+		Find(&setMinifigs).Error
+	return setMinifigs, err
+}
+
+// GetSetMinifigByID retrieves a set minifig by its ID
+func (r *minifigRepository) GetSetMinifigByID(id uint) (*entity.SetMinifig, error) {
+	var setMinifig entity.SetMinifig
+	err := r.db.Preload("Minifig").First(&setMinifig, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setMinifig, nil
+}
+
+// DeleteSetMinifigsBySetID deletes all minifigs of a set
+func (r *minifigRepository) DeleteSetMinifigsBySetID(setID uint) error {
+	return r.db.Where("set_id = ?", setID).Delete(&entity.SetMinifig{}).Error
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// MissingMinifigRepository defines the interface for missing minifig data operations
+type MissingMinifigRepository interface {
+	Create(missingMinifig *entity.MissingMinifig) error
+	GetByID(id uint) (*entity.MissingMinifig, error)
+	GetBySetID(setID uint) ([]entity.MissingMinifig, error)
+	GetByKey(setCopyID, setMinifigID uint) (*entity.MissingMinifig, error)
+	GetAllMissing(setIDs []uint, themeIDs []int) ([]entity.MissingMinifig, error)
+	Update(missingMinifig *entity.MissingMinifig) error
+	Delete(id uint) error
+	DeleteBySetCopyID(setCopyID uint) error
+}
+
+// missingMinifigRepository implements MissingMinifigRepository interface
+type missingMinifigRepository struct {
+	db *gorm.DB
+}
+
+// NewMissingMinifigRepository creates a new missing minifig repository
+func NewMissingMinifigRepository(db *gorm.DB) MissingMinifigRepository {
+	return &missingMinifigRepository{db: db}
+}
+
+// Create creates a new missing minifig
+func (r *missingMinifigRepository) Create(missingMinifig *entity.MissingMinifig) error {
+	return r.db.Omit("Set", "Minifig").Create(missingMinifig).Error
+}
+
+// GetByID retrieves a missing minifig by its ID
+func (r *missingMinifigRepository) GetByID(id uint) (*entity.MissingMinifig, error) {
+	var missingMinifig entity.MissingMinifig
+	err := r.db.Preload("Set").Preload("Minifig").First(&missingMinifig, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &missingMinifig, nil
+}
+
+// GetBySetID retrieves all missing minifigs for a specific set
+func (r *missingMinifigRepository) GetBySetID(setID uint) ([]entity.MissingMinifig, error) {
+	var missingMinifigs []entity.MissingMinifig
+	err := r.db.Where("set_id = ?", setID).Preload("Minifig").Find(&missingMinifigs).Error
+	return missingMinifigs, err
+}
+
+// GetByKey retrieves the missing minifig recorded for a set minifig in a set copy, preferring a row
+// that is still missing over one that was found
+func (r *missingMinifigRepository) GetByKey(setCopyID, setMinifigID uint) (*entity.MissingMinifig, error) {
+	var missingMinifig entity.MissingMinifig
+	err := r.db.Where("set_copy_id = ? AND set_minifig_id = ?", setCopyID, setMinifigID).
+		Order("is_missing DESC, id").First(&missingMinifig).Error
+	if err != nil {
+		return nil, err
+	}
+	return &missingMinifig, nil
+}
+
+// GetAllMissing retrieves the minifigs still missing from sets that have not been deleted,
+// restricted to the given sets and themes when they are not empty
+func (r *missingMinifigRepository) GetAllMissing(setIDs []uint, themeIDs []int) ([]entity.MissingMinifig, error) {
+	sets := r.db.Model(&entity.Set{}).Select("id")
+	if len(themeIDs) > 0 {
+		sets = sets.Where("theme_id IN ?", themeIDs)
+	}
+
+	query := r.db.Preload("Set").Preload("Minifig").
+		Where("missing_minifigs.is_missing = ? AND missing_minifigs.set_id IN (?)", true, sets)
+	if len(setIDs) > 0 {
+		query = query.Where("missing_minifigs.set_id IN ?", setIDs)
+	}
+
+	var missingMinifigs []entity.MissingMinifig
+	err := query.Order("missing_minifigs.minifig_id, missing_minifigs.set_id").Find(&missingMinifigs).Error
+	return missingMinifigs, err
+}
+
+// Update updates a missing minifig
+func (r *missingMinifigRepository) Update(missingMinifig *entity.MissingMinifig) error {
+	return r.db.Omit("Set", "Minifig").Save(missingMinifig).Error
+}
+
+// Delete soft deletes a missing minifig
+func (r *missingMinifigRepository) Delete(id uint) error {
+	return r.db.Delete(&entity.MissingMinifig{}, id).Error
+}
+
+// DeleteBySetCopyID soft deletes the missing minifigs of a set copy
+func (r *missingMinifigRepository) DeleteBySetCopyID(setCopyID uint) error {
+	return r.db.Where("set_copy_id = ?", setCopyID).Delete(&entity.MissingMinifig{}).Error
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"github.com/BombartSimon/MissingBrick/internal/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // MissingPartsRepository defines the interface for missing part data operations
@@ -10,14 +11,42 @@ type MissingPartsRepository interface {
 	Create(missingPart *entity.MissingPart) error
 	GetByID(id uint) (*entity.MissingPart, error)
 	GetBySetID(setID uint) ([]entity.MissingPart, error)
+	ListBySetID(setID uint, filter MissingPartFilter, query ListQuery) (*Page[entity.MissingPart], error)
 	GetAll() ([]entity.MissingPart, error)
+	GetAllMissing(filter MissingPartFilter) ([]entity.MissingPart, error)
+	GetByKey(key entity.MissingPart) (*entity.MissingPart, error)
 	Update(missingPart *entity.MissingPart) error
 	Delete(id uint) error
-	MarkAsFound(setID uint, partID uint) error
-	MarkAsMissing(setID uint, partID uint) error
+	DeleteBySetCopyID(setCopyID uint) error
+	CreateRecovery(recovery *entity.MissingPartRecovery) error
 	GetMissingBySetID(setID uint) ([]entity.MissingPart, error)
 }
 
+// MissingPartFilter restricts the missing parts returned by GetAllMissing and ListBySetID.
+// Empty fields are ignored.
+type MissingPartFilter struct {
+	SetIDs     []uint
+	SetCopyIDs []uint
+	ThemeIDs   []int
+	PartCatIDs []int
+	ColorID    *int
+	IsSpare    *bool
+	Orphaned   *bool
+}
+
+// missingPartOrder lists the columns missing parts can be sorted by
+var missingPartOrder = listOrder[entity.MissingPart]{
+	idColumn:    "missing_parts.id",
+	id:          func(missingPart entity.MissingPart) uint { return missingPart.ID },
+	defaultSort: "created_at",
+	keys: map[string]sortKey[entity.MissingPart]{
+		"id":         {column: "missing_parts.id", value: func(missingPart entity.MissingPart) interface{} { return missingPart.ID }},
+		"part_id":    {column: "missing_parts.part_id", value: func(missingPart entity.MissingPart) interface{} { return missingPart.PartID }},
+		"color_id":   {column: "missing_parts.color_id", value: func(missingPart entity.MissingPart) interface{} { return missingPart.ColorID }},
+		"created_at": {column: "missing_parts.created_at", value: func(missingPart entity.MissingPart) interface{} { return missingPart.CreatedAt }},
+	},
+}
+
 // missingPartRepository implements MissingPartRepository interface
 type missingPartRepository struct {
 	db *gorm.DB
@@ -33,10 +62,12 @@ func (r *missingPartRepository) Create(missingPart *entity.MissingPart) error {
 	return r.db.Create(missingPart).Error
 }
 
-// GetByID retrieves a missing part by its ID
+// GetByID retrieves a missing part by its ID, with the storage location of its set copy so found
+// pieces can be put back in the right place
 func (r *missingPartRepository) GetByID(id uint) (*entity.MissingPart, error) {
 	var missingPart entity.MissingPart
-	err := r.db.Preload("Set").Preload("Part").First(&missingPart, id).Error
+	err := r.db.Joins("Color").Preload("Set").Preload("SetCopy.StorageLocation").Preload("Part").
+		Preload("Recoveries", orderRecoveries).First(&missingPart, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -46,20 +77,89 @@ func (r *missingPartRepository) GetByID(id uint) (*entity.MissingPart, error) {
 // GetBySetID retrieves all missing parts for a specific set
 func (r *missingPartRepository) GetBySetID(setID uint) ([]entity.MissingPart, error) {
 	var missingParts []entity.MissingPart
-	err := r.db.Where("set_id = ?", setID).Preload("Part").Find(&missingParts).Error
+	err := r.db.Joins("Color").Where("missing_parts.set_id = ?", setID).Preload("Part").Preload("Recoveries", orderRecoveries).Find(&missingParts).Error
 	return missingParts, err
 }
 
+// ListBySetID retrieves a page of the missing parts of a set matching filter, found ones included
+func (r *missingPartRepository) ListBySetID(setID uint, filter MissingPartFilter, query ListQuery) (*Page[entity.MissingPart], error) {
+	filtered := r.db.Model(&entity.MissingPart{}).Where("missing_parts.set_id = ?", setID)
+	filtered = r.applyFilter(filtered, filter)
+
+	return paginate(filtered, missingPartOrder, query, func(db *gorm.DB) *gorm.DB {
+		return db.Joins("Color").Preload("Part").Preload("Recoveries", orderRecoveries)
+	})
+}
+
 // GetAll retrieves all missing parts
 func (r *missingPartRepository) GetAll() ([]entity.MissingPart, error) {
 	var missingParts []entity.MissingPart
-	err := r.db.Preload("Set").Preload("Part").Find(&missingParts).Error
+	err := r.db.Joins("Color").Preload("Set").Preload("Part").Find(&missingParts).Error
 	return missingParts, err
 }
 
-// Update updates a missing part
+// GetAllMissing retrieves the parts still missing from sets that have not been deleted
+func (r *missingPartRepository) GetAllMissing(filter MissingPartFilter) ([]entity.MissingPart, error) {
+	sets := r.db.Model(&entity.Set{}).Select("id")
+	if len(filter.ThemeIDs) > 0 {
+		sets = sets.Where("theme_id IN ?", filter.ThemeIDs)
+	}
+
+	query := r.db.Joins("Color").Preload("Set").Preload("Part").
+		Where("missing_parts.is_missing = ? AND missing_parts.set_id IN (?)", true, sets)
+	query = r.applyFilter(query, filter)
+
+	var missingParts []entity.MissingPart
+	err := query.Order("missing_parts.part_id, missing_parts.color_id, missing_parts.set_id").Find(&missingParts).Error
+	return missingParts, err
+}
+
+// applyFilter restricts query to the missing parts matching the set, set copy, part category,
+// color, spare and orphaned flags of filter. Themes are filtered by the callers as they need a join on sets.
+func (r *missingPartRepository) applyFilter(query *gorm.DB, filter MissingPartFilter) *gorm.DB {
+	if len(filter.SetIDs) > 0 {
+		query = query.Where("missing_parts.set_id IN ?", filter.SetIDs)
+	}
+	if len(filter.SetCopyIDs) > 0 {
+		query = query.Where("missing_parts.set_copy_id IN ?", filter.SetCopyIDs)
+	}
+	if len(filter.PartCatIDs) > 0 {
+		query = query.Where("missing_parts.part_id IN (?)", r.db.Model(&entity.Part{}).Select("id").Where("part_cat_id IN ?", filter.PartCatIDs))
+	}
+	if filter.ColorID != nil {
+		query = query.Where("missing_parts.color_id = ?", *filter.ColorID)
+	}
+	if filter.IsSpare != nil {
+		query = query.Where("missing_parts.is_spare = ?", *filter.IsSpare)
+	}
+	if filter.Orphaned != nil {
+		query = query.Where("missing_parts.orphaned = ?", *filter.Orphaned)
+	}
+	return query
+}
+
+// GetByKey retrieves the missing part recorded for the same set, part, color, spare flag and
+// minifig as key with its recoveries, preferring a row that is still missing over one that was found.
+func (r *missingPartRepository) GetByKey(key entity.MissingPart) (*entity.MissingPart, error) {
+	query := r.db.Where("missing_parts.set_id = ? AND missing_parts.set_copy_id = ? AND missing_parts.part_id = ? AND missing_parts.color_id = ? AND missing_parts.is_spare = ?",
+		key.SetID, key.SetCopyID, key.PartID, key.ColorID, key.IsSpare)
+	if key.SetMinifigID != nil {
+		query = query.Where("missing_parts.set_minifig_id = ?", *key.SetMinifigID)
+	} else {
+		query = query.Where("missing_parts.set_minifig_id IS NULL")
+	}
+
+	var missingPart entity.MissingPart
+	err := query.Preload("Recoveries", orderRecoveries).Order("missing_parts.is_missing DESC, missing_parts.id").First(&missingPart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &missingPart, nil
+}
+
+// Update updates a missing part, leaving its relations untouched
 func (r *missingPartRepository) Update(missingPart *entity.MissingPart) error {
-	return r.db.Save(missingPart).Error
+	return r.db.Omit(clause.Associations).Save(missingPart).Error
 }
 
 // Delete soft deletes a missing part
@@ -67,18 +167,24 @@ func (r *missingPartRepository) Delete(id uint) error {
 	return r.db.Delete(&entity.MissingPart{}, id).Error
 }
 
-// MarkAsFound marks a part as found
-func (r *missingPartRepository) MarkAsFound(setID uint, partID uint) error {
-	return r.db.Model(&entity.MissingPart{}).Where("set_id = ? AND part_id = ?", setID, partID).Update("is_missing", false).Error
+// DeleteBySetCopyID soft deletes the missing parts of a set copy
+func (r *missingPartRepository) DeleteBySetCopyID(setCopyID uint) error {
+	return r.db.Where("set_copy_id = ?", setCopyID).Delete(&entity.MissingPart{}).Error
 }
 
-func (r *missingPartRepository) MarkAsMissing(setID uint, partID uint) error {
-	return r.db.Model(&entity.MissingPart{}).Where("set_id = ? AND part_id = ?", setID, partID).Update("is_missing", true).Error
+// CreateRecovery records pieces of a missing part that were found or reopened
+func (r *missingPartRepository) CreateRecovery(recovery *entity.MissingPartRecovery) error {
+	return r.db.Create(recovery).Error
 }
 
 // GetMissingBySetID retrieves only the missing parts for a specific set
 func (r *missingPartRepository) GetMissingBySetID(setID uint) ([]entity.MissingPart, error) {
 	var missingParts []entity.MissingPart
-	err := r.db.Where("set_id = ? AND is_missing = ?", setID, true).Preload("Part").Find(&missingParts).Error
+	err := r.db.Joins("Color").Where("missing_parts.set_id = ? AND missing_parts.is_missing = ?", setID, true).Preload("Part").Find(&missingParts).Error
 	return missingParts, err
 }
+
+// orderRecoveries lists the recovery history of a missing part in chronological order
+func orderRecoveries(db *gorm.DB) *gorm.DB {
+	return db.Order("missing_part_recoveries.id")
+}
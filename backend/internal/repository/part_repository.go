@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"database/sql"
+
 	"github.com/BombartSimon/MissingBrick/internal/entity"
 	"gorm.io/gorm"
 )
@@ -10,10 +12,25 @@ type PartRepository interface {
 	Create(part *entity.Part) error
 	GetByID(id uint) (*entity.Part, error)
 	GetByPartNum(partNum string) (*entity.Part, error)
+	GetByIDs(ids []uint) ([]entity.Part, error)
 	GetAll() ([]entity.Part, error)
+	GetPage(offset, limit int) ([]entity.Part, int64, error)
 	Update(part *entity.Part) error
 	Delete(id uint) error
-	Search(query string) ([]entity.Part, error)
+	GetUsages(partIDs []uint) ([]PartUsage, error)
+}
+
+// PartUsage is the quantity of a part, in one color, contained in one of our sets
+// (directly or through the set's minifigs)
+type PartUsage struct {
+	PartID    uint
+	SetID     uint
+	SetNum    string
+	SetName   string
+	ColorID   int
+	ColorName string
+	ColorRGB  string
+	Quantity  int
 }
 
 // partRepository implements PartRepository interface
@@ -51,6 +68,27 @@ func (r *partRepository) GetByPartNum(partNum string) (*entity.Part, error) {
 	return &part, nil
 }
 
+// GetByIDs retrieves the parts with the given IDs, in the same order
+func (r *partRepository) GetByIDs(ids []uint) ([]entity.Part, error) {
+	var found []entity.Part
+	if err := r.db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]entity.Part, len(found))
+	for _, part := range found {
+		byID[part.ID] = part
+	}
+
+	parts := make([]entity.Part, 0, len(ids))
+	for _, id := range ids {
+		if part, ok := byID[id]; ok {
+			parts = append(parts, part)
+		}
+	}
+	return parts, nil
+}
+
 // GetAll retrieves all parts
 func (r *partRepository) GetAll() ([]entity.Part, error) {
 	var parts []entity.Part
@@ -58,6 +96,18 @@ func (r *partRepository) GetAll() ([]entity.Part, error) {
 	return parts, err
 }
 
+// GetPage retrieves a page of parts ordered by part number, with the total number of parts
+func (r *partRepository) GetPage(offset, limit int) ([]entity.Part, int64, error) {
+	var total int64
+	if err := r.db.Model(&entity.Part{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var parts []entity.Part
+	err := r.db.Order("part_num").Offset(offset).Limit(limit).Find(&parts).Error
+	return parts, total, err
+}
+
 // Update updates a part
 func (r *partRepository) Update(part *entity.Part) error {
 	return r.db.Save(part).Error
@@ -68,9 +118,29 @@ func (r *partRepository) Delete(id uint) error {
 	return r.db.Delete(&entity.Part{}, id).Error
 }
 
-// Search searches parts by name or part number
-func (r *partRepository) Search(query string) ([]entity.Part, error) {
-	var parts []entity.Part
-	err := r.db.Where("name LIKE ? OR part_num LIKE ?", "%"+query+"%", "%"+query+"%").Find(&parts).Error
-	return parts, err
+// GetUsages retrieves, for each of the given parts, the quantities contained in each set and color.
+// Parts of the set minifigs are counted once per minifig in the set.
+func (r *partRepository) GetUsages(partIDs []uint) ([]PartUsage, error) {
+	var usages []PartUsage
+	if len(partIDs) == 0 {
+		return usages, nil
+	}
+
+	err := r.db.Raw(`SELECT u.part_id, u.set_id, s.set_num, s.name AS set_name,
+			u.color_id, c.name AS color_name, c.rgb AS color_rgb, SUM(u.quantity) AS quantity
+		FROM (
+			SELECT part_id, set_id, color_id, quantity
+			FROM set_parts
+			WHERE deleted_at IS NULL AND part_id IN @parts
+			UNION ALL
+			SELECT mp.part_id, sm.set_id, mp.color_id, mp.quantity * sm.quantity
+			FROM minifig_parts mp
+			JOIN set_minifigs sm ON sm.minifig_id = mp.minifig_id AND sm.deleted_at IS NULL
+			WHERE mp.part_id IN @parts
+		) u
+		JOIN sets s ON s.id = u.set_id AND s.deleted_at IS NULL
+		LEFT JOIN colors c ON c.id = u.color_id
+		GROUP BY u.part_id, u.set_id, u.color_id
+		ORDER BY u.part_id, s.set_num, u.color_id`, sql.Named("parts", partIDs)).Scan(&usages).Error
+	return usages, err
 }
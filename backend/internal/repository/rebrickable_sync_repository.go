@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// RebrickableSyncRepository defines the interface for the state of the Rebrickable collection sync
+type RebrickableSyncRepository interface {
+	GetAccount() (*entity.RebrickableAccount, error)
+	SaveAccount(account *entity.RebrickableAccount) error
+	DeleteAccount() error
+	GetLostParts() ([]entity.RebrickableLostPart, error)
+	GetLostPart(id uint) (*entity.RebrickableLostPart, error)
+	GetConflicts() ([]entity.RebrickableLostPart, error)
+	SaveLostPart(lostPart *entity.RebrickableLostPart) error
+	DeleteLostPart(id uint) error
+	GetPartListItem(listID int, partNum string, colorID int) (*entity.RebrickablePartListItem, error)
+	SavePartListItem(item *entity.RebrickablePartListItem) error
+}
+
+// rebrickableSyncRepository implements RebrickableSyncRepository interface
+type rebrickableSyncRepository struct {
+	db *gorm.DB
+}
+
+// NewRebrickableSyncRepository creates a new Rebrickable sync repository
+func NewRebrickableSyncRepository(db *gorm.DB) RebrickableSyncRepository {
+	return &rebrickableSyncRepository{db: db}
+}
+
+// GetAccount retrieves the linked Rebrickable account
+func (r *rebrickableSyncRepository) GetAccount() (*entity.RebrickableAccount, error) {
+	var account entity.RebrickableAccount
+	err := r.db.Order("id").First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// SaveAccount creates or updates the linked Rebrickable account
+func (r *rebrickableSyncRepository) SaveAccount(account *entity.RebrickableAccount) error {
+	return r.db.Save(account).Error
+}
+
+// DeleteAccount unlinks the Rebrickable account and forgets the state of its sync
+func (r *rebrickableSyncRepository) DeleteAccount() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&entity.RebrickableLostPart{}, &entity.RebrickablePartListItem{}, &entity.RebrickableAccount{}} {
+			if err := tx.Where("1 = 1").Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetLostParts retrieves every link between a Rebrickable lost part and a missing part
+func (r *rebrickableSyncRepository) GetLostParts() ([]entity.RebrickableLostPart, error) {
+	var lostParts []entity.RebrickableLostPart
+	err := r.db.Order("id").Find(&lostParts).Error
+	return lostParts, err
+}
+
+// GetLostPart retrieves a link between a Rebrickable lost part and a missing part
+func (r *rebrickableSyncRepository) GetLostPart(id uint) (*entity.RebrickableLostPart, error) {
+	var lostPart entity.RebrickableLostPart
+	err := r.db.First(&lostPart, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lostPart, nil
+}
+
+// GetConflicts retrieves the lost parts changed on both sides since the last sync
+func (r *rebrickableSyncRepository) GetConflicts() ([]entity.RebrickableLostPart, error) {
+	var lostParts []entity.RebrickableLostPart
+	err := r.db.Where("conflict = ?", true).Order("id").Find(&lostParts).Error
+	return lostParts, err
+}
+
+// SaveLostPart creates or updates a link between a Rebrickable lost part and a missing part
+func (r *rebrickableSyncRepository) SaveLostPart(lostPart *entity.RebrickableLostPart) error {
+	return r.db.Save(lostPart).Error
+}
+
+// DeleteLostPart deletes a link between a Rebrickable lost part and a missing part
+func (r *rebrickableSyncRepository) DeleteLostPart(id uint) error {
+	return r.db.Delete(&entity.RebrickableLostPart{}, id).Error
+}
+
+// GetPartListItem retrieves what was imported from a line of a Rebrickable part list
+func (r *rebrickableSyncRepository) GetPartListItem(listID int, partNum string, colorID int) (*entity.RebrickablePartListItem, error) {
+	var item entity.RebrickablePartListItem
+	err := r.db.Where("list_id = ? AND part_num = ? AND color_id = ?", listID, partNum, colorID).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// SavePartListItem creates or updates what was imported from a line of a Rebrickable part list
+func (r *rebrickableSyncRepository) SavePartListItem(item *entity.RebrickablePartListItem) error {
+	return r.db.Save(item).Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// RefreshRepository defines the interface for the state of the background refresh of sets
+type RefreshRepository interface {
+	CreateRun(run *entity.RefreshRun) error
+	SaveRun(run *entity.RefreshRun) error
+	GetRuns(limit int) ([]entity.RefreshRun, error)
+	GetLastRun() (*entity.RefreshRun, error)
+	FailRunningRuns(reason string) error
+	GetStaleSets(before time.Time, limit int) ([]entity.Set, error)
+	GetSetRefresh(setID uint) (*entity.SetRefresh, error)
+	GetSetRefreshes(failedOnly bool) ([]entity.SetRefresh, error)
+	SaveSetRefresh(setRefresh *entity.SetRefresh) error
+}
+
+// refreshRepository implements RefreshRepository interface
+type refreshRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshRepository creates a new refresh repository
+func NewRefreshRepository(db *gorm.DB) RefreshRepository {
+	return &refreshRepository{db: db}
+}
+
+// CreateRun records the start of a refresh run
+func (r *refreshRepository) CreateRun(run *entity.RefreshRun) error {
+	return r.db.Create(run).Error
+}
+
+// SaveRun updates a refresh run
+func (r *refreshRepository) SaveRun(run *entity.RefreshRun) error {
+	return r.db.Save(run).Error
+}
+
+// GetRuns retrieves the latest refresh runs, newest first
+func (r *refreshRepository) GetRuns(limit int) ([]entity.RefreshRun, error) {
+	var runs []entity.RefreshRun
+	err := r.db.Order("started_at DESC, id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// GetLastRun retrieves the latest refresh run
+func (r *refreshRepository) GetLastRun() (*entity.RefreshRun, error) {
+	var run entity.RefreshRun
+	err := r.db.Order("started_at DESC, id DESC").First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FailRunningRuns marks the runs left running, by a server that stopped during them, as failed
+func (r *refreshRepository) FailRunningRuns(reason string) error {
+	return r.db.Model(&entity.RefreshRun{}).Where("status = ?", entity.RefreshStatusRunning).
+		Updates(map[string]interface{}{"status": entity.RefreshStatusFailed, "error": reason, "finished_at": time.Now()}).Error
+}
+
+// GetStaleSets retrieves up to limit imported sets last refreshed, or else modified, before a UTC time, fewest failures and stalest first
+func (r *refreshRepository) GetStaleSets(before time.Time, limit int) ([]entity.Set, error) {
+	var sets []entity.Set
+	err := r.db.Select("sets.*").
+		Joins("LEFT JOIN set_refreshes ON set_refreshes.set_id = sets.id").
+		Where("COALESCE(set_refreshes.checked_at, sets.last_modified) < ?", before.UTC()).
+		Where("sets.import_status = ?", entity.SetImportStatusDone).
+		Order("COALESCE(set_refreshes.failures, 0), COALESCE(set_refreshes.checked_at, sets.last_modified), sets.id").
+		Limit(limit).Find(&sets).Error
+	return sets, err
+}
+
+// GetSetRefresh retrieves the refresh state of a set
+func (r *refreshRepository) GetSetRefresh(setID uint) (*entity.SetRefresh, error) {
+	var setRefresh entity.SetRefresh
+	err := r.db.Where("set_id = ?", setID).First(&setRefresh).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setRefresh, nil
+}
+
+// GetSetRefreshes retrieves the refresh state of every refreshed set, only the ones whose last
+// refresh failed when failedOnly is set
+func (r *refreshRepository) GetSetRefreshes(failedOnly bool) ([]entity.SetRefresh, error) {
+	query := r.db.Preload("Set")
+	if failedOnly {
+		query = query.Where("failures > 0")
+	}
+
+	var setRefreshes []entity.SetRefresh
+	err := query.Order("set_id").Find(&setRefreshes).Error
+	return setRefreshes, err
+}
+
+// SaveSetRefresh creates or updates the refresh state of a set
+func (r *refreshRepository) SaveSetRefresh(setRefresh *entity.SetRefresh) error {
+	return r.db.Omit("Set").Save(setRefresh).Error
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of documents stored in the search index
+const (
+	SearchKindPart = "part"
+	SearchKindSet  = "set"
+)
+
+// Markers wrapped around the matched terms of highlighted search results
+const (
+	SearchHighlightStart = "<mark>"
+	SearchHighlightEnd   = "</mark>"
+)
+
+// Placeholders FTS5 wraps around matched terms, swapped for the markers once the text is HTML-escaped
+const (
+	searchMatchStart = "\x02"
+	searchMatchEnd   = "\x03"
+)
+
+// SearchRepository defines the interface for full-text search over parts and sets
+type SearchRepository interface {
+	Search(query string, kinds []string, offset, limit int) ([]SearchHit, int64, error)
+}
+
+// SearchHit is a part or set matching a search, best matches having the highest score
+type SearchHit struct {
+	Kind          string
+	RefID         uint
+	Num           string
+	Name          string
+	NumHighlight  string
+	NameHighlight string
+	Score         float64
+}
+
+// searchRepository implements SearchRepository interface
+type searchRepository struct {
+	db             *gorm.DB
+	fullTextSearch bool
+}
+
+// NewSearchRepository creates a new search repository. Without FTS5, searches fall back to LIKE
+// queries, which neither rank nor highlight matches.
+func NewSearchRepository(db *gorm.DB, fullTextSearch bool) SearchRepository {
+	return &searchRepository{db: db, fullTextSearch: fullTextSearch}
+}
+
+// Search finds the parts and sets whose number or name contain every word of query (as a prefix)
+func (r *searchRepository) Search(query string, kinds []string, offset, limit int) ([]SearchHit, int64, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []SearchHit{}, 0, nil
+	}
+	if len(kinds) == 0 {
+		kinds = []string{SearchKindPart, SearchKindSet}
+	}
+
+	if r.fullTextSearch {
+		return r.searchIndex(terms, kinds, offset, limit)
+	}
+	return r.searchLike(strings.TrimSpace(query), terms, kinds, offset, limit)
+}
+
+// searchIndex searches the FTS5 index, ranking with bm25 and weighting numbers above names
+func (r *searchRepository) searchIndex(terms []string, kinds []string, offset, limit int) ([]SearchHit, int64, error) {
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		phrases = append(phrases, `"`+term+`"*`)
+	}
+	match := strings.Join(phrases, " ")
+
+	var total int64
+	err := r.db.Raw("SELECT COUNT(*) FROM search_index WHERE search_index MATCH ? AND kind IN ?", match, kinds).Scan(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := []SearchHit{}
+	err = r.db.Raw(`SELECT kind, ref_id, num, name,
+			highlight(search_index, 2, @start, @end) AS num_highlight,
+			highlight(search_index, 3, @start, @end) AS name_highlight,
+			-bm25(search_index, 0, 0, 10, 1) AS score
+		FROM search_index
+		WHERE search_index MATCH @match AND kind IN @kinds
+		ORDER BY bm25(search_index, 0, 0, 10, 1), num
+		LIMIT @limit OFFSET @offset`,
+		map[string]interface{}{
+			"start": searchMatchStart, "end": searchMatchEnd,
+			"match": match, "kinds": kinds, "limit": limit, "offset": offset,
+		}).Scan(&hits).Error
+	return escapeHighlights(hits), total, err
+}
+
+// searchLike searches parts and sets with LIKE, ranking exact then prefix number matches first
+func (r *searchRepository) searchLike(query string, terms []string, kinds []string, offset, limit int) ([]SearchHit, int64, error) {
+	sources := map[string]string{
+		SearchKindPart: "SELECT 'part' AS kind, id AS ref_id, part_num AS num, name FROM parts WHERE deleted_at IS NULL",
+		SearchKindSet:  "SELECT 'set' AS kind, id AS ref_id, set_num AS num, name FROM sets WHERE deleted_at IS NULL",
+	}
+
+	var selects []string
+	var args []interface{}
+	for _, kind := range kinds {
+		source, ok := sources[kind]
+		if !ok {
+			continue
+		}
+		for _, term := range terms {
+			source += " AND (num LIKE ? OR name LIKE ?)"
+			args = append(args, "%"+term+"%", "%"+term+"%")
+		}
+		selects = append(selects, source)
+	}
+	if len(selects) == 0 {
+		return []SearchHit{}, 0, nil
+	}
+	matches := "(" + strings.Join(selects, " UNION ALL ") + ")"
+
+	var total int64
+	if err := r.db.Raw("SELECT COUNT(*) FROM "+matches, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	hits := []SearchHit{}
+	err := r.db.Raw(fmt.Sprintf(`SELECT kind, ref_id, num, name, num AS num_highlight, name AS name_highlight,
+			CASE WHEN num = ? THEN 3 WHEN num LIKE ? THEN 2 ELSE 1 END AS score
+		FROM %s
+		ORDER BY score DESC, num
+		LIMIT ? OFFSET ?`, matches), append(append([]interface{}{query, query + "%"}, args...), limit, offset)...).Scan(&hits).Error
+	return escapeHighlights(hits), total, err
+}
+
+// escapeHighlights HTML-escapes the highlighted fields of hits so that only the markers are markup
+func escapeHighlights(hits []SearchHit) []SearchHit {
+	for i := range hits {
+		hits[i].NumHighlight = escapeHighlight(hits[i].NumHighlight)
+		hits[i].NameHighlight = escapeHighlight(hits[i].NameHighlight)
+	}
+	return hits
+}
+
+// escapeHighlight HTML-escapes text and turns the match placeholders into markers
+func escapeHighlight(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, searchMatchStart, SearchHighlightStart)
+	return strings.ReplaceAll(text, searchMatchEnd, SearchHighlightEnd)
+}
+
+// searchTerms splits a query into lower-cased words of letters and digits
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
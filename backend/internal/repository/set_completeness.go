@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// SQL expressions of the completeness of a set. They rely on the joins added by joinCompleteness.
+// The required pieces cover every owned copy, counting a set without copies once.
+const (
+	copiesSQL         = "COALESCE(completeness_copies.copies, 0)"
+	piecesRequiredSQL = "(COALESCE(completeness_required.pieces, 0) * MAX(" + copiesSQL + ", 1))"
+	piecesMissingSQL  = "COALESCE(completeness_missing.pieces, 0)"
+	missingLotsSQL    = "COALESCE(completeness_missing.lots, 0)"
+	completenessSQL   = "CASE WHEN " + piecesRequiredSQL + " = 0 THEN 0" +
+		" ELSE ROUND(100.0 * MAX(" + piecesRequiredSQL + " - " + piecesMissingSQL + ", 0) / " + piecesRequiredSQL + ", 2) END"
+)
+
+// setStatusSQL derives the status of a set. A set is incomplete while pieces or minifigs are
+// missing, and complete once it was checked or all of its recorded missing parts were found.
+var setStatusSQL = fmt.Sprintf("CASE WHEN COALESCE(completeness_missing.pieces, 0) > 0"+
+	" OR EXISTS (SELECT 1 FROM missing_parts WHERE missing_parts.set_id = sets.id AND missing_parts.set_minifig_id IS NOT NULL AND missing_parts.is_missing AND missing_parts.deleted_at IS NULL)"+
+	" OR EXISTS (SELECT 1 FROM missing_minifigs WHERE missing_minifigs.set_id = sets.id AND missing_minifigs.is_missing AND missing_minifigs.deleted_at IS NULL) THEN '%s'"+
+	" WHEN sets.checked_at IS NOT NULL OR COALESCE(completeness_missing.records, 0) > 0 THEN '%s'"+
+	" ELSE '%s' END", entity.SetStatusIncomplete, entity.SetStatusComplete, entity.SetStatusUnchecked)
+
+// joinCompleteness joins the copies, required and missing pieces of each set. Minifig parts are left out
+// since set_parts does not list them, and spares only count when includeSpares is set.
+func joinCompleteness(db *gorm.DB, includeSpares bool) *gorm.DB {
+	spares := " AND NOT is_spare"
+	if includeSpares {
+		spares = ""
+	}
+
+	return db.
+		Joins("LEFT JOIN (SELECT set_id, COUNT(*) AS copies FROM set_copies" +
+			" WHERE deleted_at IS NULL GROUP BY set_id) AS completeness_copies ON completeness_copies.set_id = sets.id").
+		Joins("LEFT JOIN (SELECT set_id, SUM(quantity) AS pieces FROM set_parts" +
+			" WHERE deleted_at IS NULL" + spares + " GROUP BY set_id) AS completeness_required ON completeness_required.set_id = sets.id").
+		Joins("LEFT JOIN (SELECT set_id, SUM(CASE WHEN is_missing THEN quantity ELSE 0 END) AS pieces," +
+			" COUNT(DISTINCT CASE WHEN is_missing THEN part_id || '/' || color_id END) AS lots, COUNT(*) AS records FROM missing_parts" +
+			" WHERE deleted_at IS NULL AND set_minifig_id IS NULL" + spares + " GROUP BY set_id) AS completeness_missing ON completeness_missing.set_id = sets.id")
+}
+
+// selectCompleteness selects the columns of a set together with its computed completeness
+func selectCompleteness(db *gorm.DB) *gorm.DB {
+	return db.Select("sets.*, " +
+		copiesSQL + " AS copies, " +
+		piecesRequiredSQL + " AS pieces_required, " +
+		piecesMissingSQL + " AS pieces_missing, " +
+		missingLotsSQL + " AS missing_lots, " +
+		completenessSQL + " AS completeness, " +
+		setStatusSQL + " AS status")
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+func TestSetCompleteness(t *testing.T) {
+	setMinifigID := uint(1)
+	checkedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		copies        int
+		deletedCopies int
+		checkedAt     *time.Time
+		missing       []entity.MissingPart
+		found         []entity.MissingPart
+		includeSpares bool
+		want          entity.Set
+	}{
+		{
+			name:   "without copies the set is required once",
+			copies: 0,
+			want:   entity.Set{PiecesRequired: 6, Completeness: 100, Status: entity.SetStatusUnchecked},
+		},
+		{
+			name:   "required pieces cover every copy",
+			copies: 3,
+			want:   entity.Set{Copies: 3, PiecesRequired: 18, Completeness: 100, Status: entity.SetStatusUnchecked},
+		},
+		{
+			name:          "deleted copies are not required",
+			copies:        2,
+			deletedCopies: 1,
+			want:          entity.Set{Copies: 2, PiecesRequired: 12, Completeness: 100, Status: entity.SetStatusUnchecked},
+		},
+		{
+			name:   "missing pieces of every copy add up",
+			copies: 2,
+			missing: []entity.MissingPart{
+				{PartID: 1, ColorID: 4, Quantity: 2},
+				{PartID: 1, ColorID: 4, Quantity: 1},
+				{PartID: 2, ColorID: 0, Quantity: 1},
+			},
+			want: entity.Set{Copies: 2, PiecesRequired: 12, PiecesMissing: 4, MissingLots: 2, Completeness: 66.67, Status: entity.SetStatusIncomplete},
+		},
+		{
+			name:    "missing spares are left out",
+			copies:  1,
+			missing: []entity.MissingPart{{PartID: 3, ColorID: 0, Quantity: 1, IsSpare: true}},
+			want:    entity.Set{Copies: 1, PiecesRequired: 6, Completeness: 100, Status: entity.SetStatusUnchecked},
+		},
+		{
+			name:          "missing spares count when spares are included",
+			copies:        1,
+			includeSpares: true,
+			missing:       []entity.MissingPart{{PartID: 3, ColorID: 0, Quantity: 1, IsSpare: true}},
+			want:          entity.Set{Copies: 1, PiecesRequired: 7, PiecesMissing: 1, MissingLots: 1, Completeness: 85.71, Status: entity.SetStatusIncomplete},
+		},
+		{
+			name:    "missing minifig parts only make the set incomplete",
+			copies:  1,
+			missing: []entity.MissingPart{{PartID: 1, ColorID: 4, Quantity: 1, SetMinifigID: &setMinifigID}},
+			want:    entity.Set{Copies: 1, PiecesRequired: 6, Completeness: 100, Status: entity.SetStatusIncomplete},
+		},
+		{
+			name:   "found parts make the set complete",
+			copies: 1,
+			found:  []entity.MissingPart{{PartID: 1, ColorID: 4, Quantity: 2}},
+			want:   entity.Set{Copies: 1, PiecesRequired: 6, Completeness: 100, Status: entity.SetStatusComplete},
+		},
+		{
+			name:      "checked sets are complete",
+			copies:    1,
+			checkedAt: &checkedAt,
+			want:      entity.Set{Copies: 1, PiecesRequired: 6, Completeness: 100, Status: entity.SetStatusComplete},
+		},
+		{
+			name:    "more missing than required",
+			copies:  1,
+			missing: []entity.MissingPart{{PartID: 1, ColorID: 4, Quantity: 9}},
+			want:    entity.Set{Copies: 1, PiecesRequired: 6, PiecesMissing: 9, MissingLots: 1, Status: entity.SetStatusIncomplete},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			mustCreate(t, db, &entity.Set{ID: 1, SetNum: "1-1", Name: "Castle", CheckedAt: tt.checkedAt})
+			mustCreate(t, db, []entity.SetPart{
+				{SetID: 1, PartID: 1, ColorID: 4, Quantity: 4},
+				{SetID: 1, PartID: 2, ColorID: 0, Quantity: 2},
+				{SetID: 1, PartID: 3, ColorID: 0, Quantity: 1, IsSpare: true},
+			})
+			createCopies(t, db, tt.copies, tt.deletedCopies)
+			for _, missingPart := range tt.missing {
+				missingPart.SetID = 1
+				mustCreate(t, db, &missingPart)
+			}
+			for _, foundPart := range tt.found {
+				foundPart.SetID = 1
+				mustCreate(t, db, &foundPart)
+				if err := db.Model(&foundPart).Update("is_missing", false).Error; err != nil {
+					t.Fatalf("failed to mark part as found: %v", err)
+				}
+			}
+
+			page, err := NewSetRepository(db).List(SetFilter{IncludeSpares: tt.includeSpares}, ListQuery{})
+			if err != nil {
+				t.Fatalf("failed to list sets: %v", err)
+			}
+			if len(page.Items) != 1 {
+				t.Fatalf("listed %d sets, want 1", len(page.Items))
+			}
+			assertCompleteness(t, page.Items[0], tt.want)
+
+			if !tt.includeSpares {
+				set, err := NewSetRepository(db).GetByID(1)
+				if err != nil {
+					t.Fatalf("failed to get set: %v", err)
+				}
+				assertCompleteness(t, *set, tt.want)
+			}
+		})
+	}
+}
+
+// createCopies creates the copies of set 1, soft deleting the extra ones
+func createCopies(t *testing.T, db *gorm.DB, copies, deletedCopies int) {
+	t.Helper()
+
+	for i := 0; i < copies+deletedCopies; i++ {
+		setCopy := &entity.SetCopy{SetID: 1}
+		mustCreate(t, db, setCopy)
+		if i >= copies {
+			if err := db.Delete(setCopy).Error; err != nil {
+				t.Fatalf("failed to delete copy: %v", err)
+			}
+		}
+	}
+}
+
+// assertCompleteness compares the computed completeness of a set with want
+func assertCompleteness(t *testing.T, got, want entity.Set) {
+	t.Helper()
+
+	if got.Copies != want.Copies || got.PiecesRequired != want.PiecesRequired || got.PiecesMissing != want.PiecesMissing ||
+		got.MissingLots != want.MissingLots || got.Completeness != want.Completeness || got.Status != want.Status {
+		t.Errorf("completeness = {copies: %d, required: %d, missing: %d, lots: %d, completeness: %v, status: %s}, "+
+			"want {copies: %d, required: %d, missing: %d, lots: %d, completeness: %v, status: %s}",
+			got.Copies, got.PiecesRequired, got.PiecesMissing, got.MissingLots, got.Completeness, got.Status,
+			want.Copies, want.PiecesRequired, want.PiecesMissing, want.MissingLots, want.Completeness, want.Status)
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// SetCopyRepository defines the interface for owned set copy data operations
+type SetCopyRepository interface {
+	Create(setCopy *entity.SetCopy) error
+	GetByID(id uint) (*entity.SetCopy, error)
+	GetBySetID(setID uint) ([]entity.SetCopy, error)
+	GetForSet(setID, id uint) (*entity.SetCopy, error)
+	GetByStorageLocationIDs(locationIDs []uint) ([]entity.SetCopy, error)
+	UpdateStorageLocation(ids []uint, locationID *uint) error
+	Delete(id uint) error
+}
+
+// setCopyRepository implements SetCopyRepository interface
+type setCopyRepository struct {
+	db *gorm.DB
+}
+
+// NewSetCopyRepository creates a new set copy repository
+func NewSetCopyRepository(db *gorm.DB) SetCopyRepository {
+	return &setCopyRepository{db: db}
+}
+
+// Create creates a new set copy
+func (r *setCopyRepository) Create(setCopy *entity.SetCopy) error {
+	return r.db.Omit("Set", "StorageLocation").Create(setCopy).Error
+}
+
+// GetByID retrieves a set copy with its storage location, missing parts and missing minifigs
+func (r *setCopyRepository) GetByID(id uint) (*entity.SetCopy, error) {
+	var setCopy entity.SetCopy
+	err := r.withMissingCounts().Preload("StorageLocation").Preload("MissingParts", func(db *gorm.DB) *gorm.DB {
+		return db.Joins("Color")
+	}).Preload("MissingParts.Part").Preload("MissingMinifigs.Minifig").First(&setCopy, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setCopy, nil
+}
+
+// GetBySetID retrieves the copies of a set, oldest first
+func (r *setCopyRepository) GetBySetID(setID uint) ([]entity.SetCopy, error) {
+	var setCopies []entity.SetCopy
+	err := r.withMissingCounts().Preload("StorageLocation").Where("set_copies.set_id = ?", setID).Order("set_copies.id").Find(&setCopies).Error
+	return setCopies, err
+}
+
+// GetForSet retrieves the copy id of a set, or the oldest copy of the set when id is 0
+func (r *setCopyRepository) GetForSet(setID, id uint) (*entity.SetCopy, error) {
+	query := r.db.Where("set_id = ?", setID)
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	}
+
+	var setCopy entity.SetCopy
+	err := query.Order("id").First(&setCopy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setCopy, nil
+}
+
+// GetByStorageLocationIDs retrieves the copies stored in any of the given locations, with their set and location
+func (r *setCopyRepository) GetByStorageLocationIDs(locationIDs []uint) ([]entity.SetCopy, error) {
+	var setCopies []entity.SetCopy
+	err := r.withMissingCounts().Preload("Set").Preload("StorageLocation").
+		Where("set_copies.storage_location_id IN ?", locationIDs).
+		Order("set_copies.id").Find(&setCopies).Error
+	return setCopies, err
+}
+
+// UpdateStorageLocation moves set copies to a storage location, or out of any when locationID is nil
+func (r *setCopyRepository) UpdateStorageLocation(ids []uint, locationID *uint) error {
+	return r.db.Model(&entity.SetCopy{}).Where("id IN ?", ids).Update("storage_location_id", locationID).Error
+}
+
+// Delete soft deletes a set copy
+func (r *setCopyRepository) Delete(id uint) error {
+	return r.db.Delete(&entity.SetCopy{}, id).Error
+}
+
+// withMissingCounts selects the columns of a copy together with the pieces and lots still
+// missing from it, minifig parts and spares included
+func (r *setCopyRepository) withMissingCounts() *gorm.DB {
+	return r.db.Select("set_copies.*," +
+		" COALESCE((SELECT SUM(quantity) FROM missing_parts WHERE missing_parts.set_copy_id = set_copies.id" +
+		" AND missing_parts.is_missing AND missing_parts.deleted_at IS NULL), 0) AS pieces_missing," +
+		" (SELECT COUNT(DISTINCT part_id || '/' || color_id) FROM missing_parts WHERE missing_parts.set_copy_id = set_copies.id" +
+		" AND missing_parts.is_missing AND missing_parts.deleted_at IS NULL) AS missing_lots")
+}
@@ -10,12 +10,32 @@ type SetPartRepository interface {
 	Create(setPart *entity.SetPart) error
 	CreateBatch(setParts []entity.SetPart) error
 	GetBySetID(setID uint) ([]entity.SetPart, error)
+	ListBySetID(setID uint, filter SetPartFilter, query ListQuery) (*Page[entity.SetPart], error)
 	GetByID(id uint) (*entity.SetPart, error)
 	Update(setPart *entity.SetPart) error
 	Delete(id uint) error
 	DeleteBySetID(setID uint) error
 }
 
+// SetPartFilter restricts the set parts returned by ListBySetID. Nil fields are ignored.
+type SetPartFilter struct {
+	ColorID *int
+	IsSpare *bool
+}
+
+// setPartOrder lists the columns set parts can be sorted by
+var setPartOrder = listOrder[entity.SetPart]{
+	idColumn:    "set_parts.id",
+	id:          func(setPart entity.SetPart) uint { return setPart.ID },
+	defaultSort: "id",
+	keys: map[string]sortKey[entity.SetPart]{
+		"id":         {column: "set_parts.id", value: func(setPart entity.SetPart) interface{} { return setPart.ID }},
+		"part_id":    {column: "set_parts.part_id", value: func(setPart entity.SetPart) interface{} { return setPart.PartID }},
+		"color_id":   {column: "set_parts.color_id", value: func(setPart entity.SetPart) interface{} { return setPart.ColorID }},
+		"created_at": {column: "set_parts.created_at", value: func(setPart entity.SetPart) interface{} { return setPart.CreatedAt }},
+	},
+}
+
 // setPartRepository implements SetPartRepository interface
 type setPartRepository struct {
 	db *gorm.DB
@@ -39,14 +59,29 @@ func (r *setPartRepository) CreateBatch(setParts []entity.SetPart) error {
 // GetBySetID retrieves all parts for a specific set
 func (r *setPartRepository) GetBySetID(setID uint) ([]entity.SetPart, error) {
 	var setParts []entity.SetPart
-	err := r.db.Where("set_id = ?", setID).Preload("Part").Find(&setParts).Error
+	err := r.db.Joins("Color").Where("set_parts.set_id = ?", setID).Preload("Part").Find(&setParts).Error
 	return setParts, err
 }
 
+// ListBySetID retrieves a page of the parts of a set matching filter
+func (r *setPartRepository) ListBySetID(setID uint, filter SetPartFilter, query ListQuery) (*Page[entity.SetPart], error) {
+	filtered := r.db.Model(&entity.SetPart{}).Where("set_parts.set_id = ?", setID)
+	if filter.ColorID != nil {
+		filtered = filtered.Where("set_parts.color_id = ?", *filter.ColorID)
+	}
+	if filter.IsSpare != nil {
+		filtered = filtered.Where("set_parts.is_spare = ?", *filter.IsSpare)
+	}
+
+	return paginate(filtered, setPartOrder, query, func(db *gorm.DB) *gorm.DB {
+		return db.Joins("Color").Preload("Part")
+	})
+}
+
 // GetByID retrieves a set part by its ID
 func (r *setPartRepository) GetByID(id uint) (*entity.SetPart, error) {
 	var setPart entity.SetPart
-	err := r.db.Preload("Set").Preload("Part").First(&setPart, id).Error
+	err := r.db.Joins("Color").Preload("Set").Preload("Part").First(&setPart, id).Error
 	if err != nil {
 		return nil, err
 	}
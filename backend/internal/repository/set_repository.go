@@ -10,10 +10,41 @@ type SetRepository interface {
 	Create(set *entity.Set) error
 	GetByID(id uint) (*entity.Set, error)
 	GetBySetNum(setNum string) (*entity.Set, error)
-	GetAll() ([]entity.Set, error)
+	List(filter SetFilter, query ListQuery) (*Page[entity.Set], error)
 	Update(set *entity.Set) error
+	UpdateImportStatus(id uint, status string) error
 	Delete(id uint) error
 	GetWithMissingParts(id uint) (*entity.Set, error)
+	GetDonors() ([]entity.Set, error)
+}
+
+// SetFilter restricts the sets returned by List. Nil and empty fields are ignored.
+// IncludeSpares counts spare parts in the computed completeness.
+type SetFilter struct {
+	YearMin         *int
+	YearMax         *int
+	ThemeIDs        []int
+	HasMissingParts *bool
+	IsDonor         *bool
+	Status          string
+	CompletenessMin *float64
+	CompletenessMax *float64
+	IncludeSpares   bool
+}
+
+// setOrder lists the columns sets can be sorted by
+var setOrder = listOrder[entity.Set]{
+	idColumn:    "sets.id",
+	id:          func(set entity.Set) uint { return set.ID },
+	defaultSort: "created_at",
+	keys: map[string]sortKey[entity.Set]{
+		"year":         {column: "sets.year", value: func(set entity.Set) interface{} { return set.Year }},
+		"name":         {column: "sets.name", value: func(set entity.Set) interface{} { return set.Name }},
+		"num_parts":    {column: "sets.num_parts", value: func(set entity.Set) interface{} { return set.NumParts }},
+		"created_at":   {column: "sets.created_at", value: func(set entity.Set) interface{} { return set.CreatedAt }},
+		"completeness": {column: "(" + completenessSQL + ")", value: func(set entity.Set) interface{} { return set.Completeness }},
+		"missing_lots": {column: missingLotsSQL, value: func(set entity.Set) interface{} { return set.MissingLots }},
+	},
 }
 
 // setRepository implements SetRepository interface
@@ -34,7 +65,7 @@ func (r *setRepository) Create(set *entity.Set) error {
 // GetByID retrieves a set by its ID
 func (r *setRepository) GetByID(id uint) (*entity.Set, error) {
 	var set entity.Set
-	err := r.db.First(&set, id).Error
+	err := r.withDetails().First(&set, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -44,23 +75,61 @@ func (r *setRepository) GetByID(id uint) (*entity.Set, error) {
 // GetBySetNum retrieves a set by its set number
 func (r *setRepository) GetBySetNum(setNum string) (*entity.Set, error) {
 	var set entity.Set
-	err := r.db.Where("set_num = ?", setNum).First(&set).Error
+	err := r.withDetails().Where("sets.set_num = ?", setNum).First(&set).Error
 	if err != nil {
 		return nil, err
 	}
 	return &set, nil
 }
 
-// GetAll retrieves all sets
-func (r *setRepository) GetAll() ([]entity.Set, error) {
-	var sets []entity.Set
-	err := r.db.Find(&sets).Error
-	return sets, err
+// List retrieves a page of sets matching filter, with their completeness. A set has missing
+// parts while one of its parts or minifigs is still marked as missing.
+func (r *setRepository) List(filter SetFilter, query ListQuery) (*Page[entity.Set], error) {
+	filtered := joinCompleteness(r.db.Model(&entity.Set{}), filter.IncludeSpares)
+	if filter.YearMin != nil {
+		filtered = filtered.Where("sets.year >= ?", *filter.YearMin)
+	}
+	if filter.YearMax != nil {
+		filtered = filtered.Where("sets.year <= ?", *filter.YearMax)
+	}
+	if len(filter.ThemeIDs) > 0 {
+		filtered = filtered.Where("sets.theme_id IN ?", filter.ThemeIDs)
+	}
+	if filter.HasMissingParts != nil {
+		missing := "(EXISTS (SELECT 1 FROM missing_parts WHERE missing_parts.set_id = sets.id AND missing_parts.is_missing AND missing_parts.deleted_at IS NULL)" +
+			" OR EXISTS (SELECT 1 FROM missing_minifigs WHERE missing_minifigs.set_id = sets.id AND missing_minifigs.is_missing AND missing_minifigs.deleted_at IS NULL))"
+		if !*filter.HasMissingParts {
+			missing = "NOT " + missing
+		}
+		filtered = filtered.Where(missing)
+	}
+	if filter.IsDonor != nil {
+		filtered = filtered.Where("sets.is_donor = ?", *filter.IsDonor)
+	}
+	if filter.Status != "" {
+		filtered = filtered.Where("("+setStatusSQL+") = ?", filter.Status)
+	}
+	if filter.CompletenessMin != nil {
+		filtered = filtered.Where("("+completenessSQL+") >= ?", *filter.CompletenessMin)
+	}
+	if filter.CompletenessMax != nil {
+		filtered = filtered.Where("("+completenessSQL+") <= ?", *filter.CompletenessMax)
+	}
+
+	return paginate(filtered, setOrder, query, func(db *gorm.DB) *gorm.DB {
+		return selectCompleteness(db).Preload("Theme.Parent.Parent")
+	})
 }
 
-// Update updates a set
+// Update updates a set. The theme is read-only here so that theme_id stays authoritative, and the
+// import status is only changed by the import jobs.
 func (r *setRepository) Update(set *entity.Set) error {
-	return r.db.Save(set).Error
+	return r.db.Omit("Theme", "ImportStatus").Save(set).Error
+}
+
+// UpdateImportStatus sets the import status of a set
+func (r *setRepository) UpdateImportStatus(id uint, status string) error {
+	return r.db.Model(&entity.Set{}).Where("id = ?", id).Update("import_status", status).Error
 }
 
 // Delete soft deletes a set
@@ -68,12 +137,27 @@ func (r *setRepository) Delete(id uint) error {
 	return r.db.Delete(&entity.Set{}, id).Error
 }
 
-// GetWithMissingParts retrieves a set with its missing parts
+// GetWithMissingParts retrieves a set with its missing parts and missing minifigs
 func (r *setRepository) GetWithMissingParts(id uint) (*entity.Set, error) {
 	var set entity.Set
-	err := r.db.Preload("MissingParts.Part").First(&set, id).Error
+	err := r.withDetails().Preload("MissingParts", func(db *gorm.DB) *gorm.DB {
+		return db.Joins("Color")
+	}).Preload("MissingParts.Part").Preload("MissingMinifigs.Minifig").First(&set, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &set, nil
 }
+
+// GetDonors retrieves the sets flagged as part donors
+func (r *setRepository) GetDonors() ([]entity.Set, error) {
+	var sets []entity.Set
+	err := r.db.Where("is_donor = ?", true).Order("id").Find(&sets).Error
+	return sets, err
+}
+
+// withDetails preloads the theme of a set together with its parent themes, and selects its
+// completeness with spares excluded
+func (r *setRepository) withDetails() *gorm.DB {
+	return selectCompleteness(joinCompleteness(r.db, false)).Preload("Theme.Parent.Parent")
+}
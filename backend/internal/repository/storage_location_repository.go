@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+)
+
+// StorageLocationRepository defines the interface for storage location data operations
+type StorageLocationRepository interface {
+	Create(location *entity.StorageLocation) error
+	GetByID(id uint) (*entity.StorageLocation, error)
+	GetAll() ([]entity.StorageLocation, error)
+	Update(location *entity.StorageLocation) error
+	Delete(id uint) error
+}
+
+// storageLocationRepository implements StorageLocationRepository interface
+type storageLocationRepository struct {
+	db *gorm.DB
+}
+
+// NewStorageLocationRepository creates a new storage location repository
+func NewStorageLocationRepository(db *gorm.DB) StorageLocationRepository {
+	return &storageLocationRepository{db: db}
+}
+
+// Create creates a new storage location
+func (r *storageLocationRepository) Create(location *entity.StorageLocation) error {
+	return r.db.Omit("Children").Create(location).Error
+}
+
+// GetByID retrieves a storage location with its direct children
+func (r *storageLocationRepository) GetByID(id uint) (*entity.StorageLocation, error) {
+	var location entity.StorageLocation
+	err := r.db.Preload("Children", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	}).First(&location, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// GetAll retrieves every storage location ordered by path
+func (r *storageLocationRepository) GetAll() ([]entity.StorageLocation, error) {
+	var locations []entity.StorageLocation
+	err := r.db.Order("path").Order("id").Find(&locations).Error
+	return locations, err
+}
+
+// Update updates a storage location
+func (r *storageLocationRepository) Update(location *entity.StorageLocation) error {
+	return r.db.Omit("Children").Save(location).Error
+}
+
+// Delete deletes a storage location
+func (r *storageLocationRepository) Delete(id uint) error {
+	return r.db.Delete(&entity.StorageLocation{}, id).Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ThemeRepository defines the interface for theme data operations
+type ThemeRepository interface {
+	UpsertBatch(themes []entity.Theme) error
+	GetByID(id int) (*entity.Theme, error)
+	GetAll() ([]entity.Theme, error)
+	GetDescendantIDs(id int) ([]int, error)
+}
+
+// themeRepository implements ThemeRepository interface
+type themeRepository struct {
+	db *gorm.DB
+}
+
+// NewThemeRepository creates a new theme repository
+func NewThemeRepository(db *gorm.DB) ThemeRepository {
+	return &themeRepository{db: db}
+}
+
+// UpsertBatch creates themes or updates them when their ID already exists
+func (r *themeRepository) UpsertBatch(themes []entity.Theme) error {
+	if len(themes) == 0 {
+		return nil
+	}
+
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "parent_id", "updated_at"}),
+	}).CreateInBatches(themes, 100).Error
+}
+
+// GetByID retrieves a theme with its ancestors
+func (r *themeRepository) GetByID(id int) (*entity.Theme, error) {
+	var theme entity.Theme
+	err := r.db.Preload("Parent.Parent").First(&theme, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &theme, nil
+}
+
+// GetAll retrieves all themes ordered by name
+func (r *themeRepository) GetAll() ([]entity.Theme, error) {
+	var themes []entity.Theme
+	err := r.db.Order("name").Find(&themes).Error
+	return themes, err
+}
+
+// GetDescendantIDs returns the ID of a theme followed by the IDs of all its sub-themes
+func (r *themeRepository) GetDescendantIDs(id int) ([]int, error) {
+	var ids []int
+	err := r.db.Raw(`WITH RECURSIVE subtree(id) AS (
+			SELECT ?
+			UNION
+			SELECT themes.id FROM themes JOIN subtree ON themes.parent_id = subtree.id
+		)
+		SELECT id FROM subtree`, id).Scan(&ids).Error
+	return ids, err
+}
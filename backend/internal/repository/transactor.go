@@ -0,0 +1,47 @@
+package repository
+
+import "gorm.io/gorm"
+
+// TxRepositories gives access to repositories bound to a single database transaction
+type TxRepositories struct {
+	Sets             SetRepository
+	SetParts         SetPartRepository
+	MissingParts     MissingPartsRepository
+	MissingMinifigs  MissingMinifigRepository
+	SetCopies        SetCopyRepository
+	LooseParts       LoosePartRepository
+	StorageLocations StorageLocationRepository
+	IdempotencyKeys  IdempotencyKeyRepository
+}
+
+// Transactor runs a unit of work within a database transaction
+type Transactor interface {
+	Transaction(fn func(repos TxRepositories) error) error
+}
+
+// transactor implements Transactor interface
+type transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor creates a new transactor
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
+
+// Transaction calls fn with repositories bound to a new transaction.
+// The transaction is committed when fn returns nil and rolled back otherwise.
+func (t *transactor) Transaction(fn func(repos TxRepositories) error) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		return fn(TxRepositories{
+			Sets:             NewSetRepository(tx),
+			SetParts:         NewSetPartRepository(tx),
+			MissingParts:     NewMissingPartRepository(tx),
+			MissingMinifigs:  NewMissingMinifigRepository(tx),
+			SetCopies:        NewSetCopyRepository(tx),
+			LooseParts:       NewLoosePartRepository(tx),
+			StorageLocations: NewStorageLocationRepository(tx),
+			IdempotencyKeys:  NewIdempotencyKeyRepository(tx),
+		})
+	})
+}
@@ -7,17 +7,45 @@ import (
 
 // Router holds all the handlers
 type Router struct {
-	setHandler          *handler.SetHandler
-	setPartsHandler     *handler.SetPartsHandler
-	missingPartsHandler *handler.MissingPartsHandler
+	setHandler             *handler.SetHandler
+	setCopyHandler         *handler.SetCopyHandler
+	setPartsHandler        *handler.SetPartsHandler
+	missingPartsHandler    *handler.MissingPartsHandler
+	colorHandler           *handler.ColorHandler
+	themeHandler           *handler.ThemeHandler
+	minifigHandler         *handler.MinifigHandler
+	exportHandler          *handler.ExportHandler
+	partHandler            *handler.PartHandler
+	searchHandler          *handler.SearchHandler
+	loosePartHandler       *handler.LoosePartHandler
+	fulfillmentHandler     *handler.FulfillmentHandler
+	buildabilityHandler    *handler.BuildabilityHandler
+	storageLocationHandler *handler.StorageLocationHandler
+	rebrickableSyncHandler *handler.RebrickableSyncHandler
+	refreshHandler         *handler.RefreshHandler
+	importJobHandler       *handler.ImportJobHandler
 }
 
 // NewRouter creates a new router with all handlers
-func NewRouter(setHandler *handler.SetHandler, setPartsHandler *handler.SetPartsHandler, missingPartsHandler *handler.MissingPartsHandler) *Router {
+func NewRouter(setHandler *handler.SetHandler, setCopyHandler *handler.SetCopyHandler, setPartsHandler *handler.SetPartsHandler, missingPartsHandler *handler.MissingPartsHandler, colorHandler *handler.ColorHandler, themeHandler *handler.ThemeHandler, minifigHandler *handler.MinifigHandler, exportHandler *handler.ExportHandler, partHandler *handler.PartHandler, searchHandler *handler.SearchHandler, loosePartHandler *handler.LoosePartHandler, fulfillmentHandler *handler.FulfillmentHandler, buildabilityHandler *handler.BuildabilityHandler, storageLocationHandler *handler.StorageLocationHandler, rebrickableSyncHandler *handler.RebrickableSyncHandler, refreshHandler *handler.RefreshHandler, importJobHandler *handler.ImportJobHandler) *Router {
 	return &Router{
-		setHandler:          setHandler,
-		setPartsHandler:     setPartsHandler,
-		missingPartsHandler: missingPartsHandler,
+		setHandler:             setHandler,
+		setCopyHandler:         setCopyHandler,
+		setPartsHandler:        setPartsHandler,
+		missingPartsHandler:    missingPartsHandler,
+		colorHandler:           colorHandler,
+		themeHandler:           themeHandler,
+		minifigHandler:         minifigHandler,
+		exportHandler:          exportHandler,
+		partHandler:            partHandler,
+		searchHandler:          searchHandler,
+		loosePartHandler:       loosePartHandler,
+		fulfillmentHandler:     fulfillmentHandler,
+		buildabilityHandler:    buildabilityHandler,
+		storageLocationHandler: storageLocationHandler,
+		rebrickableSyncHandler: rebrickableSyncHandler,
+		refreshHandler:         refreshHandler,
+		importJobHandler:       importJobHandler,
 	}
 }
 
@@ -29,7 +57,8 @@ func (r *Router) SetupRoutes() *gin.Engine {
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "Content-Disposition, X-Unmapped-Rows")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -56,24 +85,48 @@ func (r *Router) SetupRoutes() *gin.Engine {
 			sets.GET("/by-num/:setNum", r.setHandler.GetSetBySetNum)
 			sets.GET("/:id/missing-parts", r.setHandler.GetSetWithMissingParts)
 			sets.GET("/:id/with-parts", r.setHandler.GetSetWithParts)
+			sets.GET("/:id/copies", r.setCopyHandler.GetCopies)
 			// POST
 			sets.POST("", r.setHandler.CreateSet)
+			sets.POST("/bulk", r.setHandler.ImportSetList)
 			sets.POST("/sync", r.setHandler.SyncSetFromRebrickable)
+			sets.POST("/:id/resync", r.setHandler.ResyncSet)
+			sets.POST("/:id/missing-parts/import", r.missingPartsHandler.ImportMissingParts)
+			sets.POST("/:id/copies", r.setCopyHandler.CreateCopy)
 			// PUT
 			sets.PUT("/:id", r.setHandler.UpdateSet)
 			// DELETE
 			sets.DELETE("/:id", r.setHandler.DeleteSet)
 		}
 
+		// Set copy routes
+		copies := v1.Group("/copies")
+		{
+			copies.GET("/:id", r.setCopyHandler.GetCopy)
+			copies.DELETE("/:id", r.setCopyHandler.DeleteCopy)
+		}
+
 		// Missing Parts routes
 		missingParts := v1.Group("/missing-parts")
 		{
 			// POST
 			missingParts.POST("", r.missingPartsHandler.AssignMissingPartsToSet)
+			missingParts.POST("/minifigs", r.missingPartsHandler.AssignMissingMinifigsToSet)
+			missingParts.POST("/fulfillment/apply", r.fulfillmentHandler.ApplyFulfillment)
 			// GET
+			missingParts.GET("/summary", r.missingPartsHandler.GetMissingPartsSummary)
+			missingParts.GET("/fulfillment", r.fulfillmentHandler.GetFulfillmentPlan)
+			missingParts.GET("/export/bricklink", r.exportHandler.ExportBrickLinkWantedList)
+			missingParts.GET("/export/rebrickable", r.exportHandler.ExportRebrickableCSV)
+			missingParts.GET("/export/brickowl", r.exportHandler.ExportBrickOwlWishlist)
 			missingParts.GET("/:set_id", r.missingPartsHandler.GetMissingPartsBySetID)
+			missingParts.GET("/minifigs/:set_id", r.missingPartsHandler.GetMissingMinifigsBySetID)
+			// PUT
+			missingParts.PUT("/:missing_part_id/found", r.missingPartsHandler.MarkPartAsFound)
+			missingParts.PUT("/:missing_part_id/reopen", r.missingPartsHandler.ReopenMissingPart)
 			// DELETE
 			missingParts.DELETE("/:missing_part_id", r.missingPartsHandler.DeleteMissingPart)
+			missingParts.DELETE("/minifigs/:missing_minifig_id", r.missingPartsHandler.DeleteMissingMinifig)
 
 		}
 
@@ -84,16 +137,117 @@ func (r *Router) SetupRoutes() *gin.Engine {
 			setParts.GET("/:id", r.setPartsHandler.GetSetParts)
 		}
 
-		// TODO: Add part routes
-		// parts := v1.Group("/parts")
-		// {
-		//     parts.GET("", r.partHandler.GetAllParts)
-		//     parts.POST("", r.partHandler.CreatePart)
-		//     parts.GET("/:id", r.partHandler.GetPartByID)
-		//     parts.PUT("/:id", r.partHandler.UpdatePart)
-		//     parts.DELETE("/:id", r.partHandler.DeletePart)
-		//     parts.GET("/search", r.partHandler.SearchParts)
-		// }
+		// Set Minifigs routes
+		setMinifigs := v1.Group("/set-minifigs")
+		{
+			// GET
+			setMinifigs.GET("/:id", r.minifigHandler.GetSetMinifigs)
+		}
+
+		// Color routes
+		colors := v1.Group("/colors")
+		{
+			// GET
+			colors.GET("", r.colorHandler.GetAllColors)
+			colors.GET("/:id", r.colorHandler.GetColorByID)
+			// POST
+			colors.POST("/sync", r.colorHandler.SyncColors)
+		}
+
+		// Theme routes
+		themes := v1.Group("/themes")
+		{
+			// GET
+			themes.GET("", r.themeHandler.GetThemeTree)
+			themes.GET("/:id", r.themeHandler.GetThemeByID)
+			// POST
+			themes.POST("/sync", r.themeHandler.SyncThemes)
+		}
+
+		// Part routes
+		parts := v1.Group("/parts")
+		{
+			// GET
+			parts.GET("", r.partHandler.GetAllParts)
+			parts.GET("/search", r.partHandler.SearchParts)
+			parts.GET("/:id", r.partHandler.GetPartByID)
+		}
+
+		// Loose parts routes
+		looseParts := v1.Group("/loose-parts")
+		{
+			// GET
+			looseParts.GET("", r.loosePartHandler.GetLooseParts)
+			looseParts.GET("/:id", r.loosePartHandler.GetLoosePart)
+			// POST
+			looseParts.POST("", r.loosePartHandler.AddLoosePart)
+			looseParts.POST("/import", r.loosePartHandler.ImportLooseParts)
+			// PUT
+			looseParts.PUT("/:id", r.loosePartHandler.UpdateLoosePart)
+			// DELETE
+			looseParts.DELETE("/:id", r.loosePartHandler.DeleteLoosePart)
+		}
+
+		// Buildability routes
+		buildability := v1.Group("/buildability")
+		{
+			// GET
+			buildability.GET("", r.buildabilityHandler.RankBuildableSets)
+			buildability.GET("/:set_num", r.buildabilityHandler.GetBuildability)
+		}
+
+		// Storage location routes
+		locations := v1.Group("/locations")
+		{
+			// GET
+			locations.GET("", r.storageLocationHandler.GetStorageLocations)
+			locations.GET("/:id", r.storageLocationHandler.GetStorageLocation)
+			// POST
+			locations.POST("", r.storageLocationHandler.CreateStorageLocation)
+			locations.POST("/move", r.storageLocationHandler.MoveItems)
+			// PUT
+			locations.PUT("/:id", r.storageLocationHandler.UpdateStorageLocation)
+			// DELETE
+			locations.DELETE("/:id", r.storageLocationHandler.DeleteStorageLocation)
+		}
+
+		// Rebrickable account sync routes
+		rebrickable := v1.Group("/rebrickable")
+		{
+			// GET
+			rebrickable.GET("/account", r.rebrickableSyncHandler.GetAccount)
+			rebrickable.GET("/conflicts", r.rebrickableSyncHandler.GetConflicts)
+			// POST
+			rebrickable.POST("/sync", r.rebrickableSyncHandler.Sync)
+			rebrickable.POST("/conflicts/:id/resolve", r.rebrickableSyncHandler.ResolveConflict)
+			// PUT
+			rebrickable.PUT("/account", r.rebrickableSyncHandler.LinkAccount)
+			// DELETE
+			rebrickable.DELETE("/account", r.rebrickableSyncHandler.UnlinkAccount)
+		}
+
+		// Background refresh routes
+		refresh := v1.Group("/refresh")
+		{
+			// GET
+			refresh.GET("", r.refreshHandler.GetStatus)
+			refresh.GET("/runs", r.refreshHandler.GetRuns)
+			refresh.GET("/sets", r.refreshHandler.GetSetRefreshes)
+			// POST
+			refresh.POST("/trigger", r.refreshHandler.Trigger)
+			refresh.POST("/pause", r.refreshHandler.Pause)
+			refresh.POST("/resume", r.refreshHandler.Resume)
+		}
+
+		// Import job routes
+		jobs := v1.Group("/jobs")
+		{
+			// GET
+			jobs.GET("/:id", r.importJobHandler.GetJob)
+		}
+
+		// Search routes
+		v1.GET("/search", r.searchHandler.Search)
 
 		// TODO: Add missing part routes
 		// missingParts := v1.Group("/missing-parts")
@@ -103,8 +257,6 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		//     missingParts.GET("/:id", r.missingPartHandler.GetMissingPartByID)
 		//     missingParts.PUT("/:id", r.missingPartHandler.UpdateMissingPart)
 		//     missingParts.DELETE("/:id", r.missingPartHandler.DeleteMissingPart)
-		//     missingParts.PUT("/:id/found", r.missingPartHandler.MarkAsFound)
-		//     missingParts.PUT("/:id/missing", r.missingPartHandler.MarkAsMissing)
 		//     missingParts.GET("/set/:setId", r.missingPartHandler.GetMissingPartsBySetID)
 		// }
 	}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// BuildabilityService compares the parts we own with the inventory of any set
+type BuildabilityService interface {
+	GetBuildability(setNum string, includeSets bool) (*Buildability, error)
+	RankBuildableSets(includeSets bool, minCompleteness float64, limit int) ([]repository.BuildableSet, error)
+}
+
+// Buildability is the share of the pieces of a set that we own, with the parts still missing.
+// Spares and minifig parts are left out.
+type Buildability struct {
+	SetNum         string             `json:"set_num"`
+	Name           string             `json:"name"`
+	Year           int                `json:"year"`
+	SetImageURL    string             `json:"set_img_url"`
+	Owned          bool               `json:"owned"`
+	PiecesRequired int                `json:"pieces_required"`
+	PiecesOwned    int                `json:"pieces_owned"`
+	Completeness   float64            `json:"completeness"`
+	Lots           int                `json:"lots"`
+	MissingLots    int                `json:"missing_lots"`
+	Missing        []BuildabilityPart `json:"missing"`
+}
+
+// BuildabilityPart is a part and color of a set inventory that we do not own enough of
+type BuildabilityPart struct {
+	PartNum      string `json:"part_num"`
+	PartName     string `json:"part_name"`
+	PartImageURL string `json:"part_img_url"`
+	ColorID      int    `json:"color_id"`
+	ColorName    string `json:"color_name"`
+	Required     int    `json:"required"`
+	Owned        int    `json:"owned"`
+	Missing      int    `json:"missing"`
+}
+
+// buildabilityService implements BuildabilityService interface
+type buildabilityService struct {
+	buildabilityRepo   repository.BuildabilityRepository
+	setRepo            repository.SetRepository
+	rebrickableService RebrickableService
+}
+
+// NewBuildabilityService creates a new buildability service
+func NewBuildabilityService(buildabilityRepo repository.BuildabilityRepository, setRepo repository.SetRepository, rebrickableService RebrickableService) BuildabilityService {
+	return &buildabilityService{
+		buildabilityRepo:   buildabilityRepo,
+		setRepo:            setRepo,
+		rebrickableService: rebrickableService,
+	}
+}
+
+// GetBuildability compares the inventory of a set, fetched from Rebrickable or the local catalog,
+// with our loose parts and, when includeSets is set, the parts of our sets that are not missing
+func (s *buildabilityService) GetBuildability(setNum string, includeSets bool) (*Buildability, error) {
+	setNum = normalizeSetNum(setNum)
+	rbSet, err := s.rebrickableService.GetSet(setNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch set %s: %w", setNum, err)
+	}
+
+	rbParts, err := s.rebrickableService.GetSetParts(setNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch parts of set %s: %w", setNum, err)
+	}
+
+	ownedParts, err := s.buildabilityRepo.GetOwnedParts(includeSets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owned parts: %w", err)
+	}
+	owned := make(map[string]int, len(ownedParts))
+	for _, ownedPart := range ownedParts {
+		owned[importKey(ownedPart.PartNum, strconv.Itoa(ownedPart.ColorID))] = ownedPart.Quantity
+	}
+
+	_, err = s.setRepo.GetBySetNum(rbSet.SetNum)
+	isOwned := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get set %s: %w", rbSet.SetNum, err)
+	}
+
+	buildability := &Buildability{
+		SetNum:      rbSet.SetNum,
+		Name:        rbSet.Name,
+		Year:        rbSet.Year,
+		SetImageURL: rbSet.SetImageURL,
+		Owned:       isOwned,
+		Missing:     []BuildabilityPart{},
+	}
+
+	// Lines of the same part and color are merged before comparing quantities
+	var lots []*BuildabilityPart
+	lotsByKey := make(map[string]*BuildabilityPart)
+	for _, rbPart := range rbParts {
+		if rbPart.IsSpare {
+			continue
+		}
+
+		key := importKey(rbPart.Part.PartNum, strconv.Itoa(rbPart.Color.ID))
+		lot, ok := lotsByKey[key]
+		if !ok {
+			lot = &BuildabilityPart{
+				PartNum:      rbPart.Part.PartNum,
+				PartName:     rbPart.Part.Name,
+				PartImageURL: rbPart.Part.PartImageURL,
+				ColorID:      rbPart.Color.ID,
+				ColorName:    rbPart.Color.Name,
+			}
+			lotsByKey[key] = lot
+			lots = append(lots, lot)
+		}
+		lot.Required += rbPart.Quantity
+	}
+
+	for _, lot := range lots {
+		key := importKey(lot.PartNum, strconv.Itoa(lot.ColorID))
+		lot.Owned = min(lot.Required, owned[key])
+		lot.Missing = lot.Required - lot.Owned
+
+		buildability.Lots++
+		buildability.PiecesRequired += lot.Required
+		buildability.PiecesOwned += lot.Owned
+		if lot.Missing > 0 {
+			buildability.MissingLots++
+			buildability.Missing = append(buildability.Missing, *lot)
+		}
+	}
+
+	if buildability.PiecesRequired > 0 {
+		completeness := 100 * float64(buildability.PiecesOwned) / float64(buildability.PiecesRequired)
+		buildability.Completeness = math.Round(completeness*100) / 100
+	}
+
+	return buildability, nil
+}
+
+// RankBuildableSets ranks the sets of the local catalog by the share of their pieces that we own
+func (s *buildabilityService) RankBuildableSets(includeSets bool, minCompleteness float64, limit int) ([]repository.BuildableSet, error) {
+	sets, err := s.buildabilityRepo.RankCatalogSets(includeSets, minCompleteness, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank catalog sets: %w", err)
+	}
+	if sets == nil {
+		sets = []repository.BuildableSet{}
+	}
+	return sets, nil
+}
+
+// normalizeSetNum adds the default -1 variant to set numbers given without one
+func normalizeSetNum(setNum string) string {
+	setNum = strings.TrimSpace(setNum)
+	if setNum != "" && !strings.Contains(setNum, "-") {
+		return setNum + "-1"
+	}
+	return setNum
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// catalogImportBatchSize is the number of CSV rows buffered before they are written to the database
+const catalogImportBatchSize = 5000
+
+// CatalogImportService imports the Rebrickable CSV downloads into the local catalog mirror
+type CatalogImportService interface {
+	ImportDirectory(dir string) (*CatalogImportReport, error)
+}
+
+// CatalogImportReport summarizes a catalog import
+type CatalogImportReport struct {
+	Imported map[string]int `json:"imported"`
+	Skipped  []string       `json:"skipped"`
+}
+
+// catalogImportService implements CatalogImportService interface
+type catalogImportService struct {
+	catalogRepo repository.CatalogRepository
+}
+
+// NewCatalogImportService creates a new catalog import service
+func NewCatalogImportService(catalogRepo repository.CatalogRepository) CatalogImportService {
+	return &catalogImportService{catalogRepo: catalogRepo}
+}
+
+// csvRow gives access to the fields of a CSV record by column name
+type csvRow func(column string) string
+
+// catalogFile describes how one Rebrickable download is mapped to a catalog table
+type catalogFile struct {
+	name  string
+	model interface{}
+	load  func(r io.Reader, insert func(rows interface{}) error) (int, error)
+}
+
+// newCatalogFile builds a catalogFile whose rows are parsed into T
+func newCatalogFile[T any](name string, parse func(row csvRow) (T, error)) catalogFile {
+	return catalogFile{
+		name:  name,
+		model: new(T),
+		load: func(r io.Reader, insert func(rows interface{}) error) (int, error) {
+			return readCSV(r, parse, func(rows []T) error {
+				return insert(rows)
+			})
+		},
+	}
+}
+
+// catalogFiles lists the Rebrickable downloads in dependency order
+var catalogFiles = []catalogFile{
+	newCatalogFile("themes", parseCatalogTheme),
+	newCatalogFile("colors", parseCatalogColor),
+	newCatalogFile("part_categories", parseCatalogPartCategory),
+	newCatalogFile("parts", parseCatalogPart),
+	newCatalogFile("sets", parseCatalogSet),
+	newCatalogFile("minifigs", parseCatalogMinifig),
+	newCatalogFile("inventories", parseCatalogInventory),
+	newCatalogFile("inventory_parts", parseCatalogInventoryPart),
+	newCatalogFile("inventory_minifigs", parseCatalogInventoryMinifig),
+	newCatalogFile("elements", parseCatalogElement),
+	newCatalogFile("part_relationships", parseCatalogPartRelationship),
+}
+
+// ImportDirectory imports every known <name>.csv.gz (or <name>.csv) file found in dir.
+// Each table is replaced atomically; files that are absent are reported as skipped.
+func (s *catalogImportService) ImportDirectory(dir string) (*CatalogImportReport, error) {
+	report := &CatalogImportReport{Imported: make(map[string]int)}
+
+	for _, file := range catalogFiles {
+		path, err := findCatalogFile(dir, file.name)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			report.Skipped = append(report.Skipped, file.name)
+			continue
+		}
+
+		count, err := s.importFile(path, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", filepath.Base(path), err)
+		}
+		report.Imported[file.name] = count
+	}
+
+	return report, nil
+}
+
+// importFile streams a CSV file into its catalog table
+func (s *catalogImportService) importFile(path string, file catalogFile) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	count := 0
+	err = s.catalogRepo.Replace(file.model, func(insert func(rows interface{}) error) error {
+		var err error
+		count, err = file.load(reader, insert)
+		return err
+	})
+	return count, err
+}
+
+// findCatalogFile returns the path of the compressed or plain CSV download, or "" if neither exists
+func findCatalogFile(dir, name string) (string, error) {
+	for _, candidate := range []string{name + ".csv.gz", name + ".csv"} {
+		path := filepath.Join(dir, candidate)
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// readCSV parses every record with parse, hands them to flush in batches and returns the record count
+func readCSV[T any](r io.Reader, parse func(row csvRow) (T, error), flush func(rows []T) error) (int, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))] = i
+	}
+
+	batch := make([]T, 0, catalogImportBatchSize)
+	count := 0
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		parsed, err := parse(row)
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", line, err)
+		}
+		batch = append(batch, parsed)
+
+		if len(batch) == catalogImportBatchSize {
+			if err := flush(batch); err != nil {
+				return count, err
+			}
+			count += len(batch)
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := flush(batch); err != nil {
+			return count, err
+		}
+		count += len(batch)
+	}
+	return count, nil
+}
+
+// parseCatalogTheme maps a CSV record to a CatalogTheme
+func parseCatalogTheme(row csvRow) (entity.CatalogTheme, error) {
+	id, err := parseCSVInt(row, "id")
+	if err != nil {
+		return entity.CatalogTheme{}, err
+	}
+
+	theme := entity.CatalogTheme{ID: id, Name: row("name")}
+	if row("parent_id") != "" {
+		parentID, err := parseCSVInt(row, "parent_id")
+		if err != nil {
+			return entity.CatalogTheme{}, err
+		}
+		theme.ParentID = &parentID
+	}
+	return theme, nil
+}
+
+// parseCatalogColor maps a CSV record to a CatalogColor
+func parseCatalogColor(row csvRow) (entity.CatalogColor, error) {
+	id, err := parseCSVInt(row, "id")
+	if err != nil {
+		return entity.CatalogColor{}, err
+	}
+	return entity.CatalogColor{
+		ID:      id,
+		Name:    row("name"),
+		RGB:     row("rgb"),
+		IsTrans: parseCSVBool(row("is_trans")),
+	}, nil
+}
+
+// parseCatalogPartCategory maps a CSV record to a CatalogPartCategory
+func parseCatalogPartCategory(row csvRow) (entity.CatalogPartCategory, error) {
+	id, err := parseCSVInt(row, "id")
+	if err != nil {
+		return entity.CatalogPartCategory{}, err
+	}
+	return entity.CatalogPartCategory{ID: id, Name: row("name")}, nil
+}
+
+// parseCatalogPart maps a CSV record to a CatalogPart
+func parseCatalogPart(row csvRow) (entity.CatalogPart, error) {
+	partCatID, err := parseCSVInt(row, "part_cat_id")
+	if err != nil {
+		return entity.CatalogPart{}, err
+	}
+	return entity.CatalogPart{
+		PartNum:      row("part_num"),
+		Name:         row("name"),
+		PartCatID:    partCatID,
+		PartMaterial: row("part_material"),
+	}, nil
+}
+
+// parseCatalogSet maps a CSV record to a CatalogSet
+func parseCatalogSet(row csvRow) (entity.CatalogSet, error) {
+	year, err := parseCSVInt(row, "year")
+	if err != nil {
+		return entity.CatalogSet{}, err
+	}
+	themeID, err := parseCSVInt(row, "theme_id")
+	if err != nil {
+		return entity.CatalogSet{}, err
+	}
+	numParts, err := parseCSVInt(row, "num_parts")
+	if err != nil {
+		return entity.CatalogSet{}, err
+	}
+	return entity.CatalogSet{
+		SetNum:   row("set_num"),
+		Name:     row("name"),
+		Year:     year,
+		ThemeID:  themeID,
+		NumParts: numParts,
+		ImageURL: row("img_url"),
+	}, nil
+}
+
+// parseCatalogMinifig maps a CSV record to a CatalogMinifig
+func parseCatalogMinifig(row csvRow) (entity.CatalogMinifig, error) {
+	numParts, err := parseCSVInt(row, "num_parts")
+	if err != nil {
+		return entity.CatalogMinifig{}, err
+	}
+	return entity.CatalogMinifig{
+		FigNum:   row("fig_num"),
+		Name:     row("name"),
+		NumParts: numParts,
+		ImageURL: row("img_url"),
+	}, nil
+}
+
+// parseCatalogInventory maps a CSV record to a CatalogInventory
+func parseCatalogInventory(row csvRow) (entity.CatalogInventory, error) {
+	id, err := parseCSVInt(row, "id")
+	if err != nil {
+		return entity.CatalogInventory{}, err
+	}
+	version, err := parseCSVInt(row, "version")
+	if err != nil {
+		return entity.CatalogInventory{}, err
+	}
+	return entity.CatalogInventory{ID: id, Version: version, SetNum: row("set_num")}, nil
+}
+
+// parseCatalogInventoryPart maps a CSV record to a CatalogInventoryPart
+func parseCatalogInventoryPart(row csvRow) (entity.CatalogInventoryPart, error) {
+	inventoryID, err := parseCSVInt(row, "inventory_id")
+	if err != nil {
+		return entity.CatalogInventoryPart{}, err
+	}
+	colorID, err := parseCSVInt(row, "color_id")
+	if err != nil {
+		return entity.CatalogInventoryPart{}, err
+	}
+	quantity, err := parseCSVInt(row, "quantity")
+	if err != nil {
+		return entity.CatalogInventoryPart{}, err
+	}
+	return entity.CatalogInventoryPart{
+		InventoryID: inventoryID,
+		PartNum:     row("part_num"),
+		ColorID:     colorID,
+		Quantity:    quantity,
+		IsSpare:     parseCSVBool(row("is_spare")),
+		ImageURL:    row("img_url"),
+	}, nil
+}
+
+// parseCatalogInventoryMinifig maps a CSV record to a CatalogInventoryMinifig
+func parseCatalogInventoryMinifig(row csvRow) (entity.CatalogInventoryMinifig, error) {
+	inventoryID, err := parseCSVInt(row, "inventory_id")
+	if err != nil {
+		return entity.CatalogInventoryMinifig{}, err
+	}
+	quantity, err := parseCSVInt(row, "quantity")
+	if err != nil {
+		return entity.CatalogInventoryMinifig{}, err
+	}
+	return entity.CatalogInventoryMinifig{
+		InventoryID: inventoryID,
+		FigNum:      row("fig_num"),
+		Quantity:    quantity,
+	}, nil
+}
+
+// parseCatalogElement maps a CSV record to a CatalogElement
+func parseCatalogElement(row csvRow) (entity.CatalogElement, error) {
+	colorID, err := parseCSVInt(row, "color_id")
+	if err != nil {
+		return entity.CatalogElement{}, err
+	}
+	return entity.CatalogElement{
+		ElementID: row("element_id"),
+		PartNum:   row("part_num"),
+		ColorID:   colorID,
+		DesignID:  row("design_id"),
+	}, nil
+}
+
+// parseCatalogPartRelationship maps a CSV record to a CatalogPartRelationship
+func parseCatalogPartRelationship(row csvRow) (entity.CatalogPartRelationship, error) {
+	return entity.CatalogPartRelationship{
+		RelType:       row("rel_type"),
+		ChildPartNum:  row("child_part_num"),
+		ParentPartNum: row("parent_part_num"),
+	}, nil
+}
+
+// parseCSVInt parses an integer column, treating an empty value as zero
+func parseCSVInt(row csvRow, column string) (int, error) {
+	value := row(column)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", column, value, err)
+	}
+	return n, nil
+}
+
+// parseCSVBool parses the boolean flags used by the Rebrickable downloads ("t"/"f", "True"/"False")
+func parseCSVBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "t", "true", "1":
+		return true
+	default:
+		return false
+	}
+}
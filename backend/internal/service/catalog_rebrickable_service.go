@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// catalogRebrickableService implements RebrickableService on top of the local catalog mirror,
+// so that sets can be added without any network access
+type catalogRebrickableService struct {
+	catalogRepo repository.CatalogRepository
+}
+
+// NewCatalogRebrickableService creates a RebrickableService backed by the local catalog mirror
+func NewCatalogRebrickableService(catalogRepo repository.CatalogRepository) RebrickableService {
+	return &catalogRebrickableService{catalogRepo: catalogRepo}
+}
+
+// GetSet retrieves a set from the local catalog
+func (s *catalogRebrickableService) GetSet(setNum string) (*RebrickableSet, error) {
+	set, err := s.catalogRepo.GetSet(setNum)
+	if err != nil {
+		return nil, catalogLookupError("set", setNum, err)
+	}
+
+	return &RebrickableSet{
+		SetNum:      set.SetNum,
+		Name:        set.Name,
+		Year:        set.Year,
+		ThemeID:     set.ThemeID,
+		NumParts:    set.NumParts,
+		SetImageURL: set.ImageURL,
+		SetURL:      fmt.Sprintf("https://rebrickable.com/sets/%s/", set.SetNum),
+	}, nil
+}
+
+// GetSetParts retrieves the parts of the latest inventory of a set, without minifig parts
+func (s *catalogRebrickableService) GetSetParts(setNum string) ([]RebrickableSetPart, error) {
+	return s.getInventoryParts("set", setNum)
+}
+
+// GetSetMinifigs retrieves the minifigs of the latest inventory of a set
+func (s *catalogRebrickableService) GetSetMinifigs(setNum string) ([]RebrickableSetMinifig, error) {
+	inventory, err := s.catalogRepo.GetLatestInventory(setNum)
+	if err != nil {
+		return nil, catalogLookupError("inventory of set", setNum, err)
+	}
+
+	inventoryMinifigs, err := s.catalogRepo.GetInventoryMinifigs(inventory.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load minifigs of set %s: %w", setNum, err)
+	}
+
+	minifigs := make([]RebrickableSetMinifig, 0, len(inventoryMinifigs))
+	for _, inventoryMinifig := range inventoryMinifigs {
+		minifigs = append(minifigs, RebrickableSetMinifig{
+			ID:       int(inventoryMinifig.ID),
+			FigNum:   inventoryMinifig.FigNum,
+			Name:     inventoryMinifig.Minifig.Name,
+			Quantity: inventoryMinifig.Quantity,
+			ImageURL: inventoryMinifig.Minifig.ImageURL,
+		})
+	}
+	return minifigs, nil
+}
+
+// GetMinifigParts retrieves the parts of the latest inventory of a minifig
+func (s *catalogRebrickableService) GetMinifigParts(figNum string) ([]RebrickableSetPart, error) {
+	return s.getInventoryParts("minifig", figNum)
+}
+
+// GetPart retrieves a part from the local catalog
+func (s *catalogRebrickableService) GetPart(partNum string) (*RebrickablePart, error) {
+	part, err := s.catalogRepo.GetPart(partNum)
+	if err != nil {
+		return nil, catalogLookupError("part", partNum, err)
+	}
+
+	designIDs, err := s.catalogRepo.GetDesignIDs([]string{partNum})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load design IDs of part %s: %w", partNum, err)
+	}
+	printOf, err := s.printParents([]string{partNum})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load print relationships of part %s: %w", partNum, err)
+	}
+
+	rbPart := catalogPartToRebrickable(*part, "", designIDs[partNum], printOf[partNum])
+	return &rbPart, nil
+}
+
+// GetColors retrieves every color from the local catalog
+func (s *catalogRebrickableService) GetColors() ([]RebrickableColor, error) {
+	catalogColors, err := s.catalogRepo.GetColors()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load colors from local catalog: %w", err)
+	}
+
+	colors := make([]RebrickableColor, 0, len(catalogColors))
+	for _, color := range catalogColors {
+		colors = append(colors, catalogColorToRebrickable(color))
+	}
+	return colors, nil
+}
+
+// GetThemes retrieves every theme from the local catalog
+func (s *catalogRebrickableService) GetThemes() ([]RebrickableTheme, error) {
+	catalogThemes, err := s.catalogRepo.GetThemes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load themes from local catalog: %w", err)
+	}
+
+	themes := make([]RebrickableTheme, 0, len(catalogThemes))
+	for _, theme := range catalogThemes {
+		themes = append(themes, RebrickableTheme{ID: theme.ID, ParentID: theme.ParentID, Name: theme.Name})
+	}
+	return themes, nil
+}
+
+// GetTheme retrieves a theme from the local catalog
+func (s *catalogRebrickableService) GetTheme(id int) (*RebrickableTheme, error) {
+	theme, err := s.catalogRepo.GetTheme(id)
+	if err != nil {
+		return nil, catalogLookupError("theme", strconv.Itoa(id), err)
+	}
+
+	return &RebrickableTheme{ID: theme.ID, ParentID: theme.ParentID, Name: theme.Name}, nil
+}
+
+// getInventoryParts retrieves the parts of the latest inventory of a set or minifig
+func (s *catalogRebrickableService) getInventoryParts(kind, setNum string) ([]RebrickableSetPart, error) {
+	inventory, err := s.catalogRepo.GetLatestInventory(setNum)
+	if err != nil {
+		return nil, catalogLookupError("inventory of "+kind, setNum, err)
+	}
+
+	inventoryParts, err := s.catalogRepo.GetInventoryParts(inventory.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inventory parts of %s %s: %w", kind, setNum, err)
+	}
+
+	return s.toRebrickableSetParts(inventoryParts)
+}
+
+// toRebrickableSetParts converts inventory lines to the shape returned by the Rebrickable API
+func (s *catalogRebrickableService) toRebrickableSetParts(inventoryParts []entity.CatalogInventoryPart) ([]RebrickableSetPart, error) {
+	partNums := make([]string, 0, len(inventoryParts))
+	for _, inventoryPart := range inventoryParts {
+		partNums = append(partNums, inventoryPart.PartNum)
+	}
+
+	designIDs, err := s.catalogRepo.GetDesignIDs(partNums)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load design IDs: %w", err)
+	}
+	printOf, err := s.printParents(partNums)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load print relationships: %w", err)
+	}
+
+	setParts := make([]RebrickableSetPart, 0, len(inventoryParts))
+	for _, inventoryPart := range inventoryParts {
+		setParts = append(setParts, RebrickableSetPart{
+			ID:       int(inventoryPart.ID),
+			Part:     catalogPartToRebrickable(inventoryPart.Part, inventoryPart.ImageURL, designIDs[inventoryPart.PartNum], printOf[inventoryPart.PartNum]),
+			Color:    catalogColorToRebrickable(inventoryPart.Color),
+			Quantity: inventoryPart.Quantity,
+			IsSpare:  inventoryPart.IsSpare,
+		})
+	}
+
+	return setParts, nil
+}
+
+// printParents maps the given parts that are prints to the part they are a print of
+func (s *catalogRebrickableService) printParents(partNums []string) (map[string]string, error) {
+	relationships, err := s.catalogRepo.GetPartRelationships(partNums, []string{entity.CatalogRelPrint})
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(partNums))
+	for _, partNum := range partNums {
+		wanted[partNum] = true
+	}
+
+	printOf := make(map[string]string)
+	for _, relationship := range relationships {
+		if wanted[relationship.ChildPartNum] {
+			printOf[relationship.ChildPartNum] = relationship.ParentPartNum
+		}
+	}
+	return printOf, nil
+}
+
+// catalogPartToRebrickable converts a catalog part to the shape returned by the Rebrickable API
+func catalogPartToRebrickable(part entity.CatalogPart, imageURL string, designIDs []string, printOf string) RebrickablePart {
+	externalIDs := map[string]interface{}{}
+	if len(designIDs) > 0 {
+		externalIDs["LEGO"] = designIDs
+	}
+
+	return RebrickablePart{
+		PartNum:      part.PartNum,
+		Name:         part.Name,
+		PartCatID:    part.PartCatID,
+		PartImageURL: imageURL,
+		PartURL:      fmt.Sprintf("https://rebrickable.com/parts/%s/", part.PartNum),
+		PrintOf:      printOf,
+		ExternalIDs:  externalIDs,
+	}
+}
+
+// catalogColorToRebrickable converts a catalog color to the shape returned by the Rebrickable API
+func catalogColorToRebrickable(color entity.CatalogColor) RebrickableColor {
+	return RebrickableColor{
+		ID:      color.ID,
+		Name:    color.Name,
+		RGB:     color.RGB,
+		IsTrans: color.IsTrans,
+	}
+}
+
+// catalogLookupError reports a failed catalog lookup, mapping missing rows to ErrRebrickableNotFound
+func catalogLookupError(kind, key string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%s %s not found in local catalog: %w", kind, key, ErrRebrickableNotFound)
+	}
+	return fmt.Errorf("failed to load %s %s from local catalog: %w", kind, key, err)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// ColorService handles business logic for colors
+type ColorService interface {
+	SyncColorsFromRebrickable() (int, error)
+	GetAllColors(isTrans *bool) ([]entity.Color, error)
+	GetColorByID(id int) (*entity.Color, error)
+}
+
+// colorService implements ColorService interface
+type colorService struct {
+	colorRepo          repository.ColorRepository
+	rebrickableService RebrickableService
+}
+
+// NewColorService creates a new color service
+func NewColorService(colorRepo repository.ColorRepository, rebrickableService RebrickableService) ColorService {
+	return &colorService{
+		colorRepo:          colorRepo,
+		rebrickableService: rebrickableService,
+	}
+}
+
+// SyncColorsFromRebrickable refreshes the colors table from Rebrickable and returns the number of colors synced
+func (s *colorService) SyncColorsFromRebrickable() (int, error) {
+	rbColors, err := s.rebrickableService.GetColors()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch colors from Rebrickable: %w", err)
+	}
+
+	colors := make([]entity.Color, 0, len(rbColors))
+	for _, rbColor := range rbColors {
+		colors = append(colors, colorFromRebrickable(rbColor))
+	}
+
+	if err := s.colorRepo.UpsertBatch(colors); err != nil {
+		return 0, fmt.Errorf("failed to save colors: %w", err)
+	}
+
+	return len(colors), nil
+}
+
+// GetAllColors retrieves all colors, optionally filtered by transparency
+func (s *colorService) GetAllColors(isTrans *bool) ([]entity.Color, error) {
+	return s.colorRepo.GetAll(isTrans)
+}
+
+// GetColorByID retrieves a color by its Rebrickable ID
+func (s *colorService) GetColorByID(id int) (*entity.Color, error) {
+	return s.colorRepo.GetByID(id)
+}
+
+// colorFromRebrickable converts a Rebrickable color to a Color entity
+func colorFromRebrickable(rbColor RebrickableColor) entity.Color {
+	color := entity.Color{
+		ID:          rbColor.ID,
+		Name:        rbColor.Name,
+		RGB:         rbColor.RGB,
+		IsTrans:     rbColor.IsTrans,
+		BrickLinkID: firstExternalColorID(rbColor.ExternalIDs, "BrickLink"),
+		LDrawID:     firstExternalColorID(rbColor.ExternalIDs, "LDraw"),
+		LEGOID:      firstExternalColorID(rbColor.ExternalIDs, "LEGO"),
+	}
+
+	if len(rbColor.ExternalIDs) > 0 {
+		externalIDsJSON, _ := json.Marshal(rbColor.ExternalIDs)
+		color.ExternalIDs = string(externalIDsJSON)
+	}
+
+	return color
+}
+
+// firstExternalColorID returns the primary ID of a color in the given external system, if any
+func firstExternalColorID(externalIDs map[string]RebrickableColorExternalID, system string) *int {
+	ids := externalIDs[system].ExtIDs
+	if len(ids) == 0 {
+		return nil
+	}
+	id := ids[0]
+	return &id
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// Sources a missing part can be covered from
+const (
+	FulfillmentSourceLoose = "loose"
+	FulfillmentSourceDonor = "donor"
+)
+
+// FulfillmentService plans and applies the coverage of missing parts from the loose parts
+// inventory and from donor sets
+type FulfillmentService interface {
+	PlanFulfillment(options FulfillmentOptions) (*FulfillmentPlan, error)
+	ApplyFulfillment(options FulfillmentOptions) (*FulfillmentPlan, error)
+}
+
+// FulfillmentOptions selects the missing parts to cover and the stock to cover them from.
+// Empty SetIDs and MissingPartIDs cover every missing part, and empty DonorSetIDs uses the
+// sets flagged as donors. Alternates allows prints and mold variants of a part in the same color.
+type FulfillmentOptions struct {
+	SetIDs         []uint `json:"set_ids"`
+	MissingPartIDs []uint `json:"missing_part_ids"`
+	DonorSetIDs    []uint `json:"donor_set_ids"`
+	Alternates     bool   `json:"alternates"`
+}
+
+// FulfillmentPlan lists, for each missing part, the pieces that can be taken from stock
+type FulfillmentPlan struct {
+	Items         []FulfillmentItem `json:"items"`
+	PiecesMissing int               `json:"pieces_missing"`
+	PiecesCovered int               `json:"pieces_covered"`
+	FullyCovered  int               `json:"fully_covered"`
+}
+
+// FulfillmentItem is a missing part together with the sources covering it
+type FulfillmentItem struct {
+	MissingPartID uint                `json:"missing_part_id"`
+	SetID         uint                `json:"set_id"`
+	SetNum        string              `json:"set_num"`
+	SetCopyID     uint                `json:"set_copy_id"`
+	Part          entity.Part         `json:"part"`
+	Color         entity.Color        `json:"color"`
+	Missing       int                 `json:"missing"`
+	Covered       int                 `json:"covered"`
+	Sources       []FulfillmentSource `json:"sources"`
+}
+
+// FulfillmentSource is a quantity taken from a loose part entry or from a copy of a donor set.
+// Location is the path of the storage location the pieces are taken from, when it is known.
+type FulfillmentSource struct {
+	Type              string `json:"type"`
+	LoosePartID       uint   `json:"loose_part_id,omitempty"`
+	StorageLocationID *uint  `json:"storage_location_id,omitempty"`
+	Location          string `json:"location,omitempty"`
+	DonorSetID        uint   `json:"donor_set_id,omitempty"`
+	DonorSetNum       string `json:"donor_set_num,omitempty"`
+	DonorSetCopyID    uint   `json:"donor_set_copy_id,omitempty"`
+	DonorSetPartID    uint   `json:"donor_set_part_id,omitempty"`
+	PartNum           string `json:"part_num"`
+	Alternate         bool   `json:"alternate"`
+	Quantity          int    `json:"quantity"`
+}
+
+// fulfillmentStock is a quantity of a part and color that can still be allocated
+type fulfillmentStock struct {
+	source    FulfillmentSource
+	part      entity.Part
+	colorID   int
+	available int
+}
+
+// fulfillmentService implements FulfillmentService interface
+type fulfillmentService struct {
+	missingPartsRepo repository.MissingPartsRepository
+	loosePartRepo    repository.LoosePartRepository
+	setRepo          repository.SetRepository
+	setPartRepo      repository.SetPartRepository
+	setCopyRepo      repository.SetCopyRepository
+	catalogRepo      repository.CatalogRepository
+	transactor       repository.Transactor
+}
+
+// NewFulfillmentService creates a new fulfillment service
+func NewFulfillmentService(missingPartsRepo repository.MissingPartsRepository, loosePartRepo repository.LoosePartRepository, setRepo repository.SetRepository, setPartRepo repository.SetPartRepository, setCopyRepo repository.SetCopyRepository, catalogRepo repository.CatalogRepository, transactor repository.Transactor) FulfillmentService {
+	return &fulfillmentService{
+		missingPartsRepo: missingPartsRepo,
+		loosePartRepo:    loosePartRepo,
+		setRepo:          setRepo,
+		setPartRepo:      setPartRepo,
+		setCopyRepo:      setCopyRepo,
+		catalogRepo:      catalogRepo,
+		transactor:       transactor,
+	}
+}
+
+// PlanFulfillment suggests how missing parts can be covered. Loose parts are used before donor sets,
+// and exact part matches are allocated to every missing part before any alternate is considered.
+// Donor sets give the parts of their inventory that are not missing from their own copies; their
+// minifig parts and their own missing parts are left out.
+func (s *fulfillmentService) PlanFulfillment(options FulfillmentOptions) (*FulfillmentPlan, error) {
+	donors, err := s.getDonors(options.DonorSetIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	donorIDs := make(map[uint]bool, len(donors))
+	for _, donor := range donors {
+		donorIDs[donor.ID] = true
+	}
+
+	missingParts, err := s.missingPartsRepo.GetAllMissing(repository.MissingPartFilter{SetIDs: options.SetIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get missing parts: %w", err)
+	}
+
+	requested := make(map[uint]bool, len(options.MissingPartIDs))
+	for _, id := range options.MissingPartIDs {
+		requested[id] = true
+	}
+
+	plan := &FulfillmentPlan{Items: []FulfillmentItem{}}
+	for _, missingPart := range missingParts {
+		if donorIDs[missingPart.SetID] || (len(requested) > 0 && !requested[missingPart.ID]) {
+			continue
+		}
+		plan.Items = append(plan.Items, FulfillmentItem{
+			MissingPartID: missingPart.ID,
+			SetID:         missingPart.SetID,
+			SetNum:        missingPart.Set.SetNum,
+			SetCopyID:     missingPart.SetCopyID,
+			Part:          missingPart.Part,
+			Color:         missingPart.Color,
+			Missing:       missingPart.Quantity,
+			Sources:       []FulfillmentSource{},
+		})
+		plan.PiecesMissing += missingPart.Quantity
+	}
+
+	stock, err := s.loadStock(donors)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exact matches first, for every missing part
+	exact := make(map[string][]*fulfillmentStock)
+	for _, entry := range stock {
+		key := fmt.Sprintf("%d|%d", entry.part.ID, entry.colorID)
+		exact[key] = append(exact[key], entry)
+	}
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		allocate(item, exact[fmt.Sprintf("%d|%d", item.Part.ID, item.Color.ID)], false)
+	}
+
+	if options.Alternates {
+		families, err := s.partFamilies(plan.Items, stock)
+		if err != nil {
+			return nil, err
+		}
+
+		alternates := make(map[string][]*fulfillmentStock)
+		for _, entry := range stock {
+			key := fmt.Sprintf("%s|%d", families.find(entry.part.PartNum), entry.colorID)
+			alternates[key] = append(alternates[key], entry)
+		}
+		for i := range plan.Items {
+			item := &plan.Items[i]
+			candidates := alternates[fmt.Sprintf("%s|%d", families.find(item.Part.PartNum), item.Color.ID)]
+			allocate(item, candidates, true)
+		}
+	}
+
+	for _, item := range plan.Items {
+		plan.PiecesCovered += item.Covered
+		if item.Covered == item.Missing {
+			plan.FullyCovered++
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyFulfillment computes the plan for options and applies it in a single transaction: loose part
+// quantities are decreased, pieces taken from a donor set are recorded as missing from the donor copy,
+// and the covered pieces are recorded as found on the missing parts, closing the fully covered ones.
+func (s *fulfillmentService) ApplyFulfillment(options FulfillmentOptions) (*FulfillmentPlan, error) {
+	plan, err := s.PlanFulfillment(options)
+	if err != nil {
+		return nil, err
+	}
+
+	setParts := make(map[uint]*entity.SetPart)
+	for _, item := range plan.Items {
+		for _, source := range item.Sources {
+			if source.Type != FulfillmentSourceDonor || setParts[source.DonorSetPartID] != nil {
+				continue
+			}
+			setPart, err := s.setPartRepo.GetByID(source.DonorSetPartID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get set part %d: %w", source.DonorSetPartID, err)
+			}
+			setParts[setPart.ID] = setPart
+		}
+	}
+
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		for _, item := range plan.Items {
+			if item.Covered == 0 {
+				continue
+			}
+
+			missingPart, err := repos.MissingParts.GetByID(item.MissingPartID)
+			if err != nil {
+				return fmt.Errorf("failed to get missing part %d: %w", item.MissingPartID, err)
+			}
+
+			for _, source := range item.Sources {
+				notes, err := takeFromSource(repos, source, setParts[source.DonorSetPartID], item)
+				if err != nil {
+					return err
+				}
+
+				missingPart.Quantity -= source.Quantity
+				recovery := &entity.MissingPartRecovery{
+					MissingPartID: missingPart.ID,
+					Action:        entity.RecoveryActionFound,
+					Quantity:      source.Quantity,
+					Notes:         notes,
+				}
+				if err := repos.MissingParts.CreateRecovery(recovery); err != nil {
+					return fmt.Errorf("failed to record recovery: %w", err)
+				}
+			}
+
+			if missingPart.Quantity == 0 {
+				missingPart.IsMissing = false
+			}
+			if err := repos.MissingParts.Update(missingPart); err != nil {
+				return fmt.Errorf("failed to update missing part %d: %w", missingPart.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// getDonors retrieves the given donor sets, or the sets flagged as donors when ids is empty
+func (s *fulfillmentService) getDonors(ids []uint) ([]entity.Set, error) {
+	if len(ids) == 0 {
+		donors, err := s.setRepo.GetDonors()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get donor sets: %w", err)
+		}
+		return donors, nil
+	}
+
+	donors := make([]entity.Set, 0, len(ids))
+	for _, id := range ids {
+		set, err := s.setRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get donor set %d: %w", id, err)
+		}
+		donors = append(donors, *set)
+	}
+	return donors, nil
+}
+
+// loadStock lists the loose parts, then the parts available in each copy of the donor sets
+func (s *fulfillmentService) loadStock(donors []entity.Set) ([]*fulfillmentStock, error) {
+	looseParts, err := s.loosePartRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loose parts: %w", err)
+	}
+
+	var stock []*fulfillmentStock
+	for _, loosePart := range looseParts {
+		stock = append(stock, &fulfillmentStock{
+			source: FulfillmentSource{
+				Type:              FulfillmentSourceLoose,
+				LoosePartID:       loosePart.ID,
+				StorageLocationID: loosePart.StorageLocationID,
+				Location:          storageLocationPathOf(loosePart.StorageLocation),
+				PartNum:           loosePart.Part.PartNum,
+			},
+			part:      loosePart.Part,
+			colorID:   loosePart.ColorID,
+			available: loosePart.Quantity,
+		})
+	}
+
+	for _, donor := range donors {
+		setParts, err := s.setPartRepo.GetBySetID(donor.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parts of donor set %d: %w", donor.ID, err)
+		}
+		copies, err := s.setCopyRepo.GetBySetID(donor.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get copies of donor set %d: %w", donor.ID, err)
+		}
+		missingParts, err := s.missingPartsRepo.GetMissingBySetID(donor.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get missing parts of donor set %d: %w", donor.ID, err)
+		}
+
+		missing := make(map[string]int)
+		for _, missingPart := range missingParts {
+			if missingPart.SetMinifigID == nil {
+				missing[donorStockKey(missingPart.SetCopyID, missingPart.PartID, missingPart.ColorID, missingPart.IsSpare)] += missingPart.Quantity
+			}
+		}
+
+		for _, setCopy := range copies {
+			for i := range setParts {
+				setPart := &setParts[i]
+				available := setPart.Quantity - missing[donorStockKey(setCopy.ID, setPart.PartID, setPart.ColorID, setPart.IsSpare)]
+				if available <= 0 {
+					continue
+				}
+				stock = append(stock, &fulfillmentStock{
+					source: FulfillmentSource{
+						Type:              FulfillmentSourceDonor,
+						StorageLocationID: setCopy.StorageLocationID,
+						Location:          storageLocationPathOf(setCopy.StorageLocation),
+						DonorSetID:        donor.ID,
+						DonorSetNum:       donor.SetNum,
+						DonorSetCopyID:    setCopy.ID,
+						DonorSetPartID:    setPart.ID,
+						PartNum:           setPart.Part.PartNum,
+					},
+					part:      setPart.Part,
+					colorID:   setPart.ColorID,
+					available: available,
+				})
+			}
+		}
+	}
+
+	return stock, nil
+}
+
+// partFamilies groups the parts of the plan and of the stock with their prints and mold variants,
+// from the print_of of the parts and the part relationships of the local catalog
+func (s *fulfillmentService) partFamilies(items []FulfillmentItem, stock []*fulfillmentStock) (partFamilies, error) {
+	families := partFamilies{}
+	var partNums []string
+	addPart := func(part entity.Part) {
+		if _, ok := families[strings.ToLower(part.PartNum)]; !ok {
+			partNums = append(partNums, part.PartNum)
+		}
+		families.union(part.PartNum, part.PartNum)
+		if part.PrintOf != "" {
+			families.union(part.PartNum, part.PrintOf)
+		}
+	}
+	for _, item := range items {
+		addPart(item.Part)
+	}
+	for _, entry := range stock {
+		addPart(entry.part)
+	}
+
+	relationships, err := s.catalogRepo.GetPartRelationships(partNums, []string{entity.CatalogRelPrint, entity.CatalogRelMold})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get part relationships: %w", err)
+	}
+	for _, relationship := range relationships {
+		families.union(relationship.ChildPartNum, relationship.ParentPartNum)
+	}
+
+	return families, nil
+}
+
+// allocate takes the missing quantity of item from the candidates in order. Alternate allocation
+// skips the exact part, which was allocated before.
+func allocate(item *FulfillmentItem, candidates []*fulfillmentStock, alternate bool) {
+	for _, entry := range candidates {
+		remaining := item.Missing - item.Covered
+		if remaining == 0 {
+			return
+		}
+		if entry.available == 0 || (alternate && entry.part.ID == item.Part.ID) {
+			continue
+		}
+
+		quantity := min(remaining, entry.available)
+		entry.available -= quantity
+		item.Covered += quantity
+
+		source := entry.source
+		source.Alternate = alternate
+		source.Quantity = quantity
+		item.Sources = append(item.Sources, source)
+	}
+}
+
+// takeFromSource removes the pieces of source from the loose parts inventory or from the donor copy,
+// and returns the notes describing where the pieces came from
+func takeFromSource(repos repository.TxRepositories, source FulfillmentSource, setPart *entity.SetPart, item FulfillmentItem) (string, error) {
+	switch source.Type {
+	case FulfillmentSourceLoose:
+		loosePart, err := repos.LooseParts.GetByID(source.LoosePartID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get loose part %d: %w", source.LoosePartID, err)
+		}
+		if loosePart.Quantity < source.Quantity {
+			return "", fmt.Errorf("loose part %d only has %d pieces left: %w", loosePart.ID, loosePart.Quantity, ErrInvalidLoosePart)
+		}
+
+		loosePart.Quantity -= source.Quantity
+		if loosePart.Quantity == 0 {
+			err = repos.LooseParts.Delete(loosePart.ID)
+		} else {
+			err = repos.LooseParts.Update(loosePart)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to update loose part %d: %w", loosePart.ID, err)
+		}
+
+		notes := "Taken from loose parts " + source.PartNum
+		if source.Location != "" {
+			notes += " in " + source.Location
+		}
+		return notes, nil
+
+	case FulfillmentSourceDonor:
+		label := fmt.Sprintf("set_part_id %d", setPart.ID)
+		candidate := newSetPartCandidate(setPart, source.Quantity, label)
+		candidate.missingPart.SetCopyID = source.DonorSetCopyID
+		candidate.missingPart.Notes = fmt.Sprintf("Donated to set %s", item.SetNum)
+		if _, err := upsertMissingPart(repos.MissingParts, *candidate); err != nil {
+			return "", fmt.Errorf("failed to take %s from donor set %s: %w", source.PartNum, source.DonorSetNum, err)
+		}
+		notes := fmt.Sprintf("Taken from donor set %s (%s)", source.DonorSetNum, source.PartNum)
+		if source.Location != "" {
+			notes += " in " + source.Location
+		}
+		return notes, nil
+
+	default:
+		return "", fmt.Errorf("unknown fulfillment source %q", source.Type)
+	}
+}
+
+// donorStockKey identifies a part of a donor copy
+func donorStockKey(setCopyID, partID uint, colorID int, isSpare bool) string {
+	return fmt.Sprintf("%d|%d|%d|%t", setCopyID, partID, colorID, isSpare)
+}
+
+// partFamilies is a union-find of part numbers that can replace each other
+type partFamilies map[string]string
+
+// find returns the representative part number of the family of partNum
+func (f partFamilies) find(partNum string) string {
+	partNum = strings.ToLower(partNum)
+	parent, ok := f[partNum]
+	if !ok || parent == partNum {
+		return partNum
+	}
+	root := f.find(parent)
+	f[partNum] = root
+	return root
+}
+
+// union merges the families of two part numbers
+func (f partFamilies) union(a, b string) {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if _, ok := f[a]; !ok {
+		f[a] = a
+	}
+	if _, ok := f[b]; !ok {
+		f[b] = b
+	}
+	rootA, rootB := f.find(a), f.find(b)
+	if rootA != rootB {
+		f[rootA] = rootB
+	}
+}
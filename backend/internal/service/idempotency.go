@@ -0,0 +1,66 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// hashIdempotentRequest fingerprints the payload of a request sent with an idempotency key
+func hashIdempotentRequest(payload ...interface{}) (string, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash request: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replayIdempotentRequest loads the response stored for key into response.
+// It reports false when the key has not been used yet.
+func replayIdempotentRequest(repo repository.IdempotencyKeyRepository, key, scope, requestHash string, response interface{}) (bool, error) {
+	stored, err := repo.GetByKey(key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if stored.Scope != scope || stored.RequestHash != requestHash {
+		return false, fmt.Errorf("idempotency key %q: %w", key, ErrIdempotencyKeyReused)
+	}
+
+	if err := json.Unmarshal([]byte(stored.Response), response); err != nil {
+		return false, fmt.Errorf("failed to decode stored response for idempotency key %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// storeIdempotentResponse records the response of a request so that retries can replay it
+func storeIdempotentResponse(repo repository.IdempotencyKeyRepository, key, scope, requestHash string, response interface{}) error {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode response for idempotency key %q: %w", key, err)
+	}
+
+	err = repo.Create(&entity.IdempotencyKey{
+		Key:         key,
+		Scope:       scope,
+		RequestHash: requestHash,
+		Response:    string(encoded),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store idempotency key %q: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// importJobMaxAttempts is how many times a job is run before it is marked as failed
+	importJobMaxAttempts = 5
+	// importJobRetryDelay is the wait before the first retry of a job, doubled on each retry
+	importJobRetryDelay = 30 * time.Second
+	// importJobPollInterval is how often the worker looks for jobs due for a retry
+	importJobPollInterval = 5 * time.Second
+)
+
+// ImportJobService imports sets from Rebrickable in the background
+type ImportJobService interface {
+	Start()
+	EnqueueSetImport(setNum string, copies int) (*entity.ImportJob, error)
+	ImportSetList(format string, r io.Reader) (*SetListImportReport, error)
+	GetJob(id uint) (*entity.ImportJob, error)
+}
+
+// importJobService implements ImportJobService interface
+type importJobService struct {
+	jobRepo        repository.ImportJobRepository
+	setRepo        repository.SetRepository
+	setService     SetService
+	setPartService SetPartService
+	minifigService MinifigService
+	wake           chan struct{}
+}
+
+// NewImportJobService creates a new import job service. Jobs are only run once it is started.
+func NewImportJobService(jobRepo repository.ImportJobRepository, setRepo repository.SetRepository, setService SetService, setPartService SetPartService, minifigService MinifigService) ImportJobService {
+	return &importJobService{
+		jobRepo:        jobRepo,
+		setRepo:        setRepo,
+		setService:     setService,
+		setPartService: setPartService,
+		minifigService: minifigService,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Start runs the queued jobs one at a time in the background. Jobs left running by a previous
+// server are queued again and resume from the step they were at.
+func (s *importJobService) Start() {
+	if err := s.jobRepo.RequeueRunning(); err != nil {
+		log.Printf("Warning: failed to requeue interrupted import jobs: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(importJobPollInterval)
+		defer ticker.Stop()
+
+		for {
+			s.runDueJobs()
+
+			select {
+			case <-s.wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// EnqueueSetImport queues the import of a set that is not stored yet, with the given number of copies.
+// A set whose import failed keeps its copies and has its inventory imported again. The job already
+// queued or running for the set number is returned instead of a new one.
+func (s *importJobService) EnqueueSetImport(setNum string, copies int) (*entity.ImportJob, error) {
+	set, err := s.setRepo.GetBySetNum(setNum)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.enqueue(&entity.ImportJob{SetNum: setNum, Step: entity.ImportJobStepSet, Copies: copies})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get set %s: %w", setNum, err)
+	}
+	if set.ImportStatus != entity.SetImportStatusFailed {
+		return nil, fmt.Errorf("set with number %s: %w", setNum, ErrSetAlreadyExists)
+	}
+
+	// Flagged before the job is queued, so that the worker cannot finish it first
+	if err := s.setRepo.UpdateImportStatus(set.ID, entity.SetImportStatusImporting); err != nil {
+		return nil, fmt.Errorf("failed to update import status of set %s: %w", setNum, err)
+	}
+	return s.enqueue(&entity.ImportJob{SetNum: setNum, SetID: &set.ID, Step: entity.ImportJobStepParts, Progress: stepProgress(entity.ImportJobStepParts), Copies: copies})
+}
+
+// GetJob retrieves an import job with its set
+func (s *importJobService) GetJob(id uint) (*entity.ImportJob, error) {
+	return s.jobRepo.GetByID(id)
+}
+
+// enqueue stores a new job, unless one is already queued or running for the same set, and wakes
+// the worker up
+func (s *importJobService) enqueue(job *entity.ImportJob) (*entity.ImportJob, error) {
+	active, err := s.jobRepo.GetActiveBySetNum(job.SetNum)
+	if err == nil {
+		return active, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get import job of set %s: %w", job.SetNum, err)
+	}
+
+	job.Status = entity.ImportJobStatusQueued
+	job.MaxAttempts = importJobMaxAttempts
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// runDueJobs runs the queued jobs until none is due
+func (s *importJobService) runDueJobs() {
+	for {
+		job, err := s.jobRepo.GetNextDue(time.Now())
+		if err != nil {
+			log.Printf("Warning: failed to get next import job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		s.run(job)
+	}
+}
+
+// run runs the remaining steps of a job. A failed job is queued again with a growing delay until it
+// runs out of attempts; a set Rebrickable does not know or that is already stored fails right away.
+func (s *importJobService) run(job *entity.ImportJob) {
+	now := time.Now()
+	job.Status = entity.ImportJobStatusRunning
+	job.Attempts++
+	job.NextAttemptAt = nil
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	s.save(job)
+
+	err := s.runSteps(job)
+	if err == nil {
+		finishedAt := time.Now()
+		job.Status = entity.ImportJobStatusDone
+		job.Error = ""
+		job.FinishedAt = &finishedAt
+		s.save(job)
+		s.updateSetImportStatus(job, entity.SetImportStatusDone)
+		return
+	}
+
+	job.Error = fmt.Sprintf("%s step: %v", job.Step, err)
+	if errors.Is(err, ErrRebrickableNotFound) || errors.Is(err, ErrSetAlreadyExists) || job.Attempts >= job.MaxAttempts {
+		finishedAt := time.Now()
+		job.Status = entity.ImportJobStatusFailed
+		job.FinishedAt = &finishedAt
+		s.save(job)
+		s.updateSetImportStatus(job, entity.SetImportStatusFailed)
+		return
+	}
+
+	delay := importJobRetryDelay << (job.Attempts - 1)
+	var rbErr *RebrickableError
+	if errors.As(err, &rbErr) {
+		delay = max(delay, rbErr.RetryAfter)
+	}
+	nextAttemptAt := time.Now().Add(delay).UTC()
+	job.Status = entity.ImportJobStatusQueued
+	job.NextAttemptAt = &nextAttemptAt
+	s.save(job)
+}
+
+// runSteps runs the steps of a job from the one it is at, saving its progress after each step.
+// Every step can be run again after a failure: the inventory is diffed rather than appended to.
+func (s *importJobService) runSteps(job *entity.ImportJob) error {
+	if job.Step == entity.ImportJobStepSet {
+		set, err := s.createSet(job.SetNum, job.Copies)
+		if err != nil {
+			return err
+		}
+		job.SetID = &set.ID
+		s.advance(job, entity.ImportJobStepParts)
+	}
+
+	if job.Step == entity.ImportJobStepParts {
+		if _, err := s.setPartService.ResyncSetParts(*job.SetID, job.SetNum); err != nil {
+			return err
+		}
+		s.advance(job, entity.ImportJobStepMinifigs)
+	}
+
+	if job.Step == entity.ImportJobStepMinifigs {
+		if err := s.minifigService.ReplaceSetMinifigs(*job.SetID, job.SetNum); err != nil {
+			return err
+		}
+		job.Progress = 100
+	}
+
+	return nil
+}
+
+// createSet creates the set of a job with its copies. A set still flagged as importing was created
+// by a previous attempt that failed before recording it.
+func (s *importJobService) createSet(setNum string, copies int) (*entity.Set, error) {
+	set, err := s.setRepo.GetBySetNum(setNum)
+	if err == nil && set.ImportStatus == entity.SetImportStatusImporting {
+		return set, nil
+	}
+
+	return s.setService.CreateSet(setNum, copies)
+}
+
+// advance moves a job on to its next step
+func (s *importJobService) advance(job *entity.ImportJob, step string) {
+	job.Step = step
+	job.Progress = stepProgress(step)
+	s.save(job)
+}
+
+// save stores the state of a job. Failures are only logged since the job goes on either way.
+func (s *importJobService) save(job *entity.ImportJob) {
+	if err := s.jobRepo.Save(job); err != nil {
+		log.Printf("Warning: failed to save import job %d: %v", job.ID, err)
+	}
+}
+
+// updateSetImportStatus flags the set of a job with the outcome of its import
+func (s *importJobService) updateSetImportStatus(job *entity.ImportJob, status string) {
+	if job.SetID == nil {
+		return
+	}
+
+	if err := s.setRepo.UpdateImportStatus(*job.SetID, status); err != nil {
+		log.Printf("Warning: failed to update import status of set %s: %v", job.SetNum, err)
+	}
+}
+
+// stepProgress is the progress of a job, in percent, when it starts a step
+func stepProgress(step string) int {
+	switch step {
+	case entity.ImportJobStepParts:
+		return 33
+	case entity.ImportJobStepMinifigs:
+		return 66
+	default:
+		return 0
+	}
+}
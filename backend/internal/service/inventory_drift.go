@@ -0,0 +1,341 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// InventoryDrift describes how the inventory of a set changed on Rebrickable since it was stored,
+// and what became of the missing parts of the set
+type InventoryDrift struct {
+	SetID           uint                 `json:"set_id"`
+	SetNum          string               `json:"set_num"`
+	Added           []SetPartDrift       `json:"added"`
+	Removed         []SetPartDrift       `json:"removed"`
+	QuantityChanged []SetPartDrift       `json:"quantity_changed"`
+	Unchanged       int                  `json:"unchanged"`
+	Remapped        []MissingPartRemap   `json:"remapped"`
+	Clamped         []MissingPartClamp   `json:"clamped"`
+	Orphaned        []entity.MissingPart `json:"orphaned"`
+}
+
+// SetPartDrift is a lot of the inventory that was added, removed or whose quantity changed
+type SetPartDrift struct {
+	SetPartID   uint   `json:"set_part_id"`
+	PartNum     string `json:"part_num"`
+	ColorID     int    `json:"color_id"`
+	IsSpare     bool   `json:"is_spare"`
+	OldQuantity int    `json:"old_quantity"`
+	NewQuantity int    `json:"new_quantity"`
+}
+
+// MissingPartRemap is a missing part moved onto a lot of the new inventory
+type MissingPartRemap struct {
+	MissingPartID uint   `json:"missing_part_id"`
+	FromPartNum   string `json:"from_part_num"`
+	ToPartNum     string `json:"to_part_num"`
+	ColorID       int    `json:"color_id"`
+	FromSpare     bool   `json:"from_spare"`
+	ToSpare       bool   `json:"to_spare"`
+}
+
+// MissingPartClamp is a missing part whose quantity was lowered to the quantity of its lot in the
+// new inventory
+type MissingPartClamp struct {
+	MissingPartID uint   `json:"missing_part_id"`
+	PartNum       string `json:"part_num"`
+	ColorID       int    `json:"color_id"`
+	IsSpare       bool   `json:"is_spare"`
+	OldQuantity   int    `json:"old_quantity"`
+	NewQuantity   int    `json:"new_quantity"`
+}
+
+// setPartKey identifies a lot of an inventory the way missing parts reference it
+type setPartKey struct {
+	partID  uint
+	colorID int
+	isSpare bool
+}
+
+// ResyncSetParts updates the inventory of a set with fresh data from Rebrickable. Lots that did not
+// change keep their ID. Missing parts of removed lots are moved onto the lot that replaced them
+// when there is one, and flagged as orphaned otherwise. Missing quantities above the new quantity
+// of their lot are lowered to it.
+func (s *setPartService) ResyncSetParts(setID uint, setNum string) (*InventoryDrift, error) {
+	rbSetParts, err := s.rebrickableService.GetSetParts(setNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch set parts from Rebrickable: %w", err)
+	}
+
+	partsByNum, err := s.partResolver.resolve(rbSetParts)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &InventoryDrift{
+		SetID:           setID,
+		SetNum:          setNum,
+		Added:           []SetPartDrift{},
+		Removed:         []SetPartDrift{},
+		QuantityChanged: []SetPartDrift{},
+		Remapped:        []MissingPartRemap{},
+		Clamped:         []MissingPartClamp{},
+		Orphaned:        []entity.MissingPart{},
+	}
+
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		oldParts, err := repos.SetParts.GetBySetID(setID)
+		if err != nil {
+			return fmt.Errorf("failed to get set parts: %w", err)
+		}
+		sort.Slice(oldParts, func(i, j int) bool { return oldParts[i].ID < oldParts[j].ID })
+
+		// Lines of the new inventory are matched in order with the stored lots of the same key
+		oldByKey := make(map[setPartKey][]*entity.SetPart)
+		for i := range oldParts {
+			key := keyOfSetPart(oldParts[i])
+			oldByKey[key] = append(oldByKey[key], &oldParts[i])
+		}
+
+		newLots := make(map[setPartKey]*entity.Part)
+		newQuantities := make(map[setPartKey]int)
+		var added []entity.SetPart
+		for _, rbSetPart := range rbSetParts {
+			part := partsByNum[rbSetPart.Part.PartNum]
+			key := setPartKey{partID: part.ID, colorID: rbSetPart.Color.ID, isSpare: rbSetPart.IsSpare}
+			newLots[key] = part
+			newQuantities[key] += rbSetPart.Quantity
+
+			if olds := oldByKey[key]; len(olds) > 0 {
+				old := olds[0]
+				oldByKey[key] = olds[1:]
+				if old.Quantity == rbSetPart.Quantity {
+					drift.Unchanged++
+					continue
+				}
+
+				drift.QuantityChanged = append(drift.QuantityChanged, newSetPartDrift(old, part.PartNum, old.Quantity, rbSetPart.Quantity))
+				updated := *old
+				updated.Quantity = rbSetPart.Quantity
+				updated.Part = entity.Part{}
+				updated.Color = entity.Color{}
+				if err := repos.SetParts.Update(&updated); err != nil {
+					return fmt.Errorf("failed to update set part %d: %w", old.ID, err)
+				}
+				continue
+			}
+
+			added = append(added, entity.SetPart{
+				SetID:    setID,
+				PartID:   part.ID,
+				ColorID:  rbSetPart.Color.ID,
+				Quantity: rbSetPart.Quantity,
+				IsSpare:  rbSetPart.IsSpare,
+			})
+		}
+
+		// Pieces of the lots gone from the new inventory, by key
+		removed := make(map[setPartKey]int)
+		for i := range oldParts {
+			old := &oldParts[i]
+			key := keyOfSetPart(*old)
+			if !containsSetPart(oldByKey[key], old) {
+				continue
+			}
+			if err := repos.SetParts.Delete(old.ID); err != nil {
+				return fmt.Errorf("failed to delete set part %d: %w", old.ID, err)
+			}
+			drift.Removed = append(drift.Removed, newSetPartDrift(old, old.Part.PartNum, old.Quantity, 0))
+			if _, kept := newLots[key]; !kept {
+				removed[key] += old.Quantity
+			}
+		}
+
+		if len(added) > 0 {
+			if err := repos.SetParts.CreateBatch(added); err != nil {
+				return fmt.Errorf("failed to create set parts: %w", err)
+			}
+		}
+		for i := range added {
+			drift.Added = append(drift.Added, newSetPartDrift(&added[i], partOfLot(newLots, added[i]).PartNum, 0, added[i].Quantity))
+		}
+
+		return remapMissingParts(repos.MissingParts, setID, newLots, newQuantities, removed, added, drift)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return drift, nil
+}
+
+// remapMissingParts checks the missing parts of a set against its new inventory. Minifig parts are
+// left alone since set_parts does not list them.
+func remapMissingParts(repo repository.MissingPartsRepository, setID uint, newLots map[setPartKey]*entity.Part, newQuantities map[setPartKey]int, removed map[setPartKey]int, added []entity.SetPart, drift *InventoryDrift) error {
+	missingParts, err := repo.GetBySetID(setID)
+	if err != nil {
+		return fmt.Errorf("failed to get missing parts: %w", err)
+	}
+
+	for i := range missingParts {
+		missingPart := &missingParts[i]
+		if missingPart.SetMinifigID != nil {
+			continue
+		}
+
+		key := setPartKey{partID: missingPart.PartID, colorID: missingPart.ColorID, isSpare: missingPart.IsSpare}
+		if _, ok := newLots[key]; ok {
+			clamped := clampMissingQuantity(missingPart, newQuantities[key], drift)
+			if missingPart.Orphaned || clamped {
+				missingPart.Orphaned = false
+				if err := updateMissingPartLot(repo, missingPart, nil); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		fromPartNum := missingPart.Part.PartNum
+		target, ok := remapTarget(key, newLots, removed, added)
+		if ok {
+			// The new lot may already be recorded as missing on the same copy
+			candidate := *missingPart
+			candidate.PartID, candidate.IsSpare = target.partID, target.isSpare
+			existing, err := repo.GetByKey(candidate)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("failed to get missing part: %w", err)
+			}
+			ok = existing == nil
+		}
+
+		if !ok {
+			missingPart.Orphaned = true
+			if err := updateMissingPartLot(repo, missingPart, nil); err != nil {
+				return err
+			}
+			drift.Orphaned = append(drift.Orphaned, *missingPart)
+			continue
+		}
+
+		missingPart.PartID = target.partID
+		missingPart.IsSpare = target.isSpare
+		missingPart.Orphaned = false
+		missingPart.Part = *newLots[target]
+		clampMissingQuantity(missingPart, newQuantities[target], drift)
+		if err := updateMissingPartLot(repo, missingPart, newLots[target]); err != nil {
+			return err
+		}
+		drift.Remapped = append(drift.Remapped, MissingPartRemap{
+			MissingPartID: missingPart.ID,
+			FromPartNum:   fromPartNum,
+			ToPartNum:     missingPart.Part.PartNum,
+			ColorID:       missingPart.ColorID,
+			FromSpare:     key.isSpare,
+			ToSpare:       target.isSpare,
+		})
+	}
+
+	return nil
+}
+
+// clampMissingQuantity lowers the quantity still missing of a missing part to the quantity of its lot,
+// reporting it in the drift. It returns whether the missing part changed.
+func clampMissingQuantity(missingPart *entity.MissingPart, quantity int, drift *InventoryDrift) bool {
+	if !missingPart.IsMissing || missingPart.Quantity <= quantity {
+		return false
+	}
+
+	drift.Clamped = append(drift.Clamped, MissingPartClamp{
+		MissingPartID: missingPart.ID,
+		PartNum:       missingPart.Part.PartNum,
+		ColorID:       missingPart.ColorID,
+		IsSpare:       missingPart.IsSpare,
+		OldQuantity:   missingPart.Quantity,
+		NewQuantity:   quantity,
+	})
+	missingPart.Quantity = quantity
+	return true
+}
+
+// remapTarget finds the lot of the new inventory that replaced a lot gone from it: a single lot
+// added with the same color, spare flag and quantity (the part was renumbered), or else the same
+// part and color on the other side of the spare flag
+func remapTarget(key setPartKey, newLots map[setPartKey]*entity.Part, removed map[setPartKey]int, added []entity.SetPart) (setPartKey, bool) {
+	if quantity, ok := removed[key]; ok {
+		addedQuantities := make(map[setPartKey]int)
+		for _, setPart := range added {
+			if setPart.ColorID == key.colorID && setPart.IsSpare == key.isSpare {
+				addedQuantities[keyOfSetPart(setPart)] += setPart.Quantity
+			}
+		}
+
+		var matches []setPartKey
+		for addedKey, addedQuantity := range addedQuantities {
+			if addedQuantity == quantity {
+				matches = append(matches, addedKey)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0], true
+		}
+	}
+
+	flipped := setPartKey{partID: key.partID, colorID: key.colorID, isSpare: !key.isSpare}
+	if _, ok := newLots[flipped]; ok {
+		return flipped, true
+	}
+	return setPartKey{}, false
+}
+
+// updateMissingPartLot saves a missing part moved onto another lot, or whose orphaned flag changed.
+// Its part relation is replaced first, since saving it would otherwise restore the previous part.
+func updateMissingPartLot(repo repository.MissingPartsRepository, missingPart *entity.MissingPart, part *entity.Part) error {
+	current := missingPart.Part
+	missingPart.Part = entity.Part{}
+	if err := repo.Update(missingPart); err != nil {
+		return fmt.Errorf("failed to update missing part %d: %w", missingPart.ID, err)
+	}
+
+	missingPart.Part = current
+	if part != nil {
+		missingPart.Part = *part
+	}
+	return nil
+}
+
+// keyOfSetPart returns the key of a lot
+func keyOfSetPart(setPart entity.SetPart) setPartKey {
+	return setPartKey{partID: setPart.PartID, colorID: setPart.ColorID, isSpare: setPart.IsSpare}
+}
+
+// containsSetPart reports whether setPart is one of setParts
+func containsSetPart(setParts []*entity.SetPart, setPart *entity.SetPart) bool {
+	for _, candidate := range setParts {
+		if candidate == setPart {
+			return true
+		}
+	}
+	return false
+}
+
+// partOfLot returns the part of a lot of the new inventory
+func partOfLot(newLots map[setPartKey]*entity.Part, setPart entity.SetPart) *entity.Part {
+	return newLots[keyOfSetPart(setPart)]
+}
+
+// newSetPartDrift describes a change to a lot
+func newSetPartDrift(setPart *entity.SetPart, partNum string, oldQuantity, newQuantity int) SetPartDrift {
+	return SetPartDrift{
+		SetPartID:   setPart.ID,
+		PartNum:     partNum,
+		ColorID:     setPart.ColorID,
+		IsSpare:     setPart.IsSpare,
+		OldQuantity: oldQuantity,
+		NewQuantity: newQuantity,
+	}
+}
@@ -0,0 +1,473 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ImportFormatJSON is a JSON array of LoosePartRequest, accepted by ImportLooseParts only
+const ImportFormatJSON = "json"
+
+// ErrInvalidLoosePart is returned when a loose part request has a quantity that is not positive
+var ErrInvalidLoosePart = errors.New("invalid loose part")
+
+// ErrInvalidLoosePartsImport is returned for a loose parts file in an unsupported format or that cannot be read
+var ErrInvalidLoosePartsImport = errors.New("invalid loose parts import")
+
+// LoosePartService handles business logic for the loose parts inventory
+type LoosePartService interface {
+	GetLooseParts(filter repository.LoosePartFilter, query repository.ListQuery) (*repository.Page[entity.LoosePart], error)
+	GetLoosePart(id uint) (*entity.LoosePart, error)
+	AddLoosePart(req LoosePartRequest) (*entity.LoosePart, error)
+	UpdateLoosePart(id uint, req LoosePartUpdate) (*entity.LoosePart, error)
+	DeleteLoosePart(id uint) error
+	ImportLooseParts(format string, storageLocationID *uint, r io.Reader) (*LoosePartsImportReport, error)
+}
+
+// LoosePartRequest adds pieces of a part, in a Rebrickable color, to the loose parts inventory
+type LoosePartRequest struct {
+	PartNum           string `json:"part_num" binding:"required"`
+	ColorID           *int   `json:"color_id" binding:"required"`
+	Quantity          int    `json:"quantity"`
+	StorageLocationID *uint  `json:"storage_location_id"`
+	Notes             string `json:"notes"`
+}
+
+// LoosePartUpdate changes a loose part entry. Nil fields are left unchanged and a storage_location_id
+// of 0 takes the entry out of its location.
+type LoosePartUpdate struct {
+	Quantity          *int    `json:"quantity"`
+	StorageLocationID *uint   `json:"storage_location_id"`
+	Notes             *string `json:"notes"`
+}
+
+// LoosePartsImportReport summarizes an import into the loose parts inventory
+type LoosePartsImportReport struct {
+	Format    string                  `json:"format"`
+	Applied   []*entity.LoosePart     `json:"applied"`
+	Unmatched []MissingPartsImportRow `json:"unmatched"`
+}
+
+// loosePartLot accumulates the quantity to add to one part and color in one storage location
+type loosePartLot struct {
+	partID            uint
+	colorID           int
+	quantity          int
+	storageLocationID *uint
+}
+
+// loosePartService implements LoosePartService interface
+type loosePartService struct {
+	loosePartRepo       repository.LoosePartRepository
+	partRepo            repository.PartRepository
+	colorRepo           repository.ColorRepository
+	storageLocationRepo repository.StorageLocationRepository
+	rebrickableService  RebrickableService
+	transactor          repository.Transactor
+}
+
+// NewLoosePartService creates a new loose part service
+func NewLoosePartService(loosePartRepo repository.LoosePartRepository, partRepo repository.PartRepository, colorRepo repository.ColorRepository, storageLocationRepo repository.StorageLocationRepository, rebrickableService RebrickableService, transactor repository.Transactor) LoosePartService {
+	return &loosePartService{
+		loosePartRepo:       loosePartRepo,
+		partRepo:            partRepo,
+		colorRepo:           colorRepo,
+		storageLocationRepo: storageLocationRepo,
+		rebrickableService:  rebrickableService,
+		transactor:          transactor,
+	}
+}
+
+// GetLooseParts retrieves a page of the loose parts inventory
+func (s *loosePartService) GetLooseParts(filter repository.LoosePartFilter, query repository.ListQuery) (*repository.Page[entity.LoosePart], error) {
+	return s.loosePartRepo.List(filter, query)
+}
+
+// GetLoosePart retrieves a loose part entry with its part, color and storage location
+func (s *loosePartService) GetLoosePart(id uint) (*entity.LoosePart, error) {
+	return s.loosePartRepo.GetByID(id)
+}
+
+// AddLoosePart adds pieces to the entry of their part and color in their storage location, creating
+// it when needed. Parts that are not stored yet are fetched from Rebrickable.
+func (s *loosePartService) AddLoosePart(req LoosePartRequest) (*entity.LoosePart, error) {
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be positive, got %d: %w", req.Quantity, ErrInvalidLoosePart)
+	}
+
+	part, err := s.resolvePart(req.PartNum)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.colorRepo.GetByID(*req.ColorID); err != nil {
+		return nil, fmt.Errorf("failed to get color %d: %w", *req.ColorID, err)
+	}
+	if err := checkStorageLocation(s.storageLocationRepo, req.StorageLocationID); err != nil {
+		return nil, err
+	}
+
+	var loosePart *entity.LoosePart
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		var err error
+		loosePart, err = addLooseParts(repos.LooseParts, loosePartLot{
+			partID:            part.ID,
+			colorID:           *req.ColorID,
+			quantity:          req.Quantity,
+			storageLocationID: req.StorageLocationID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if req.Notes != "" {
+			loosePart.Notes = req.Notes
+			return repos.LooseParts.Update(loosePart)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loosePartRepo.GetByID(loosePart.ID)
+}
+
+// UpdateLoosePart sets the quantity, storage location or notes of a loose part entry. An entry moved
+// to a location that already holds its part and color is merged into the entry kept there, which is
+// returned instead.
+func (s *loosePartService) UpdateLoosePart(id uint, req LoosePartUpdate) (*entity.LoosePart, error) {
+	loosePart, err := s.loosePartRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loose part %d: %w", id, err)
+	}
+
+	if req.Quantity != nil {
+		if *req.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be positive, got %d, delete the entry instead: %w", *req.Quantity, ErrInvalidLoosePart)
+		}
+		loosePart.Quantity = *req.Quantity
+	}
+	locationID := loosePart.StorageLocationID
+	if req.StorageLocationID != nil {
+		locationID = req.StorageLocationID
+		if *req.StorageLocationID == 0 {
+			locationID = nil
+		}
+		if err := checkStorageLocation(s.storageLocationRepo, locationID); err != nil {
+			return nil, err
+		}
+	}
+	if req.Notes != nil {
+		loosePart.Notes = *req.Notes
+	}
+
+	// A new location moves the whole entry, which joins the entry already kept there
+	resultID := id
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		if err := repos.LooseParts.Update(loosePart); err != nil {
+			return fmt.Errorf("failed to update loose part %d: %w", id, err)
+		}
+		moved, err := moveLooseParts(repos.LooseParts, loosePart, loosePart.Quantity, locationID)
+		if err != nil {
+			return err
+		}
+		resultID = moved.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loosePartRepo.GetByID(resultID)
+}
+
+// DeleteLoosePart removes a loose part entry
+func (s *loosePartService) DeleteLoosePart(id uint) error {
+	if _, err := s.loosePartRepo.GetByID(id); err != nil {
+		return fmt.Errorf("failed to get loose part %d: %w", id, err)
+	}
+	return s.loosePartRepo.Delete(id)
+}
+
+// ImportLooseParts reads a Rebrickable part list CSV, a BrickLink XML file or a JSON list and adds
+// the listed pieces to the loose parts inventory in a single transaction. Rows without a storage location
+// are stored in storageLocationID. Rows whose part, color or location is unknown are reported instead of
+// failing the import.
+func (s *loosePartService) ImportLooseParts(format string, storageLocationID *uint, r io.Reader) (*LoosePartsImportReport, error) {
+	if err := checkStorageLocation(s.storageLocationRepo, storageLocationID); err != nil {
+		return nil, err
+	}
+
+	var rows []importRow
+	var err error
+	switch format {
+	case ImportFormatRebrickableCSV:
+		rows, err = parseRebrickableImport(r)
+	case ImportFormatBrickLinkXML:
+		rows, err = parseBrickLinkImport(r)
+	case ImportFormatJSON:
+		rows, err = parseLoosePartsJSON(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q: %w", format, ErrInvalidLoosePartsImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %v: %w", format, err, ErrInvalidLoosePartsImport)
+	}
+
+	colors, err := s.indexColorsForImport(format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LoosePartsImportReport{
+		Format:    format,
+		Applied:   []*entity.LoosePart{},
+		Unmatched: []MissingPartsImportRow{},
+	}
+
+	var lots []*loosePartLot
+	lotsByKey := make(map[string]*loosePartLot)
+	knownLocations := make(map[uint]bool)
+	for _, row := range rows {
+		if row.err != "" {
+			report.Unmatched = append(report.Unmatched, row.report(row.err, 0))
+			continue
+		}
+
+		if row.storageLocationID != nil {
+			known, ok := knownLocations[*row.storageLocationID]
+			if !ok {
+				err := checkStorageLocation(s.storageLocationRepo, row.storageLocationID)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, err
+				}
+				known = err == nil
+				knownLocations[*row.storageLocationID] = known
+			}
+			if !known {
+				report.Unmatched = append(report.Unmatched, row.report("unknown storage location", 0))
+				continue
+			}
+		}
+
+		colorID, ok := colors[row.color]
+		if !ok {
+			report.Unmatched = append(report.Unmatched, row.report("unknown color", 0))
+			continue
+		}
+
+		// BrickLink part numbers mostly match Rebrickable ones
+		part, err := s.resolvePart(row.partNum)
+		if errors.Is(err, ErrRebrickableNotFound) {
+			report.Unmatched = append(report.Unmatched, row.report("unknown part", 0))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		locationID := storageLocationID
+		if row.storageLocationID != nil {
+			locationID = row.storageLocationID
+		}
+		key := importKey(part.PartNum, strconv.Itoa(colorID))
+		if locationID != nil {
+			key += "|" + strconv.FormatUint(uint64(*locationID), 10)
+		}
+		lot, ok := lotsByKey[key]
+		if !ok {
+			lot = &loosePartLot{partID: part.ID, colorID: colorID, storageLocationID: locationID}
+			lotsByKey[key] = lot
+			lots = append(lots, lot)
+		}
+		lot.quantity += row.quantity
+	}
+
+	var ids []uint
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		for _, lot := range lots {
+			loosePart, err := addLooseParts(repos.LooseParts, *lot)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, loosePart.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		loosePart, err := s.loosePartRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get loose part %d: %w", id, err)
+		}
+		report.Applied = append(report.Applied, loosePart)
+	}
+
+	return report, nil
+}
+
+// resolvePart returns the stored part with the given part number, fetching it from Rebrickable
+// and storing it when it is not known yet
+func (s *loosePartService) resolvePart(partNum string) (*entity.Part, error) {
+	part, err := s.partRepo.GetByPartNum(partNum)
+	if err == nil {
+		return part, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get part %s: %w", partNum, err)
+	}
+
+	rbPart, err := s.rebrickableService.GetPart(partNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch part %s: %w", partNum, err)
+	}
+
+	part = partFromRebrickable(*rbPart)
+	if err := s.partRepo.Create(part); err != nil {
+		return nil, fmt.Errorf("failed to create part %s: %w", partNum, err)
+	}
+	return part, nil
+}
+
+// indexColorsForImport maps the color numbers of an import format to Rebrickable color IDs
+func (s *loosePartService) indexColorsForImport(format string) (map[string]int, error) {
+	colors, err := s.colorRepo.GetAll(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get colors: %w", err)
+	}
+
+	index := make(map[string]int, len(colors))
+	for _, color := range colors {
+		if format != ImportFormatBrickLinkXML {
+			index[strconv.Itoa(color.ID)] = color.ID
+			continue
+		}
+		if id, ok := colorExternalID(color, "BrickLink"); ok {
+			index[strconv.Itoa(id)] = color.ID
+		}
+	}
+	return index, nil
+}
+
+// addLooseParts adds a lot to the entry of its part and color in its storage location, creating the
+// entry when needed
+func addLooseParts(repo repository.LoosePartRepository, lot loosePartLot) (*entity.LoosePart, error) {
+	loosePart, err := repo.GetByKey(lot.partID, lot.colorID, lot.storageLocationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		loosePart = &entity.LoosePart{
+			PartID:            lot.partID,
+			ColorID:           lot.colorID,
+			Quantity:          lot.quantity,
+			StorageLocationID: lot.storageLocationID,
+		}
+		if err := repo.Create(loosePart); err != nil {
+			return nil, fmt.Errorf("failed to create loose part: %w", err)
+		}
+		return loosePart, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loose part: %w", err)
+	}
+
+	loosePart.Quantity += lot.quantity
+	if err := repo.Update(loosePart); err != nil {
+		return nil, fmt.Errorf("failed to update loose part %d: %w", loosePart.ID, err)
+	}
+	return loosePart, nil
+}
+
+// moveLooseParts moves quantity pieces of a loose part entry to a storage location, or out of any
+// when locationID is nil. The pieces join the entry of the same part and color already kept there;
+// the moved entry is deleted once empty. Returns the entry holding the moved pieces.
+func moveLooseParts(repo repository.LoosePartRepository, loosePart *entity.LoosePart, quantity int, locationID *uint) (*entity.LoosePart, error) {
+	if quantity <= 0 || quantity > loosePart.Quantity {
+		return nil, fmt.Errorf("cannot move %d of the %d pieces of loose part %d: %w", quantity, loosePart.Quantity, loosePart.ID, ErrInvalidLoosePart)
+	}
+	if sameStorageLocation(loosePart.StorageLocationID, locationID) {
+		return loosePart, nil
+	}
+
+	source := *loosePart
+	source.Part, source.Color, source.StorageLocation = entity.Part{}, entity.Color{}, nil
+
+	target, err := repo.GetByKey(source.PartID, source.ColorID, locationID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) && quantity == source.Quantity:
+		// The whole entry moves
+		source.StorageLocationID = locationID
+		if err := repo.Update(&source); err != nil {
+			return nil, fmt.Errorf("failed to move loose part %d: %w", source.ID, err)
+		}
+		return &source, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		target = &entity.LoosePart{PartID: source.PartID, ColorID: source.ColorID, Quantity: quantity, StorageLocationID: locationID, Notes: source.Notes}
+		if err := repo.Create(target); err != nil {
+			return nil, fmt.Errorf("failed to create loose part: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get loose part: %w", err)
+	default:
+		target.Quantity += quantity
+		if err := repo.Update(target); err != nil {
+			return nil, fmt.Errorf("failed to update loose part %d: %w", target.ID, err)
+		}
+	}
+
+	source.Quantity -= quantity
+	if source.Quantity == 0 {
+		err = repo.Delete(source.ID)
+	} else {
+		err = repo.Update(&source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update loose part %d: %w", source.ID, err)
+	}
+	return target, nil
+}
+
+// sameStorageLocation reports whether two storage location ids, nil for no location, are the same
+func sameStorageLocation(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// parseLoosePartsJSON reads a JSON array of loose part requests
+func parseLoosePartsJSON(r io.Reader) ([]importRow, error) {
+	var items []LoosePartRequest
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, err
+	}
+
+	rows := make([]importRow, 0, len(items))
+	for i, item := range items {
+		row := importRow{
+			line:              i + 1,
+			partNum:           item.PartNum,
+			quantity:          item.Quantity,
+			storageLocationID: item.StorageLocationID,
+		}
+		switch {
+		case item.PartNum == "" || item.ColorID == nil:
+			row.err = "missing part or color"
+		case item.Quantity <= 0:
+			row.err = fmt.Sprintf("invalid quantity %d", item.Quantity)
+		}
+		if item.ColorID != nil {
+			row.color = strconv.Itoa(*item.ColorID)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// MinifigService handles business logic for minifigs and set minifigs
+type MinifigService interface {
+	SyncSetMinifigsFromRebrickable(setID uint, setNum string) error
+	ReplaceSetMinifigs(setID uint, setNum string) error
+	GetSetMinifigs(setID uint) ([]entity.SetMinifig, error)
+}
+
+// minifigService implements MinifigService interface
+type minifigService struct {
+	minifigRepo        repository.MinifigRepository
+	partResolver       partResolver
+	rebrickableService RebrickableService
+}
+
+// NewMinifigService creates a new minifig service
+func NewMinifigService(minifigRepo repository.MinifigRepository, partRepo repository.PartRepository, colorRepo repository.ColorRepository, rebrickableService RebrickableService) MinifigService {
+	return &minifigService{
+		minifigRepo:        minifigRepo,
+		partResolver:       partResolver{partRepo: partRepo, colorRepo: colorRepo},
+		rebrickableService: rebrickableService,
+	}
+}
+
+// SyncSetMinifigsFromRebrickable imports the minifigs of a set, with their parts, from Rebrickable API
+func (s *minifigService) SyncSetMinifigsFromRebrickable(setID uint, setNum string) error {
+	rbMinifigs, err := s.rebrickableService.GetSetMinifigs(setNum)
+	if err != nil {
+		return fmt.Errorf("failed to fetch set minifigs from Rebrickable: %w", err)
+	}
+
+	var setMinifigs []entity.SetMinifig
+
+	for _, rbMinifig := range rbMinifigs {
+		minifig, err := s.getOrCreateMinifig(rbMinifig)
+		if err != nil {
+			return err
+		}
+
+		setMinifigs = append(setMinifigs, entity.SetMinifig{
+			SetID:     setID,
+			MinifigID: minifig.ID,
+			Quantity:  rbMinifig.Quantity,
+		})
+	}
+
+	if len(setMinifigs) > 0 {
+		if err := s.minifigRepo.CreateSetMinifigs(setMinifigs); err != nil {
+			return fmt.Errorf("failed to create set minifigs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ReplaceSetMinifigs replaces all minifigs of a set with fresh data from Rebrickable
+func (s *minifigService) ReplaceSetMinifigs(setID uint, setNum string) error {
+	if err := s.minifigRepo.DeleteSetMinifigsBySetID(setID); err != nil {
+		return fmt.Errorf("failed to delete existing set minifigs: %w", err)
+	}
+
+	return s.SyncSetMinifigsFromRebrickable(setID, setNum)
+}
+
+// GetSetMinifigs retrieves all minifigs of a set with their parts
+func (s *minifigService) GetSetMinifigs(setID uint) ([]entity.SetMinifig, error) {
+	return s.minifigRepo.GetSetMinifigsBySetID(setID)
+}
+
+// getOrCreateMinifig returns the stored minifig, importing it and its parts on first use
+func (s *minifigService) getOrCreateMinifig(rbMinifig RebrickableSetMinifig) (*entity.Minifig, error) {
+	minifig, err := s.minifigRepo.GetByFigNum(rbMinifig.FigNum)
+	if err == nil {
+		return minifig, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get minifig %s: %w", rbMinifig.FigNum, err)
+	}
+
+	rbParts, err := s.rebrickableService.GetMinifigParts(rbMinifig.FigNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch minifig parts from Rebrickable: %w", err)
+	}
+
+	partsByNum, err := s.partResolver.resolve(rbParts)
+	if err != nil {
+		return nil, err
+	}
+
+	minifig = &entity.Minifig{
+		FigNum:   rbMinifig.FigNum,
+		Name:     rbMinifig.Name,
+		ImageURL: rbMinifig.ImageURL,
+		URL:      fmt.Sprintf("https://rebrickable.com/minifigs/%s/", rbMinifig.FigNum),
+	}
+	for _, rbPart := range rbParts {
+		if !rbPart.IsSpare {
+			minifig.NumParts += rbPart.Quantity
+		}
+		minifig.Parts = append(minifig.Parts, entity.MinifigPart{
+			PartID:   partsByNum[rbPart.Part.PartNum].ID,
+			ColorID:  rbPart.Color.ID,
+			Quantity: rbPart.Quantity,
+			IsSpare:  rbPart.IsSpare,
+		})
+	}
+
+	if err := s.minifigRepo.Create(minifig); err != nil {
+		return nil, fmt.Errorf("failed to create minifig %s: %w", rbMinifig.FigNum, err)
+	}
+
+	return minifig, nil
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+)
+
+// Export formats supported by MissingPartsExportService
+const (
+	ExportFormatBrickLinkXML     = "bricklink_xml"
+	ExportFormatRebrickableCSV   = "rebrickable_csv"
+	ExportFormatBrickOwlWishlist = "brickowl_csv"
+)
+
+// MissingPartsExportService renders missing parts in the formats accepted by brick marketplaces
+type MissingPartsExportService interface {
+	ExportBrickLinkWantedList(filter MissingPartsSummaryFilter) (*MissingPartsExport, error)
+	ExportRebrickableCSV(filter MissingPartsSummaryFilter) (*MissingPartsExport, error)
+	ExportBrickOwlWishlist(filter MissingPartsSummaryFilter) (*MissingPartsExport, error)
+}
+
+// MissingPartsExport is a rendered export together with the rows that could not be mapped
+type MissingPartsExport struct {
+	Format      string              `json:"format"`
+	ContentType string              `json:"content_type"`
+	FileName    string              `json:"file_name"`
+	Content     string              `json:"content"`
+	Rows        int                 `json:"rows"`
+	Unmapped    []UnmappedExportRow `json:"unmapped"`
+}
+
+// UnmappedExportRow is a missing part left out of an export because it has no ID in the target system
+type UnmappedExportRow struct {
+	PartNum   string `json:"part_num"`
+	PartName  string `json:"part_name"`
+	ColorID   int    `json:"color_id"`
+	ColorName string `json:"color_name"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+}
+
+// missingPartsExportService implements MissingPartsExportService interface
+type missingPartsExportService struct {
+	missingPartsService MissingPartsService
+}
+
+// NewMissingPartsExportService creates a new missing parts export service
+func NewMissingPartsExportService(missingPartsService MissingPartsService) MissingPartsExportService {
+	return &missingPartsExportService{missingPartsService: missingPartsService}
+}
+
+// brickLinkInventory is the root element of a BrickLink wanted list upload
+type brickLinkInventory struct {
+	XMLName xml.Name        `xml:"INVENTORY"`
+	Items   []brickLinkItem `xml:"ITEM"`
+}
+
+// brickLinkItem is one lot of a BrickLink wanted list
+type brickLinkItem struct {
+	ItemType string `xml:"ITEMTYPE"`
+	ItemID   string `xml:"ITEMID"`
+	Color    int    `xml:"COLOR"`
+	MinQty   int    `xml:"MINQTY"`
+}
+
+// ExportBrickLinkWantedList renders the missing parts and minifigs matching filter as a BrickLink wanted list XML.
+// Parts and colors without a BrickLink ID are reported as unmapped, as are minifigs.
+func (s *missingPartsExportService) ExportBrickLinkWantedList(filter MissingPartsSummaryFilter) (*MissingPartsExport, error) {
+	summary, err := s.missingPartsService.GetMissingPartsSummary(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &MissingPartsExport{
+		Format:      ExportFormatBrickLinkXML,
+		ContentType: "application/xml",
+		FileName:    "missingbrick-wanted-list.xml",
+		Unmapped:    []UnmappedExportRow{},
+	}
+
+	inventory := brickLinkInventory{Items: []brickLinkItem{}}
+	for _, item := range summary.Items {
+		partID, ok := partExternalID(item.Part, "BrickLink")
+		if !ok {
+			export.Unmapped = append(export.Unmapped, newUnmappedExportRow(item, "no BrickLink part ID"))
+			continue
+		}
+		colorID, ok := colorExternalID(item.Color, "BrickLink")
+		if !ok {
+			export.Unmapped = append(export.Unmapped, newUnmappedExportRow(item, "no BrickLink color ID"))
+			continue
+		}
+
+		inventory.Items = append(inventory.Items, brickLinkItem{
+			ItemType: "P",
+			ItemID:   partID,
+			Color:    colorID,
+			MinQty:   item.Quantity,
+		})
+	}
+	export.Unmapped = append(export.Unmapped, newUnmappedMinifigRows(summary.Minifigs, "no BrickLink minifig ID")...)
+
+	content, err := xml.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render BrickLink XML: %w", err)
+	}
+
+	export.Content = string(content)
+	export.Rows = len(inventory.Items)
+	return export, nil
+}
+
+// ExportRebrickableCSV renders the missing parts matching filter as a Rebrickable part list CSV.
+// Rebrickable part numbers and color IDs are used as is, so every part can be exported.
+// Minifigs cannot be added to a part list and are reported as unmapped.
+func (s *missingPartsExportService) ExportRebrickableCSV(filter MissingPartsSummaryFilter) (*MissingPartsExport, error) {
+	summary, err := s.missingPartsService.GetMissingPartsSummary(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([][]string, 0, len(summary.Items))
+	for _, item := range summary.Items {
+		records = append(records, []string{item.Part.PartNum, strconv.Itoa(item.Color.ID), strconv.Itoa(item.Quantity)})
+	}
+	unmapped := newUnmappedMinifigRows(summary.Minifigs, "minifigs cannot be added to a Rebrickable part list")
+
+	return newCSVExport(ExportFormatRebrickableCSV, "missingbrick-part-list.csv", []string{"Part", "Color", "Quantity"}, records, unmapped)
+}
+
+// ExportBrickOwlWishlist renders the missing parts matching filter as a BrickOwl wishlist CSV.
+// Parts and colors without a BrickOwl ID are reported as unmapped, as are minifigs.
+func (s *missingPartsExportService) ExportBrickOwlWishlist(filter MissingPartsSummaryFilter) (*MissingPartsExport, error) {
+	summary, err := s.missingPartsService.GetMissingPartsSummary(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	unmapped := []UnmappedExportRow{}
+	records := make([][]string, 0, len(summary.Items))
+	for _, item := range summary.Items {
+		boid, ok := partExternalID(item.Part, "BrickOwl")
+		if !ok {
+			unmapped = append(unmapped, newUnmappedExportRow(item, "no BrickOwl part ID"))
+			continue
+		}
+		colorID, ok := colorExternalID(item.Color, "BrickOwl")
+		if !ok {
+			unmapped = append(unmapped, newUnmappedExportRow(item, "no BrickOwl color ID"))
+			continue
+		}
+
+		records = append(records, []string{boid, strconv.Itoa(colorID), strconv.Itoa(item.Quantity)})
+	}
+	unmapped = append(unmapped, newUnmappedMinifigRows(summary.Minifigs, "no BrickOwl minifig ID")...)
+
+	return newCSVExport(ExportFormatBrickOwlWishlist, "missingbrick-brickowl-wishlist.csv", []string{"BOID", "Color ID", "Quantity"}, records, unmapped)
+}
+
+// newCSVExport renders a header and records as a CSV export
+func newCSVExport(format, fileName string, header []string, records [][]string, unmapped []UnmappedExportRow) (*MissingPartsExport, error) {
+	var content strings.Builder
+	writer := csv.NewWriter(&content)
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to render CSV: %w", err)
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to render CSV: %w", err)
+	}
+
+	return &MissingPartsExport{
+		Format:      format,
+		ContentType: "text/csv",
+		FileName:    fileName,
+		Content:     content.String(),
+		Rows:        len(records),
+		Unmapped:    unmapped,
+	}, nil
+}
+
+// partExternalID returns the first ID of a part in the given external system, from its stored external IDs
+func partExternalID(part entity.Part, system string) (string, bool) {
+	if part.ExternalIDs == "" {
+		return "", false
+	}
+
+	var externalIDs map[string][]string
+	if err := json.Unmarshal([]byte(part.ExternalIDs), &externalIDs); err != nil {
+		return "", false
+	}
+
+	ids := externalIDs[system]
+	if len(ids) == 0 || ids[0] == "" {
+		return "", false
+	}
+	return ids[0], true
+}
+
+// colorExternalID returns the first ID of a color in the given external system, from its stored external IDs
+func colorExternalID(color entity.Color, system string) (int, bool) {
+	if system == "BrickLink" && color.BrickLinkID != nil {
+		return *color.BrickLinkID, true
+	}
+	if color.ExternalIDs == "" {
+		return 0, false
+	}
+
+	var externalIDs map[string]RebrickableColorExternalID
+	if err := json.Unmarshal([]byte(color.ExternalIDs), &externalIDs); err != nil {
+		return 0, false
+	}
+
+	id := firstExternalColorID(externalIDs, system)
+	if id == nil {
+		return 0, false
+	}
+	return *id, true
+}
+
+// newUnmappedExportRow describes a summary item that could not be exported
+func newUnmappedExportRow(item MissingPartsSummaryItem, reason string) UnmappedExportRow {
+	return UnmappedExportRow{
+		PartNum:   item.Part.PartNum,
+		PartName:  item.Part.Name,
+		ColorID:   item.Color.ID,
+		ColorName: item.Color.Name,
+		Quantity:  item.Quantity,
+		Reason:    reason,
+	}
+}
+
+// newUnmappedMinifigRows describes summary minifigs that could not be exported. Minifigs have no color,
+// so they are reported with Rebrickable's unknown color ID.
+func newUnmappedMinifigRows(items []MissingMinifigsSummaryItem, reason string) []UnmappedExportRow {
+	rows := make([]UnmappedExportRow, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, UnmappedExportRow{
+			PartNum:  item.Minifig.FigNum,
+			PartName: item.Minifig.Name,
+			ColorID:  -1,
+			Quantity: item.Quantity,
+			Reason:   reason,
+		})
+	}
+	return rows
+}
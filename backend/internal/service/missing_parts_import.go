@@ -0,0 +1,303 @@
+package service
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// Import formats accepted by ImportMissingParts
+const (
+	ImportFormatRebrickableCSV = "rebrickable"
+	ImportFormatBrickLinkXML   = "bricklink"
+)
+
+// ErrInvalidMissingPartsImport is returned for a missing parts file in an unsupported format or that cannot be read
+var ErrInvalidMissingPartsImport = errors.New("invalid missing parts import")
+
+// ErrSetInventoryMissing is returned when importing missing parts into a set whose parts are not imported
+var ErrSetInventoryMissing = errors.New("set has no parts inventory")
+
+// MissingPartsImportReport summarizes an import of missing parts into a set
+type MissingPartsImportReport struct {
+	Format       string                  `json:"format"`
+	Applied      []*entity.MissingPart   `json:"applied"`
+	Unmatched    []MissingPartsImportRow `json:"unmatched"`
+	OverQuantity []MissingPartsImportRow `json:"over_quantity"`
+}
+
+// MissingPartsImportRow is a row of an imported file that could not be applied. A row overflowing
+// the set inventory is only partly applied, Imported being the pieces that were added.
+type MissingPartsImportRow struct {
+	Line      int    `json:"line"`
+	PartNum   string `json:"part_num"`
+	Color     string `json:"color"`
+	Quantity  int    `json:"quantity"`
+	Available int    `json:"available,omitempty"`
+	Imported  int    `json:"imported,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// importRow is a parsed row of an imported file, identified in the numbering of its source system
+type importRow struct {
+	line     int
+	partNum  string
+	color    string
+	quantity int
+	err      string
+	// storageLocationID is only read by loose parts imports
+	storageLocationID *uint
+}
+
+// importLot accumulates the rows of a file that resolve to the same set part
+type importLot struct {
+	setPart *entity.SetPart
+	rows    []importRow
+}
+
+// brickLinkImportItem is one lot of a BrickLink wanted list or inventory XML
+type brickLinkImportItem struct {
+	ItemType string `xml:"ITEMTYPE"`
+	ItemID   string `xml:"ITEMID"`
+	Color    string `xml:"COLOR"`
+	MinQty   string `xml:"MINQTY"`
+	Qty      string `xml:"QTY"`
+}
+
+// ImportMissingParts reads a Rebrickable part list CSV or a BrickLink XML file and adds the listed
+// parts to the missing parts of a copy of the set (the oldest one when setCopyID is 0), in a single
+// transaction. Rows that do not match the set inventory are reported instead of failing the whole
+// import. The rows of a part are applied in order up to the quantity in the set; the pieces beyond
+// it are reported.
+func (s *missingPartsService) ImportMissingParts(setID int, setCopyID uint, format string, r io.Reader) (*MissingPartsImportReport, error) {
+	setCopy, err := s.setCopyService.ResolveCopy(uint(setID), setCopyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []importRow
+	switch format {
+	case ImportFormatRebrickableCSV:
+		rows, err = parseRebrickableImport(r)
+	case ImportFormatBrickLinkXML:
+		rows, err = parseBrickLinkImport(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q: %w", format, ErrInvalidMissingPartsImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %v: %w", format, err, ErrInvalidMissingPartsImport)
+	}
+
+	setParts, err := s.setPartRepo.GetBySetID(uint(setID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parts of set %d: %w", setID, err)
+	}
+	if len(setParts) == 0 {
+		return nil, fmt.Errorf("set %d: %w", setID, ErrSetInventoryMissing)
+	}
+
+	report := &MissingPartsImportReport{
+		Format:       format,
+		Applied:      []*entity.MissingPart{},
+		Unmatched:    []MissingPartsImportRow{},
+		OverQuantity: []MissingPartsImportRow{},
+	}
+
+	index := indexSetPartsForImport(setParts, format)
+	var lots []*importLot
+	lotsBySetPart := make(map[uint]*importLot)
+	for _, row := range rows {
+		if row.err != "" {
+			report.Unmatched = append(report.Unmatched, row.report(row.err, 0))
+			continue
+		}
+
+		setPart, ok := index[importKey(row.partNum, row.color)]
+		if !ok {
+			report.Unmatched = append(report.Unmatched, row.report("part and color not found in set inventory", 0))
+			continue
+		}
+
+		lot, ok := lotsBySetPart[setPart.ID]
+		if !ok {
+			lot = &importLot{setPart: setPart}
+			lotsBySetPart[setPart.ID] = lot
+			lots = append(lots, lot)
+		}
+		lot.rows = append(lot.rows, row)
+	}
+
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		for _, lot := range lots {
+			var applied *entity.MissingPart
+			for _, row := range lot.rows {
+				missingPart, err := importMissingPart(repos.MissingParts, lot.setPart, setCopy.ID, row.quantity)
+
+				var quantityErr *MissingQuantityError
+				if errors.As(err, &quantityErr) {
+					// The row only fits partly: the pieces left in the set are added
+					available := quantityErr.Available - quantityErr.AlreadyMissing
+					overflow := row.report("quantity exceeds set inventory", available)
+					if available > 0 {
+						if missingPart, err = importMissingPart(repos.MissingParts, lot.setPart, setCopy.ID, available); err != nil {
+							return err
+						}
+						applied = missingPart
+						overflow.Imported = available
+					}
+					report.OverQuantity = append(report.OverQuantity, overflow)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				applied = missingPart
+			}
+
+			if applied != nil {
+				report.Applied = append(report.Applied, applied)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// importMissingPart adds quantity pieces of a set part to the missing parts of a set copy
+func importMissingPart(repo repository.MissingPartsRepository, setPart *entity.SetPart, setCopyID uint, quantity int) (*entity.MissingPart, error) {
+	candidate := newSetPartCandidate(setPart, quantity, fmt.Sprintf("set_part_id %d", setPart.ID))
+	candidate.missingPart.SetCopyID = setCopyID
+	return upsertMissingPart(repo, *candidate)
+}
+
+// indexSetPartsForImport indexes set parts by part and color in the numbering of the import format.
+// Regular parts take precedence over spares of the same part and color.
+func indexSetPartsForImport(setParts []entity.SetPart, format string) map[string]*entity.SetPart {
+	index := make(map[string]*entity.SetPart, len(setParts))
+	for i := range setParts {
+		setPart := &setParts[i]
+
+		key := importKey(setPart.Part.PartNum, strconv.Itoa(setPart.ColorID))
+		if format == ImportFormatBrickLinkXML {
+			// BrickLink part numbers mostly match Rebrickable ones when no mapping is stored
+			colorID, ok := colorExternalID(setPart.Color, "BrickLink")
+			if !ok {
+				continue
+			}
+			partNum := setPart.Part.PartNum
+			if partID, ok := partExternalID(setPart.Part, "BrickLink"); ok {
+				partNum = partID
+			}
+			key = importKey(partNum, strconv.Itoa(colorID))
+		}
+
+		if existing, ok := index[key]; ok && !existing.IsSpare {
+			continue
+		}
+		index[key] = setPart
+	}
+	return index
+}
+
+// importKey identifies a part in a given color
+func importKey(partNum, color string) string {
+	return strings.ToLower(partNum) + "|" + color
+}
+
+// report describes a row that could not be applied
+func (row importRow) report(reason string, available int) MissingPartsImportRow {
+	return MissingPartsImportRow{
+		Line:      row.line,
+		PartNum:   row.partNum,
+		Color:     row.color,
+		Quantity:  row.quantity,
+		Available: available,
+		Reason:    reason,
+	}
+}
+
+// parseRebrickableImport reads a Rebrickable part list CSV (Part, Color, Quantity)
+func parseRebrickableImport(r io.Reader) ([]importRow, error) {
+	var rows []importRow
+	line := 1
+	_, err := readCSV(r, func(row csvRow) (importRow, error) {
+		line++
+		parsed := importRow{
+			line:    line,
+			partNum: strings.TrimSpace(firstCSVValue(row, "Part", "part_num")),
+			color:   strings.TrimSpace(firstCSVValue(row, "Color", "color_id")),
+		}
+		parsed.quantity, parsed.err = parseImportQuantity(firstCSVValue(row, "Quantity", "quantity"))
+		if parsed.err == "" && (parsed.partNum == "" || parsed.color == "") {
+			parsed.err = "missing part or color"
+		}
+		return parsed, nil
+	}, func(batch []importRow) error {
+		rows = append(rows, batch...)
+		return nil
+	})
+	return rows, err
+}
+
+// parseBrickLinkImport reads a BrickLink wanted list or inventory XML
+func parseBrickLinkImport(r io.Reader) ([]importRow, error) {
+	var inventory struct {
+		Items []brickLinkImportItem `xml:"ITEM"`
+	}
+	if err := xml.NewDecoder(r).Decode(&inventory); err != nil {
+		return nil, err
+	}
+
+	rows := make([]importRow, 0, len(inventory.Items))
+	for i, item := range inventory.Items {
+		row := importRow{
+			line:    i + 1,
+			partNum: strings.TrimSpace(item.ItemID),
+			color:   strings.TrimSpace(item.Color),
+		}
+
+		quantity := item.MinQty
+		if strings.TrimSpace(quantity) == "" {
+			quantity = item.Qty
+		}
+		row.quantity, row.err = parseImportQuantity(quantity)
+
+		if row.err == "" && item.ItemType != "" && strings.TrimSpace(item.ItemType) != "P" {
+			row.err = fmt.Sprintf("unsupported item type %q", item.ItemType)
+		}
+		if row.err == "" && (row.partNum == "" || row.color == "") {
+			row.err = "missing part or color"
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// firstCSVValue returns the value of the first of the given columns that is set
+func firstCSVValue(row csvRow, columns ...string) string {
+	for _, column := range columns {
+		if value := row(column); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseImportQuantity parses a quantity, returning a reason when it is not a positive integer
+func parseImportQuantity(value string) (int, string) {
+	quantity, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || quantity <= 0 {
+		return 0, fmt.Sprintf("invalid quantity %q", value)
+	}
+	return quantity, ""
+}
@@ -1,101 +1,599 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/BombartSimon/MissingBrick/internal/entity"
 	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
 )
 
+// missingPartsAssignScope identifies missing part assignments among stored idempotency keys
+const missingPartsAssignScope = "missing-parts:assign"
+
+// Errors returned when a missing quantity is rejected. Use errors.Is to test for them.
+var (
+	ErrInvalidMissingQuantity  = errors.New("invalid missing quantity")
+	ErrMissingQuantityExceeded = errors.New("missing quantity exceeds set quantity")
+)
+
+// ErrInvalidMissingPart is returned for a request naming no part, or a part or minifig that is not
+// in the set
+var ErrInvalidMissingPart = errors.New("invalid missing part request")
+
+// MissingQuantityError reports a cumulative missing quantity greater than the quantity in the set
+type MissingQuantityError struct {
+	Label          string
+	Quantity       int
+	Available      int
+	AlreadyMissing int
+}
+
+// Error implements the error interface
+func (e *MissingQuantityError) Error() string {
+	if e.AlreadyMissing > 0 {
+		return fmt.Sprintf("missing quantity (%d, of which %d already missing) cannot be greater than set quantity (%d) for %s", e.Quantity, e.AlreadyMissing, e.Available, e.Label)
+	}
+	return fmt.Sprintf("missing quantity (%d) cannot be greater than set quantity (%d) for %s", e.Quantity, e.Available, e.Label)
+}
+
+// Is makes MissingQuantityError match ErrMissingQuantityExceeded
+func (e *MissingQuantityError) Is(target error) bool {
+	return target == ErrMissingQuantityExceeded
+}
+
 type MissingPartsService interface {
-	AssignMissingPartsToSet(setID int, partRequests []MissingPartRequest) ([]*entity.MissingPart, error)
-	GetMissingPartsBySetID(setID int) ([]entity.MissingPart, error)
-	MarkPartAsFound(setID int, partID int) error
+	AssignMissingPartsToSet(setID int, setCopyID uint, partRequests []MissingPartRequest, idempotencyKey string) ([]*entity.MissingPart, error)
+	AssignMissingMinifigsToSet(setID int, setCopyID uint, minifigRequests []MissingMinifigRequest) ([]*entity.MissingMinifig, error)
+	GetMissingPartsBySetID(setID int, filter repository.MissingPartFilter, query repository.ListQuery) (*repository.Page[entity.MissingPart], error)
+	GetMissingMinifigsBySetID(setID int) ([]entity.MissingMinifig, error)
+	GetMissingPartsSummary(filter MissingPartsSummaryFilter) (*MissingPartsSummary, error)
+	ImportMissingParts(setID int, setCopyID uint, format string, r io.Reader) (*MissingPartsImportReport, error)
+	MarkPartAsFound(missingPartID int, quantity *int, notes string) (*entity.MissingPart, error)
+	ReopenMissingPart(missingPartID int, quantity *int, notes string) (*entity.MissingPart, error)
 	DeleteMissingPart(missingPartID int) error
+	DeleteMissingMinifig(missingMinifigID int) error
 }
 
+// MissingPartRequest identifies a missing part either by its set part, or by a part of one of the set's minifigs
 type MissingPartRequest struct {
-	SetPartID uint `json:"set_part_id"`
-	Quantity  *int `json:"quantity,omitempty"`
+	SetPartID     uint `json:"set_part_id"`
+	SetMinifigID  uint `json:"set_minifig_id,omitempty"`
+	MinifigPartID uint `json:"minifig_part_id,omitempty"`
+	Quantity      *int `json:"quantity,omitempty"`
+}
+
+// MissingMinifigRequest identifies a whole minifig missing from a set
+type MissingMinifigRequest struct {
+	SetMinifigID uint `json:"set_minifig_id" binding:"required"`
+	Quantity     *int `json:"quantity,omitempty"`
 }
 
 type missingPartsService struct {
-	missingPartsRepo repository.MissingPartsRepository
-	setPartRepo      repository.SetPartRepository
+	missingPartsRepo   repository.MissingPartsRepository
+	missingMinifigRepo repository.MissingMinifigRepository
+	setPartRepo        repository.SetPartRepository
+	minifigRepo        repository.MinifigRepository
+	setCopyService     SetCopyService
+	themeService       ThemeService
+	transactor         repository.Transactor
 }
 
-func NewMissingPartsService(missingPartsRepo repository.MissingPartsRepository, setPartRepo repository.SetPartRepository) MissingPartsService {
+func NewMissingPartsService(missingPartsRepo repository.MissingPartsRepository, missingMinifigRepo repository.MissingMinifigRepository, setPartRepo repository.SetPartRepository, minifigRepo repository.MinifigRepository, setCopyService SetCopyService, themeService ThemeService, transactor repository.Transactor) MissingPartsService {
 	return &missingPartsService{
-		missingPartsRepo: missingPartsRepo,
-		setPartRepo:      setPartRepo,
+		missingPartsRepo:   missingPartsRepo,
+		missingMinifigRepo: missingMinifigRepo,
+		setPartRepo:        setPartRepo,
+		minifigRepo:        minifigRepo,
+		setCopyService:     setCopyService,
+		themeService:       themeService,
+		transactor:         transactor,
 	}
 }
 
-func (s *missingPartsService) AssignMissingPartsToSet(setID int, partRequests []MissingPartRequest) ([]*entity.MissingPart, error) {
-	var missingParts []*entity.MissingPart
+// AssignMissingPartsToSet marks parts of a copy of a set as missing, the oldest copy when setCopyID
+// is 0. Each request is added to the missing part already recorded for the same copy, part, color
+// and spare flag, and the whole batch is applied in a single transaction. When idempotencyKey is
+// set, a retried request returns the original result.
+func (s *missingPartsService) AssignMissingPartsToSet(setID int, setCopyID uint, partRequests []MissingPartRequest, idempotencyKey string) ([]*entity.MissingPart, error) {
+	if len(partRequests) == 0 {
+		return nil, fmt.Errorf("no part requested: %w", ErrInvalidMissingPart)
+	}
+
+	setCopy, err := s.setCopyService.ResolveCopy(uint(setID), setCopyID)
+	if err != nil {
+		return nil, err
+	}
 
+	var candidates []missingPartCandidate
 	for _, partRequest := range partRequests {
-		setPart, err := s.setPartRepo.GetByID(partRequest.SetPartID)
+		var candidate *missingPartCandidate
+		var err error
+
+		if partRequest.MinifigPartID != 0 {
+			candidate, err = s.newMissingMinifigPart(setID, partRequest)
+		} else {
+			candidate, err = s.newMissingSetPart(setID, partRequest)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to get set part with ID %d: %w", partRequest.SetPartID, err)
+			return nil, err
 		}
+		candidate.missingPart.SetCopyID = setCopy.ID
+		candidates = append(candidates, *candidate)
+	}
 
-		if setPart.SetID != uint(setID) {
-			return nil, fmt.Errorf("set part %d does not belong to set %d", partRequest.SetPartID, setID)
-		}
+	requestHash, err := hashIdempotentRequest(setID, setCopy.ID, partRequests)
+	if err != nil {
+		return nil, err
+	}
 
-		missingQuantity := setPart.Quantity
-		if partRequest.Quantity != nil {
-			missingQuantity = *partRequest.Quantity
+	var missingParts []*entity.MissingPart
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		if idempotencyKey != "" {
+			replayed, err := replayIdempotentRequest(repos.IdempotencyKeys, idempotencyKey, missingPartsAssignScope, requestHash, &missingParts)
+			if err != nil || replayed {
+				return err
+			}
+		}
 
-			if missingQuantity > setPart.Quantity {
-				return nil, fmt.Errorf("missing quantity (%d) cannot be greater than set quantity (%d) for set_part_id %d",
-					missingQuantity, setPart.Quantity, partRequest.SetPartID)
+		missingParts = nil
+		seen := make(map[uint]bool)
+		for _, candidate := range candidates {
+			missingPart, err := upsertMissingPart(repos.MissingParts, candidate)
+			if err != nil {
+				return err
+			}
+			if !seen[missingPart.ID] {
+				seen[missingPart.ID] = true
+				missingParts = append(missingParts, missingPart)
 			}
+		}
+
+		if idempotencyKey != "" {
+			return storeIdempotentResponse(repos.IdempotencyKeys, idempotencyKey, missingPartsAssignScope, requestHash, missingParts)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return missingParts, nil
+}
+
+// missingPartCandidate is a validated request to mark a quantity of an inventory line as missing
+type missingPartCandidate struct {
+	missingPart entity.MissingPart
+	limit       int
+	label       string
+	part        entity.Part
+	color       entity.Color
+}
+
+// upsertMissingPart adds the candidate quantity to the missing part recorded for the same key, or
+// creates it. The cumulative missing quantity may not exceed the candidate limit. A found missing
+// part is reopened with the candidate quantity, which is recorded in its history so that those
+// pieces cannot be reopened again.
+func upsertMissingPart(repo repository.MissingPartsRepository, candidate missingPartCandidate) (*entity.MissingPart, error) {
+	missingPart, err := repo.GetByKey(candidate.missingPart)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get missing part: %w", err)
+	}
+
+	alreadyMissing := 0
+	if missingPart != nil && missingPart.IsMissing {
+		alreadyMissing = missingPart.Quantity
+	}
+
+	total := alreadyMissing + candidate.missingPart.Quantity
+	if total > candidate.limit {
+		return nil, &MissingQuantityError{Label: candidate.label, Quantity: total, Available: candidate.limit, AlreadyMissing: alreadyMissing}
+	}
+
+	if missingPart == nil {
+		missingPart = &candidate.missingPart
+		if err := repo.Create(missingPart); err != nil {
+			return nil, fmt.Errorf("failed to create missing part: %w", err)
+		}
+	} else {
+		reopened := 0
+		if !missingPart.IsMissing {
+			reopened = min(candidate.missingPart.Quantity, recoveredQuantity(missingPart))
+		}
 
-			if missingQuantity <= 0 {
-				return nil, fmt.Errorf("missing quantity must be positive for set_part_id %d", partRequest.SetPartID)
+		missingPart.Quantity = total
+		missingPart.IsMissing = true
+		if err := repo.Update(missingPart); err != nil {
+			return nil, fmt.Errorf("failed to update missing part: %w", err)
+		}
+
+		if reopened > 0 {
+			recovery := &entity.MissingPartRecovery{MissingPartID: missingPart.ID, Action: entity.RecoveryActionReopened, Quantity: reopened, Notes: "marked as missing again"}
+			if err := repo.CreateRecovery(recovery); err != nil {
+				return nil, fmt.Errorf("failed to record recovery: %w", err)
 			}
+			missingPart.Recoveries = append(missingPart.Recoveries, *recovery)
 		}
+	}
+
+	missingPart.Part = candidate.part
+	missingPart.Color = candidate.color
+	return missingPart, nil
+}
+
+// newMissingSetPart validates a request for a part of the set inventory
+func (s *missingPartsService) newMissingSetPart(setID int, partRequest MissingPartRequest) (*missingPartCandidate, error) {
+	setPart, err := s.setPartRepo.GetByID(partRequest.SetPartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get set part with ID %d: %w", partRequest.SetPartID, err)
+	}
+
+	if setPart.SetID != uint(setID) {
+		return nil, fmt.Errorf("set part %d does not belong to set %d: %w", partRequest.SetPartID, setID, ErrInvalidMissingPart)
+	}
+
+	label := fmt.Sprintf("set_part_id %d", partRequest.SetPartID)
+	missingQuantity, err := resolveMissingQuantity(partRequest.Quantity, setPart.Quantity, label)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSetPartCandidate(setPart, missingQuantity, label), nil
+}
 
-		missingPart := &entity.MissingPart{
-			SetID:     uint(setID),
+// newSetPartCandidate builds the candidate marking quantity of a set part as missing
+func newSetPartCandidate(setPart *entity.SetPart, quantity int, label string) *missingPartCandidate {
+	return &missingPartCandidate{
+		missingPart: entity.MissingPart{
+			SetID:     setPart.SetID,
 			PartID:    setPart.PartID,
 			ColorID:   setPart.ColorID,
-			ColorName: setPart.ColorName,
-			ColorHex:  setPart.ColorHex,
-			Quantity:  missingQuantity,
+			Quantity:  quantity,
 			IsMissing: true,
+			IsSpare:   setPart.IsSpare,
+		},
+		limit: setPart.Quantity,
+		label: label,
+		part:  setPart.Part,
+		color: setPart.Color,
+	}
+}
+
+// newMissingMinifigPart validates a request for a part of one of the set's minifigs
+func (s *missingPartsService) newMissingMinifigPart(setID int, partRequest MissingPartRequest) (*missingPartCandidate, error) {
+	setMinifig, err := s.getSetMinifig(setID, partRequest.SetMinifigID)
+	if err != nil {
+		return nil, err
+	}
+
+	minifigPart, err := s.minifigRepo.GetPartByID(partRequest.MinifigPartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get minifig part with ID %d: %w", partRequest.MinifigPartID, err)
+	}
+
+	if minifigPart.MinifigID != setMinifig.MinifigID {
+		return nil, fmt.Errorf("minifig part %d does not belong to set minifig %d: %w", partRequest.MinifigPartID, partRequest.SetMinifigID, ErrInvalidMissingPart)
+	}
+
+	label := fmt.Sprintf("minifig_part_id %d", partRequest.MinifigPartID)
+	limit := minifigPart.Quantity * setMinifig.Quantity
+	missingQuantity, err := resolveMissingQuantity(partRequest.Quantity, limit, label)
+	if err != nil {
+		return nil, err
+	}
+
+	return &missingPartCandidate{
+		missingPart: entity.MissingPart{
+			SetID:        uint(setID),
+			PartID:       minifigPart.PartID,
+			ColorID:      minifigPart.ColorID,
+			Quantity:     missingQuantity,
+			IsMissing:    true,
+			IsSpare:      minifigPart.IsSpare,
+			SetMinifigID: &setMinifig.ID,
+		},
+		limit: limit,
+		label: label,
+		part:  minifigPart.Part,
+		color: minifigPart.Color,
+	}, nil
+}
+
+// AssignMissingMinifigsToSet marks whole minifigs of a copy of a set as missing, the oldest copy when
+// setCopyID is 0. Each request is added to the missing minifig already recorded for the same copy and
+// set minifig, and the whole batch is applied in a single transaction.
+func (s *missingPartsService) AssignMissingMinifigsToSet(setID int, setCopyID uint, minifigRequests []MissingMinifigRequest) ([]*entity.MissingMinifig, error) {
+	if len(minifigRequests) == 0 {
+		return nil, fmt.Errorf("no minifig requested: %w", ErrInvalidMissingPart)
+	}
+
+	setCopy, err := s.setCopyService.ResolveCopy(uint(setID), setCopyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []missingMinifigCandidate
+	for _, minifigRequest := range minifigRequests {
+		setMinifig, err := s.getSetMinifig(setID, minifigRequest.SetMinifigID)
+		if err != nil {
+			return nil, err
 		}
 
-		// TODO: Vérifier si cette pièce n'est pas déjà marquée comme manquante pour ce set
-		// pour éviter les doublons
+		label := fmt.Sprintf("set_minifig_id %d", minifigRequest.SetMinifigID)
+		missingQuantity, err := resolveMissingQuantity(minifigRequest.Quantity, setMinifig.Quantity, label)
+		if err != nil {
+			return nil, err
+		}
 
-		if err := s.missingPartsRepo.Create(missingPart); err != nil {
-			return nil, fmt.Errorf("failed to create missing part: %w", err)
+		candidates = append(candidates, missingMinifigCandidate{
+			missingMinifig: entity.MissingMinifig{
+				SetID:        uint(setID),
+				SetCopyID:    setCopy.ID,
+				SetMinifigID: setMinifig.ID,
+				MinifigID:    setMinifig.MinifigID,
+				Quantity:     missingQuantity,
+				IsMissing:    true,
+			},
+			limit:   setMinifig.Quantity,
+			label:   label,
+			minifig: setMinifig.Minifig,
+		})
+	}
+
+	var missingMinifigs []*entity.MissingMinifig
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		missingMinifigs = nil
+		seen := make(map[uint]bool)
+		for _, candidate := range candidates {
+			missingMinifig, err := upsertMissingMinifig(repos.MissingMinifigs, candidate)
+			if err != nil {
+				return err
+			}
+			if !seen[missingMinifig.ID] {
+				seen[missingMinifig.ID] = true
+				missingMinifigs = append(missingMinifigs, missingMinifig)
+			}
 		}
-		missingParts = append(missingParts, missingPart)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return missingParts, nil
+	return missingMinifigs, nil
+}
+
+// missingMinifigCandidate is a validated request to mark a quantity of a set minifig as missing
+type missingMinifigCandidate struct {
+	missingMinifig entity.MissingMinifig
+	limit          int
+	label          string
+	minifig        entity.Minifig
+}
+
+// upsertMissingMinifig adds the candidate quantity to the missing minifig recorded for the same copy
+// and set minifig, or creates it. The cumulative missing quantity may not exceed the candidate limit.
+// A found missing minifig is reopened with the candidate quantity.
+func upsertMissingMinifig(repo repository.MissingMinifigRepository, candidate missingMinifigCandidate) (*entity.MissingMinifig, error) {
+	missingMinifig, err := repo.GetByKey(candidate.missingMinifig.SetCopyID, candidate.missingMinifig.SetMinifigID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get missing minifig: %w", err)
+	}
+
+	alreadyMissing := 0
+	if missingMinifig != nil && missingMinifig.IsMissing {
+		alreadyMissing = missingMinifig.Quantity
+	}
+
+	total := alreadyMissing + candidate.missingMinifig.Quantity
+	if total > candidate.limit {
+		return nil, &MissingQuantityError{Label: candidate.label, Quantity: total, Available: candidate.limit, AlreadyMissing: alreadyMissing}
+	}
+
+	if missingMinifig == nil {
+		missingMinifig = &candidate.missingMinifig
+		if err := repo.Create(missingMinifig); err != nil {
+			return nil, fmt.Errorf("failed to create missing minifig: %w", err)
+		}
+	} else {
+		missingMinifig.Quantity = total
+		missingMinifig.IsMissing = true
+		if err := repo.Update(missingMinifig); err != nil {
+			return nil, fmt.Errorf("failed to update missing minifig: %w", err)
+		}
+	}
+
+	missingMinifig.Minifig = candidate.minifig
+	return missingMinifig, nil
 }
 
-func (s *missingPartsService) GetMissingPartsBySetID(setID int) ([]entity.MissingPart, error) {
-	missingParts, err := s.missingPartsRepo.GetBySetID(uint(setID))
+// getSetMinifig retrieves a set minifig and checks that it belongs to the set
+func (s *missingPartsService) getSetMinifig(setID int, setMinifigID uint) (*entity.SetMinifig, error) {
+	setMinifig, err := s.minifigRepo.GetSetMinifigByID(setMinifigID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get set minifig with ID %d: %w", setMinifigID, err)
 	}
 
-	return missingParts, nil
+	if setMinifig.SetID != uint(setID) {
+		return nil, fmt.Errorf("set minifig %d does not belong to set %d: %w", setMinifigID, setID, ErrInvalidMissingPart)
+	}
+
+	return setMinifig, nil
 }
 
-func (s *missingPartsService) MarkPartAsFound(setID int, partID int) error {
-	err := s.missingPartsRepo.MarkAsFound(uint(setID), uint(partID))
+// resolveMissingQuantity validates the requested missing quantity against the quantity in the set.
+// When no quantity is requested, everything is considered missing.
+func resolveMissingQuantity(requested *int, available int, label string) (int, error) {
+	if requested == nil {
+		return available, nil
+	}
+
+	if *requested > available {
+		return 0, &MissingQuantityError{Label: label, Quantity: *requested, Available: available}
+	}
+
+	if *requested <= 0 {
+		return 0, fmt.Errorf("missing quantity must be positive for %s: %w", label, ErrInvalidMissingQuantity)
+	}
+
+	return *requested, nil
+}
+
+// GetMissingPartsBySetID retrieves a page of the missing parts of a set, found ones included
+func (s *missingPartsService) GetMissingPartsBySetID(setID int, filter repository.MissingPartFilter, query repository.ListQuery) (*repository.Page[entity.MissingPart], error) {
+	return s.missingPartsRepo.ListBySetID(uint(setID), filter, query)
+}
+
+func (s *missingPartsService) GetMissingMinifigsBySetID(setID int) ([]entity.MissingMinifig, error) {
+	return s.missingMinifigRepo.GetBySetID(uint(setID))
+}
+
+// MarkPartAsFound records that pieces of a missing part were found. The missing quantity is
+// decremented, and the missing part is closed once every piece was found. When quantity is nil,
+// every remaining piece is considered found.
+func (s *missingPartsService) MarkPartAsFound(missingPartID int, quantity *int, notes string) (*entity.MissingPart, error) {
+	return s.recordRecovery(missingPartID, func(missingPart *entity.MissingPart) (*entity.MissingPartRecovery, error) {
+		if !missingPart.IsMissing {
+			return nil, fmt.Errorf("missing part %d was already found: %w", missingPartID, ErrInvalidMissingQuantity)
+		}
+
+		found, err := resolveRecoveryQuantity(quantity, missingPart.Quantity, "found", missingPartID)
+		if err != nil {
+			return nil, err
+		}
+
+		missingPart.Quantity -= found
+		if missingPart.Quantity == 0 {
+			missingPart.IsMissing = false
+		}
+
+		return &entity.MissingPartRecovery{Action: entity.RecoveryActionFound, Quantity: found, Notes: notes}, nil
+	})
+}
+
+// ReopenMissingPart records that pieces previously found are missing again. Up to the quantity
+// recovered so far can be reopened, without the missing quantity exceeding the quantity in the set;
+// when quantity is nil, all of it is.
+func (s *missingPartsService) ReopenMissingPart(missingPartID int, quantity *int, notes string) (*entity.MissingPart, error) {
+	return s.recordRecovery(missingPartID, func(missingPart *entity.MissingPart) (*entity.MissingPartRecovery, error) {
+		if !missingPart.IsMissing {
+			missingPart.Quantity = 0
+		}
+
+		limit, err := s.missingPartLimit(missingPart)
+		if err != nil {
+			return nil, err
+		}
+
+		available := min(recoveredQuantity(missingPart), limit-missingPart.Quantity)
+		reopened, err := resolveRecoveryQuantity(quantity, available, "reopened", missingPartID)
+		if err != nil {
+			return nil, err
+		}
+
+		missingPart.Quantity += reopened
+		missingPart.IsMissing = true
+
+		return &entity.MissingPartRecovery{Action: entity.RecoveryActionReopened, Quantity: reopened, Notes: notes}, nil
+	})
+}
+
+// recoveredQuantity returns the pieces of a missing part found and not reopened since
+func recoveredQuantity(missingPart *entity.MissingPart) int {
+	recovered := 0
+	for _, recovery := range missingPart.Recoveries {
+		switch recovery.Action {
+		case entity.RecoveryActionFound:
+			recovered += recovery.Quantity
+		case entity.RecoveryActionReopened:
+			recovered -= recovery.Quantity
+		}
+	}
+	return recovered
+}
+
+// missingPartLimit returns how many pieces of a missing part can be missing from one copy of its set:
+// the quantity of its inventory line, or of its minifig part times the minifig quantity
+func (s *missingPartsService) missingPartLimit(missingPart *entity.MissingPart) (int, error) {
+	limit := 0
+	if missingPart.SetMinifigID != nil {
+		setMinifigs, err := s.minifigRepo.GetSetMinifigsBySetID(missingPart.SetID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get minifigs of set %d: %w", missingPart.SetID, err)
+		}
+		for _, setMinifig := range setMinifigs {
+			if setMinifig.ID != *missingPart.SetMinifigID {
+				continue
+			}
+			for _, minifigPart := range setMinifig.Minifig.Parts {
+				if minifigPart.PartID == missingPart.PartID && minifigPart.ColorID == missingPart.ColorID && minifigPart.IsSpare == missingPart.IsSpare {
+					limit += minifigPart.Quantity * setMinifig.Quantity
+				}
+			}
+		}
+		return limit, nil
+	}
+
+	setParts, err := s.setPartRepo.GetBySetID(missingPart.SetID)
 	if err != nil {
-		return fmt.Errorf("failed to mark part as found: %w", err)
+		return 0, fmt.Errorf("failed to get parts of set %d: %w", missingPart.SetID, err)
+	}
+	for _, setPart := range setParts {
+		if setPart.PartID == missingPart.PartID && setPart.ColorID == missingPart.ColorID && setPart.IsSpare == missingPart.IsSpare {
+			limit += setPart.Quantity
+		}
 	}
+	return limit, nil
+}
 
-	return nil
+// recordRecovery applies a change to a missing part and stores it in its history, in a single transaction
+func (s *missingPartsService) recordRecovery(missingPartID int, apply func(missingPart *entity.MissingPart) (*entity.MissingPartRecovery, error)) (*entity.MissingPart, error) {
+	var missingPart *entity.MissingPart
+	err := s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		var err error
+		missingPart, err = repos.MissingParts.GetByID(uint(missingPartID))
+		if err != nil {
+			return fmt.Errorf("failed to get missing part with ID %d: %w", missingPartID, err)
+		}
+
+		recovery, err := apply(missingPart)
+		if err != nil {
+			return err
+		}
+
+		if err := repos.MissingParts.Update(missingPart); err != nil {
+			return fmt.Errorf("failed to update missing part: %w", err)
+		}
+
+		recovery.MissingPartID = missingPart.ID
+		if err := repos.MissingParts.CreateRecovery(recovery); err != nil {
+			return fmt.Errorf("failed to record recovery: %w", err)
+		}
+		missingPart.Recoveries = append(missingPart.Recoveries, *recovery)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return missingPart, nil
+}
+
+// resolveRecoveryQuantity validates a found or reopened quantity against what is available.
+// When no quantity is requested, everything available is used.
+func resolveRecoveryQuantity(requested *int, available int, action string, missingPartID int) (int, error) {
+	if available <= 0 {
+		return 0, fmt.Errorf("nothing can be %s for missing part %d: %w", action, missingPartID, ErrInvalidMissingQuantity)
+	}
+	if requested == nil {
+		return available, nil
+	}
+	if *requested <= 0 || *requested > available {
+		return 0, fmt.Errorf("%s quantity (%d) must be between 1 and %d for missing part %d: %w", action, *requested, available, missingPartID, ErrInvalidMissingQuantity)
+	}
+	return *requested, nil
 }
 
 func (s *missingPartsService) DeleteMissingPart(missingPartID int) error {
@@ -106,3 +604,12 @@ func (s *missingPartsService) DeleteMissingPart(missingPartID int) error {
 
 	return nil
 }
+
+func (s *missingPartsService) DeleteMissingMinifig(missingMinifigID int) error {
+	err := s.missingMinifigRepo.Delete(uint(missingMinifigID))
+	if err != nil {
+		return fmt.Errorf("failed to delete missing minifig: %w", err)
+	}
+
+	return nil
+}
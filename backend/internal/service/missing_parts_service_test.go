@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/BombartSimon/MissingBrick/internal/database"
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newTestDB opens a migrated in-memory database private to the test
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.NewDatabase(fmt.Sprintf("file:%s?mode=memory&cache=shared", url.PathEscape(t.Name())))
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		t.Fatalf("failed to get test database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db.DB
+}
+
+// newTestMissingPartsService creates a missing parts service on db, with a set 1 owning one copy
+// and four red 3001 bricks
+func newTestMissingPartsService(t *testing.T, db *gorm.DB) MissingPartsService {
+	t.Helper()
+
+	rows := []interface{}{
+		&entity.Set{ID: 1, SetNum: "1-1", Name: "Castle"},
+		&entity.SetCopy{ID: 1, SetID: 1, Label: "Copy 1"},
+		&entity.Color{ID: 4, Name: "Red"},
+		&entity.Part{ID: 1, PartNum: "3001", Name: "Brick 2 x 4"},
+		&entity.SetPart{ID: 1, SetID: 1, PartID: 1, ColorID: 4, Quantity: 4},
+	}
+	for _, row := range rows {
+		if err := db.Omit(clause.Associations).Create(row).Error; err != nil {
+			t.Fatalf("failed to create %T: %v", row, err)
+		}
+	}
+
+	transactor := repository.NewTransactor(db)
+	setRepo := repository.NewSetRepository(db)
+	setCopyService := NewSetCopyService(repository.NewSetCopyRepository(db), setRepo, repository.NewStorageLocationRepository(db), transactor)
+	return NewMissingPartsService(repository.NewMissingPartRepository(db), repository.NewMissingMinifigRepository(db), repository.NewSetPartRepository(db),
+		repository.NewMinifigRepository(db), setCopyService, nil, transactor)
+}
+
+func TestReopenAfterReassigningFoundPart(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name         string
+		reassign     int
+		reopen       *int
+		wantQuantity int
+		wantErr      error
+	}{
+		{name: "everything reassigned", reassign: 4, reopen: intPtr(4), wantErr: ErrInvalidMissingQuantity},
+		{name: "everything reassigned, reopen all", reassign: 4, wantErr: ErrInvalidMissingQuantity},
+		{name: "part reassigned", reassign: 1, reopen: intPtr(3), wantQuantity: 4},
+		{name: "part reassigned, reopen all", reassign: 1, wantQuantity: 4},
+		{name: "part reassigned, reopen too many", reassign: 1, reopen: intPtr(4), wantErr: ErrInvalidMissingQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestMissingPartsService(t, newTestDB(t))
+
+			assigned, err := service.AssignMissingPartsToSet(1, 1, []MissingPartRequest{{SetPartID: 1, Quantity: intPtr(4)}}, "")
+			if err != nil {
+				t.Fatalf("failed to assign missing part: %v", err)
+			}
+			missingPartID := int(assigned[0].ID)
+			if _, err := service.MarkPartAsFound(missingPartID, nil, ""); err != nil {
+				t.Fatalf("failed to mark part as found: %v", err)
+			}
+			reassigned, err := service.AssignMissingPartsToSet(1, 1, []MissingPartRequest{{SetPartID: 1, Quantity: intPtr(tt.reassign)}}, "")
+			if err != nil {
+				t.Fatalf("failed to reassign missing part: %v", err)
+			}
+			if int(reassigned[0].ID) != missingPartID || reassigned[0].Quantity != tt.reassign {
+				t.Fatalf("reassigned missing part %d with quantity %d, want %d with quantity %d", reassigned[0].ID, reassigned[0].Quantity, missingPartID, tt.reassign)
+			}
+
+			reopened, err := service.ReopenMissingPart(missingPartID, tt.reopen, "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to reopen missing part: %v", err)
+			}
+			if reopened.Quantity != tt.wantQuantity {
+				t.Errorf("quantity = %d, want %d", reopened.Quantity, tt.wantQuantity)
+			}
+			if recovered := recoveredQuantity(reopened); recovered != 0 {
+				t.Errorf("recovered quantity = %d, want 0", recovered)
+			}
+		})
+	}
+}
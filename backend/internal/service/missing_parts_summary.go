@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// MissingPartsSummaryFilter restricts the missing parts aggregated in a summary. Zero values are ignored.
+type MissingPartsSummaryFilter struct {
+	ThemeID   int
+	SetIDs    []uint
+	PartCatID int
+}
+
+// MissingPartsSummary is a collection-wide shopping list of missing parts and whole missing minifigs
+type MissingPartsSummary struct {
+	Items         []MissingPartsSummaryItem    `json:"items"`
+	Minifigs      []MissingMinifigsSummaryItem `json:"minifigs"`
+	TotalLots     int                          `json:"total_lots"`
+	TotalQuantity int                          `json:"total_quantity"`
+}
+
+// MissingPartsSummaryItem is the total missing quantity of a part in a given color
+type MissingPartsSummaryItem struct {
+	Part     entity.Part              `json:"part"`
+	Color    entity.Color             `json:"color"`
+	Quantity int                      `json:"quantity"`
+	Sets     []MissingPartsSummarySet `json:"sets"`
+}
+
+// MissingMinifigsSummaryItem is the total missing quantity of a whole minifig
+type MissingMinifigsSummaryItem struct {
+	Minifig  entity.Minifig           `json:"minifig"`
+	Quantity int                      `json:"quantity"`
+	Sets     []MissingPartsSummarySet `json:"sets"`
+}
+
+// MissingPartsSummarySet is the quantity of a summary item needed by one set
+type MissingPartsSummarySet struct {
+	SetID    uint   `json:"set_id"`
+	SetNum   string `json:"set_num"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// GetMissingPartsSummary aggregates the missing quantities of every set by part and color, and by minifig.
+// Minifigs have no part category, so they are left out when filtering on one.
+func (s *missingPartsService) GetMissingPartsSummary(filter MissingPartsSummaryFilter) (*MissingPartsSummary, error) {
+	repoFilter := repository.MissingPartFilter{SetIDs: filter.SetIDs}
+	if filter.PartCatID != 0 {
+		repoFilter.PartCatIDs = []int{filter.PartCatID}
+	}
+	if filter.ThemeID != 0 {
+		themeIDs, err := s.themeService.GetThemeAndSubThemeIDs(filter.ThemeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve sub-themes of theme %d: %w", filter.ThemeID, err)
+		}
+		repoFilter.ThemeIDs = themeIDs
+	}
+
+	missingParts, err := s.missingPartsRepo.GetAllMissing(repoFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get missing parts: %w", err)
+	}
+
+	summary := summarizeMissingParts(missingParts)
+	if filter.PartCatID != 0 {
+		return summary, nil
+	}
+
+	missingMinifigs, err := s.missingMinifigRepo.GetAllMissing(repoFilter.SetIDs, repoFilter.ThemeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get missing minifigs: %w", err)
+	}
+	summarizeMissingMinifigs(summary, missingMinifigs)
+
+	return summary, nil
+}
+
+// summarizeMissingParts groups missing parts by part and color, keeping the order of the input
+func summarizeMissingParts(missingParts []entity.MissingPart) *MissingPartsSummary {
+	type lotKey struct {
+		partID  uint
+		colorID int
+	}
+
+	summary := &MissingPartsSummary{Items: []MissingPartsSummaryItem{}, Minifigs: []MissingMinifigsSummaryItem{}}
+	itemIndex := make(map[lotKey]int)
+	setIndex := make(map[lotKey]map[uint]int)
+
+	for _, missingPart := range missingParts {
+		key := lotKey{partID: missingPart.PartID, colorID: missingPart.ColorID}
+		i, ok := itemIndex[key]
+		if !ok {
+			i = len(summary.Items)
+			itemIndex[key] = i
+			setIndex[key] = make(map[uint]int)
+			summary.Items = append(summary.Items, MissingPartsSummaryItem{
+				Part:  missingPart.Part,
+				Color: missingPart.Color,
+			})
+		}
+
+		item := &summary.Items[i]
+		item.Quantity += missingPart.Quantity
+
+		j, ok := setIndex[key][missingPart.SetID]
+		if !ok {
+			j = len(item.Sets)
+			setIndex[key][missingPart.SetID] = j
+			item.Sets = append(item.Sets, MissingPartsSummarySet{
+				SetID:  missingPart.SetID,
+				SetNum: missingPart.Set.SetNum,
+				Name:   missingPart.Set.Name,
+			})
+		}
+		item.Sets[j].Quantity += missingPart.Quantity
+
+		summary.TotalQuantity += missingPart.Quantity
+	}
+
+	summary.TotalLots = len(summary.Items)
+	return summary
+}
+
+// summarizeMissingMinifigs adds missing minifigs to summary grouped by minifig, keeping the order of the input
+func summarizeMissingMinifigs(summary *MissingPartsSummary, missingMinifigs []entity.MissingMinifig) {
+	itemIndex := make(map[uint]int)
+	setIndex := make(map[uint]map[uint]int)
+
+	for _, missingMinifig := range missingMinifigs {
+		i, ok := itemIndex[missingMinifig.MinifigID]
+		if !ok {
+			i = len(summary.Minifigs)
+			itemIndex[missingMinifig.MinifigID] = i
+			setIndex[missingMinifig.MinifigID] = make(map[uint]int)
+			summary.Minifigs = append(summary.Minifigs, MissingMinifigsSummaryItem{Minifig: missingMinifig.Minifig})
+		}
+
+		item := &summary.Minifigs[i]
+		item.Quantity += missingMinifig.Quantity
+
+		j, ok := setIndex[missingMinifig.MinifigID][missingMinifig.SetID]
+		if !ok {
+			j = len(item.Sets)
+			setIndex[missingMinifig.MinifigID][missingMinifig.SetID] = j
+			item.Sets = append(item.Sets, MissingPartsSummarySet{
+				SetID:  missingMinifig.SetID,
+				SetNum: missingMinifig.Set.SetNum,
+				Name:   missingMinifig.Set.Name,
+			})
+		}
+		item.Sets[j].Quantity += missingMinifig.Quantity
+
+		summary.TotalQuantity += missingMinifig.Quantity
+	}
+
+	summary.TotalLots += len(summary.Minifigs)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// partResolver makes sure the parts and colors referenced by Rebrickable inventory lines exist locally
+type partResolver struct {
+	partRepo  repository.PartRepository
+	colorRepo repository.ColorRepository
+}
+
+// resolve upserts the colors of the given inventory lines and returns their parts by part number,
+// creating the parts that are not stored yet
+func (r partResolver) resolve(rbParts []RebrickableSetPart) (map[string]*entity.Part, error) {
+	// Make sure every color used by the inventory exists
+	colorsByID := make(map[int]entity.Color)
+	for _, rbPart := range rbParts {
+		colorsByID[rbPart.Color.ID] = colorFromRebrickable(rbPart.Color)
+	}
+	colors := make([]entity.Color, 0, len(colorsByID))
+	for _, color := range colorsByID {
+		colors = append(colors, color)
+	}
+	if err := r.colorRepo.UpsertBatch(colors); err != nil {
+		return nil, fmt.Errorf("failed to save colors: %w", err)
+	}
+
+	partsByNum := make(map[string]*entity.Part)
+	for _, rbPart := range rbParts {
+		if _, ok := partsByNum[rbPart.Part.PartNum]; ok {
+			continue
+		}
+
+		// Check if part exists in our database
+		part, err := r.partRepo.GetByPartNum(rbPart.Part.PartNum)
+		if err != nil {
+			// Part doesn't exist, create it
+			part = partFromRebrickable(rbPart.Part)
+
+			err = r.partRepo.Create(part)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create part %s: %w", rbPart.Part.PartNum, err)
+			}
+		}
+
+		partsByNum[rbPart.Part.PartNum] = part
+	}
+
+	return partsByNum, nil
+}
+
+// partFromRebrickable converts a Rebrickable part to a Part entity
+func partFromRebrickable(rbPart RebrickablePart) *entity.Part {
+	externalIDsJSON, _ := json.Marshal(rbPart.ExternalIDs)
+	return &entity.Part{
+		PartNum:      rbPart.PartNum,
+		Name:         rbPart.Name,
+		PartCatID:    rbPart.PartCatID,
+		PartImageURL: rbPart.PartImageURL,
+		PartURL:      rbPart.PartURL,
+		ExternalIDs:  string(externalIDsJSON),
+		PrintOf:      rbPart.PrintOf,
+	}
+}
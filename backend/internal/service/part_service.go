@@ -1,21 +1,65 @@
 package service
 
 import (
+	"fmt"
+
 	"github.com/BombartSimon/MissingBrick/internal/entity"
 	"github.com/BombartSimon/MissingBrick/internal/repository"
 )
 
+// Page sizes used when listing parts
+const (
+	DefaultPartPageSize = 50
+	MaxPartPageSize     = 500
+)
+
 type PartService interface {
 	CreatePart(partNum string) (*entity.Part, error)
+	GetParts(offset, limit int) (*PartPage, error)
+	GetPartByID(id uint) (*PartWithSets, error)
+	SearchParts(query string, offset, limit int) (*PartPage, error)
+}
+
+// PartPage is a page of parts with the total number of matching parts
+type PartPage struct {
+	Parts  []PartWithSets `json:"parts"`
+	Total  int64          `json:"total"`
+	Offset int            `json:"offset"`
+	Limit  int            `json:"limit"`
+}
+
+// PartWithSets is a part with the sets that contain it and the colors it comes in
+type PartWithSets struct {
+	entity.Part
+	Colors []PartColorUsage `json:"colors"`
+	Sets   []PartSetUsage   `json:"sets"`
+}
+
+// PartSetUsage is the quantity of a part contained in one set, by color
+type PartSetUsage struct {
+	SetID    uint             `json:"set_id"`
+	SetNum   string           `json:"set_num"`
+	Name     string           `json:"name"`
+	Quantity int              `json:"quantity"`
+	Colors   []PartColorUsage `json:"colors"`
+}
+
+// PartColorUsage is the quantity of a part in one color
+type PartColorUsage struct {
+	ColorID  int    `json:"color_id"`
+	Name     string `json:"name"`
+	RGB      string `json:"rgb"`
+	Quantity int    `json:"quantity"`
 }
 
 type partService struct {
-	repo repository.PartRepository
+	repo       repository.PartRepository
+	searchRepo repository.SearchRepository
 }
 
 // NewPartService creates a new PartService
-func NewPartService(repo repository.PartRepository) PartService {
-	return &partService{repo: repo}
+func NewPartService(repo repository.PartRepository, searchRepo repository.SearchRepository) PartService {
+	return &partService{repo: repo, searchRepo: searchRepo}
 }
 
 // CreatePart creates a new part with the given part number
@@ -31,3 +75,126 @@ func (s *partService) CreatePart(partNum string) (*entity.Part, error) {
 
 	return part, nil
 }
+
+// GetParts retrieves a page of parts ordered by part number
+func (s *partService) GetParts(offset, limit int) (*PartPage, error) {
+	offset, limit = normalizePartPage(offset, limit)
+
+	parts, total, err := s.repo.GetPage(offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parts: %w", err)
+	}
+
+	return s.newPartPage(parts, total, offset, limit)
+}
+
+// GetPartByID retrieves a part with the sets that contain it
+func (s *partService) GetPartByID(id uint) (*PartWithSets, error) {
+	part, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := s.withSets([]entity.Part{*part})
+	if err != nil {
+		return nil, err
+	}
+	return &parts[0], nil
+}
+
+// SearchParts searches parts by name or part number, best matches first
+func (s *partService) SearchParts(query string, offset, limit int) (*PartPage, error) {
+	offset, limit = normalizePartPage(offset, limit)
+
+	hits, total, err := s.searchRepo.Search(query, []string{repository.SearchKindPart}, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search parts: %w", err)
+	}
+
+	partIDs := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		partIDs = append(partIDs, hit.RefID)
+	}
+
+	parts, err := s.repo.GetByIDs(partIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parts: %w", err)
+	}
+
+	return s.newPartPage(parts, total, offset, limit)
+}
+
+// newPartPage builds a page of parts with their sets
+func (s *partService) newPartPage(parts []entity.Part, total int64, offset, limit int) (*PartPage, error) {
+	partsWithSets, err := s.withSets(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PartPage{Parts: partsWithSets, Total: total, Offset: offset, Limit: limit}, nil
+}
+
+// withSets attaches to each part the sets and colors it is found in
+func (s *partService) withSets(parts []entity.Part) ([]PartWithSets, error) {
+	partIDs := make([]uint, 0, len(parts))
+	for _, part := range parts {
+		partIDs = append(partIDs, part.ID)
+	}
+
+	usages, err := s.repo.GetUsages(partIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sets containing parts: %w", err)
+	}
+
+	usagesByPart := make(map[uint][]repository.PartUsage)
+	for _, usage := range usages {
+		usagesByPart[usage.PartID] = append(usagesByPart[usage.PartID], usage)
+	}
+
+	result := make([]PartWithSets, 0, len(parts))
+	for _, part := range parts {
+		result = append(result, newPartWithSets(part, usagesByPart[part.ID]))
+	}
+	return result, nil
+}
+
+// newPartWithSets groups the usages of a part, ordered by set number then color, by set and by color
+func newPartWithSets(part entity.Part, usages []repository.PartUsage) PartWithSets {
+	partWithSets := PartWithSets{Part: part, Colors: []PartColorUsage{}, Sets: []PartSetUsage{}}
+	colorIndex := make(map[int]int)
+
+	for _, usage := range usages {
+		colorUsage := PartColorUsage{ColorID: usage.ColorID, Name: usage.ColorName, RGB: usage.ColorRGB, Quantity: usage.Quantity}
+
+		last := len(partWithSets.Sets) - 1
+		if last < 0 || partWithSets.Sets[last].SetID != usage.SetID {
+			partWithSets.Sets = append(partWithSets.Sets, PartSetUsage{SetID: usage.SetID, SetNum: usage.SetNum, Name: usage.SetName})
+			last++
+		}
+		partWithSets.Sets[last].Quantity += usage.Quantity
+		partWithSets.Sets[last].Colors = append(partWithSets.Sets[last].Colors, colorUsage)
+
+		if i, ok := colorIndex[usage.ColorID]; ok {
+			partWithSets.Colors[i].Quantity += usage.Quantity
+		} else {
+			colorIndex[usage.ColorID] = len(partWithSets.Colors)
+			partWithSets.Colors = append(partWithSets.Colors, colorUsage)
+		}
+	}
+
+	return partWithSets
+}
+
+// normalizePartPage applies the default and maximum page sizes
+func normalizePartPage(offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = DefaultPartPageSize
+	}
+	if limit > MaxPartPageSize {
+		limit = MaxPartPageSize
+	}
+	return offset, limit
+}
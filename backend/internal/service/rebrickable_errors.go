@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors returned by the Rebrickable client. Use errors.Is to test for them.
+var (
+	ErrRebrickableNotFound     = errors.New("rebrickable resource not found")
+	ErrRebrickableUnauthorized = errors.New("rebrickable API key is missing or invalid")
+	ErrRebrickableThrottled    = errors.New("rebrickable API rate limit exceeded")
+)
+
+// RebrickableError describes a failed Rebrickable API call
+type RebrickableError struct {
+	StatusCode int
+	URL        string
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error implements the error interface
+func (e *RebrickableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("rebrickable API returned status %d: %v", e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("rebrickable API returned status %d", e.StatusCode)
+}
+
+// Unwrap exposes the sentinel error so callers can use errors.Is
+func (e *RebrickableError) Unwrap() error {
+	return e.Err
+}
+
+// newRebrickableError builds a RebrickableError from an unsuccessful response
+func newRebrickableError(resp *http.Response) *RebrickableError {
+	rbErr := &RebrickableError{
+		StatusCode: resp.StatusCode,
+		URL:        resp.Request.URL.Redacted(),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		rbErr.Err = ErrRebrickableNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		rbErr.Err = ErrRebrickableUnauthorized
+	case http.StatusTooManyRequests:
+		rbErr.Err = ErrRebrickableThrottled
+	}
+
+	return rbErr
+}
+
+// isRetryableStatus reports whether a request that failed with the given status should be retried
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
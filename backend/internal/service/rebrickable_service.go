@@ -2,30 +2,68 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rebrickableBaseURL     = "https://rebrickable.com/api/v3"
+	rebrickablePageSize    = 1000
+	rebrickableBaseBackoff = time.Second
+	rebrickableMaxBackoff  = 30 * time.Second
 )
 
 // RebrickableService handles interactions with Rebrickable API
 type RebrickableService interface {
 	GetSet(setNum string) (*RebrickableSet, error)
 	GetSetParts(setNum string) ([]RebrickableSetPart, error)
+	GetSetMinifigs(setNum string) ([]RebrickableSetMinifig, error)
+	GetMinifigParts(figNum string) ([]RebrickableSetPart, error)
 	GetPart(partNum string) (*RebrickablePart, error)
+	GetColors() ([]RebrickableColor, error)
+	GetThemes() ([]RebrickableTheme, error)
+	GetTheme(id int) (*RebrickableTheme, error)
 }
 
 // rebrickableService implements RebrickableService interface
 type rebrickableService struct {
-	apiKey  string
-	baseURL string
-	client  *http.Client
+	apiKey     string
+	baseURL    string
+	client     *http.Client
+	limiter    *rateLimiter
+	maxRetries int
+}
+
+// RebrickableClient gives access to both the LEGO catalog and the user collections of the Rebrickable API
+type RebrickableClient interface {
+	RebrickableService
+	RebrickableUserService
+}
+
+// NewRebrickableService creates a new Rebrickable service.
+// requestsPerSecond caps the request rate and maxRetries bounds retries of throttled or failed calls.
+func NewRebrickableService(apiKey string, requestsPerSecond float64, maxRetries int) RebrickableService {
+	return NewRebrickableClient(apiKey, requestsPerSecond, maxRetries)
 }
 
-// NewRebrickableService creates a new Rebrickable service
-func NewRebrickableService(apiKey string) RebrickableService {
+// NewRebrickableClient creates a new Rebrickable API client, rate limited like NewRebrickableService
+func NewRebrickableClient(apiKey string, requestsPerSecond float64, maxRetries int) RebrickableClient {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
 	return &rebrickableService{
-		apiKey:  apiKey,
-		baseURL: "https://rebrickable.com/api/v3",
-		client:  &http.Client{},
+		apiKey:     apiKey,
+		baseURL:    rebrickableBaseURL,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		limiter:    newRateLimiter(requestsPerSecond),
+		maxRetries: maxRetries,
 	}
 }
 
@@ -63,78 +101,273 @@ type RebrickableSetPart struct {
 	NumSets   int              `json:"num_sets"`
 }
 
+// RebrickableSetMinifig represents a minifig in a set from Rebrickable API
+type RebrickableSetMinifig struct {
+	ID       int    `json:"id"`
+	FigNum   string `json:"set_num"`
+	Name     string `json:"set_name"`
+	Quantity int    `json:"quantity"`
+	ImageURL string `json:"set_img_url"`
+}
+
 // RebrickableColor represents a color from Rebrickable API
 type RebrickableColor struct {
-	ID      int    `json:"id"`
-	Name    string `json:"name"`
-	RGB     string `json:"rgb"`
-	IsTrans bool   `json:"is_trans"`
+	ID          int                                   `json:"id"`
+	Name        string                                `json:"name"`
+	RGB         string                                `json:"rgb"`
+	IsTrans     bool                                  `json:"is_trans"`
+	ExternalIDs map[string]RebrickableColorExternalID `json:"external_ids,omitempty"`
+}
+
+// RebrickableColorExternalID lists the IDs and names of a color in another system (BrickLink, LDraw, LEGO...)
+type RebrickableColorExternalID struct {
+	ExtIDs    []int      `json:"ext_ids"`
+	ExtDescrs [][]string `json:"ext_descrs"`
+}
+
+// RebrickableTheme represents a theme from Rebrickable API
+type RebrickableTheme struct {
+	ID       int    `json:"id"`
+	ParentID *int   `json:"parent_id"`
+	Name     string `json:"name"`
 }
 
 // GetSet retrieves a set from Rebrickable API
 func (s *rebrickableService) GetSet(setNum string) (*RebrickableSet, error) {
-	url := fmt.Sprintf("%s/lego/sets/%s/?key=%s", s.baseURL, setNum, s.apiKey)
+	var set RebrickableSet
+	if err := s.getJSON(fmt.Sprintf("%s/lego/sets/%s/", s.baseURL, url.PathEscape(setNum)), &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch set %s: %w", setNum, err)
+	}
 
-	resp, err := s.client.Get(url)
+	return &set, nil
+}
+
+// GetSetParts retrieves parts for a set from Rebrickable API, following pagination.
+// Minifig parts are not included; see GetSetMinifigs and GetMinifigParts.
+func (s *rebrickableService) GetSetParts(setNum string) ([]RebrickableSetPart, error) {
+	pageURL := fmt.Sprintf("%s/lego/sets/%s/parts/?page_size=%d", s.baseURL, url.PathEscape(setNum), rebrickablePageSize)
+
+	parts, err := getAllPages[RebrickableSetPart](s, pageURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch set from Rebrickable: %w", err)
+		return nil, fmt.Errorf("failed to fetch parts of set %s: %w", setNum, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("rebrickable API returned status %d", resp.StatusCode)
+	return parts, nil
+}
+
+// GetSetMinifigs retrieves the minifigs of a set from Rebrickable API
+func (s *rebrickableService) GetSetMinifigs(setNum string) ([]RebrickableSetMinifig, error) {
+	pageURL := fmt.Sprintf("%s/lego/sets/%s/minifigs/?page_size=%d", s.baseURL, url.PathEscape(setNum), rebrickablePageSize)
+
+	minifigs, err := getAllPages[RebrickableSetMinifig](s, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch minifigs of set %s: %w", setNum, err)
 	}
 
-	var set RebrickableSet
-	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
-		return nil, fmt.Errorf("failed to decode set response: %w", err)
+	return minifigs, nil
+}
+
+// GetMinifigParts retrieves the parts of a minifig from Rebrickable API
+func (s *rebrickableService) GetMinifigParts(figNum string) ([]RebrickableSetPart, error) {
+	pageURL := fmt.Sprintf("%s/lego/minifigs/%s/parts/?page_size=%d", s.baseURL, url.PathEscape(figNum), rebrickablePageSize)
+
+	parts, err := getAllPages[RebrickableSetPart](s, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch parts of minifig %s: %w", figNum, err)
 	}
 
-	return &set, nil
+	return parts, nil
 }
 
-// GetSetParts retrieves parts for a set from Rebrickable API
-func (s *rebrickableService) GetSetParts(setNum string) ([]RebrickableSetPart, error) {
-	url := fmt.Sprintf("%s/lego/sets/%s/parts/?page=1&page_size=100000&inc_minifig_parts=1&key=%s", s.baseURL, setNum, s.apiKey)
+// GetPart retrieves a part from Rebrickable API
+func (s *rebrickableService) GetPart(partNum string) (*RebrickablePart, error) {
+	var part RebrickablePart
+	if err := s.getJSON(fmt.Sprintf("%s/lego/parts/%s/", s.baseURL, url.PathEscape(partNum)), &part); err != nil {
+		return nil, fmt.Errorf("failed to fetch part %s: %w", partNum, err)
+	}
+
+	return &part, nil
+}
 
-	resp, err := s.client.Get(url)
+// GetColors retrieves every color from Rebrickable API
+func (s *rebrickableService) GetColors() ([]RebrickableColor, error) {
+	colors, err := getAllPages[RebrickableColor](s, fmt.Sprintf("%s/lego/colors/?page_size=%d", s.baseURL, rebrickablePageSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch set parts from Rebrickable: %w", err)
+		return nil, fmt.Errorf("failed to fetch colors: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("rebrickable API returned status %d", resp.StatusCode)
+	return colors, nil
+}
+
+// GetThemes retrieves every theme from Rebrickable API
+func (s *rebrickableService) GetThemes() ([]RebrickableTheme, error) {
+	themes, err := getAllPages[RebrickableTheme](s, fmt.Sprintf("%s/lego/themes/?page_size=%d", s.baseURL, rebrickablePageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch themes: %w", err)
+	}
+
+	return themes, nil
+}
+
+// GetTheme retrieves a theme from Rebrickable API
+func (s *rebrickableService) GetTheme(id int) (*RebrickableTheme, error) {
+	var theme RebrickableTheme
+	if err := s.getJSON(fmt.Sprintf("%s/lego/themes/%d/", s.baseURL, id), &theme); err != nil {
+		return nil, fmt.Errorf("failed to fetch theme %d: %w", id, err)
 	}
 
-	var response struct {
-		Results []RebrickableSetPart `json:"results"`
+	return &theme, nil
+}
+
+// rebrickablePage is the envelope of every paginated Rebrickable response
+type rebrickablePage[T any] struct {
+	Count   int     `json:"count"`
+	Next    *string `json:"next"`
+	Results []T     `json:"results"`
+}
+
+// getAllPages fetches every page of a paginated endpoint by following the next cursor
+func getAllPages[T any](s *rebrickableService, pageURL string) ([]T, error) {
+	var results []T
+
+	for pageURL != "" {
+		var page rebrickablePage[T]
+		if err := s.getJSON(pageURL, &page); err != nil {
+			return nil, err
+		}
+
+		if results == nil {
+			results = make([]T, 0, page.Count)
+		}
+		results = append(results, page.Results...)
+
+		pageURL = ""
+		if page.Next != nil {
+			pageURL = *page.Next
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode set parts response: %w", err)
+
+	return results, nil
+}
+
+// getJSON performs a rate limited GET request and decodes the JSON body into out.
+// Throttled and server errors are retried with exponential backoff, honoring Retry-After.
+func (s *rebrickableService) getJSON(requestURL string, out interface{}) error {
+	return s.sendJSON(http.MethodGet, requestURL, nil, out)
+}
+
+// sendJSON performs a rate limited request, with form as the url-encoded body when it is not nil,
+// and decodes the JSON body into out when out is not nil. Failed requests are retried like in getJSON.
+func (s *rebrickableService) sendJSON(method, requestURL string, form url.Values, out interface{}) error {
+	var lastErr error
+
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(s.backoff(attempt, lastErr))
+		}
+
+		retry, err := s.doRequest(method, requestURL, form, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		// A Retry-After longer than the longest backoff is left to the caller rather than waited for
+		if !retry || s.backoff(attempt+1, err) > rebrickableMaxBackoff {
+			return err
+		}
 	}
 
-	return response.Results, nil
+	return lastErr
 }
 
-// GetPart retrieves a part from Rebrickable API
-func (s *rebrickableService) GetPart(partNum string) (*RebrickablePart, error) {
-	url := fmt.Sprintf("%s/lego/parts/%s/?key=%s", s.baseURL, partNum, s.apiKey)
+// doRequest performs a single request. It reports whether a failed request may be retried: only
+// throttled requests are retried when they change data, as a server error may hide a change that was applied.
+func (s *rebrickableService) doRequest(method, requestURL string, form url.Values, out interface{}) (bool, error) {
+	s.limiter.wait()
 
-	resp, err := s.client.Get(url)
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, requestURL, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch part from Rebrickable: %w", err)
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "key "+s.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return method == http.MethodGet, fmt.Errorf("request to Rebrickable failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Rebrickable API returned status %d", resp.StatusCode)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		retry := resp.StatusCode == http.StatusTooManyRequests || (method == http.MethodGet && isRetryableStatus(resp.StatusCode))
+		return retry, newRebrickableError(resp)
 	}
 
-	var part RebrickablePart
-	if err := json.NewDecoder(resp.Body).Decode(&part); err != nil {
-		return nil, fmt.Errorf("failed to decode part response: %w", err)
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to decode Rebrickable response: %w", err)
 	}
 
-	return &part, nil
+	return false, nil
+}
+
+// backoff returns how long to wait before the given retry attempt: the Retry-After of a throttled
+// request, or an exponential delay capped at rebrickableMaxBackoff
+func (s *rebrickableService) backoff(attempt int, lastErr error) time.Duration {
+	var rbErr *RebrickableError
+	if errors.As(lastErr, &rbErr) && rbErr.RetryAfter > 0 {
+		return rbErr.RetryAfter
+	}
+
+	wait := rebrickableBaseBackoff << (attempt - 1)
+	if wait > rebrickableMaxBackoff {
+		wait = rebrickableMaxBackoff
+	}
+	return wait
+}
+
+// rateLimiter spaces out requests so that at most one starts per interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter creates a limiter allowing requestsPerSecond requests per second.
+// A non-positive rate disables limiting.
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	limiter := &rateLimiter{}
+	if requestsPerSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return limiter
+}
+
+// wait blocks until the caller is allowed to issue a request
+func (l *rateLimiter) wait() {
+	if l.interval == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
 }
@@ -0,0 +1,974 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrRebrickableNotLinked is returned when a sync needs a Rebrickable account and none is linked
+var ErrRebrickableNotLinked = errors.New("no rebrickable account is linked")
+
+// ErrInvalidRebrickableSync is returned for an invalid account link or conflict resolution
+var ErrInvalidRebrickableSync = errors.New("invalid rebrickable sync request")
+
+// Sides of a lost part conflict that can be kept
+const (
+	ConflictKeepLocal  = "local"
+	ConflictKeepRemote = "remote"
+)
+
+// rebrickableSyncNotes is recorded in the history of missing parts changed by a sync
+const rebrickableSyncNotes = "Synced from Rebrickable"
+
+// RebrickableSyncService synchronizes the collection with the account of a Rebrickable user
+type RebrickableSyncService interface {
+	GetAccount() (*entity.RebrickableAccount, error)
+	LinkAccount(req RebrickableAccountRequest) (*entity.RebrickableAccount, error)
+	UnlinkAccount() error
+	Sync() (*RebrickableSyncReport, error)
+	GetConflicts() ([]entity.RebrickableLostPart, error)
+	ResolveConflict(id uint, keep string) (*entity.RebrickableLostPart, error)
+}
+
+// RebrickableAccountRequest links a Rebrickable account, either with a user token or with the
+// credentials of the user
+type RebrickableAccountRequest struct {
+	UserToken string `json:"user_token"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+}
+
+// RebrickableSyncReport describes what a sync changed on each side
+type RebrickableSyncReport struct {
+	Sets      RebrickableSetsSyncReport      `json:"sets"`
+	PartLists RebrickablePartListsSyncReport `json:"part_lists"`
+	LostParts RebrickableLostPartsSyncReport `json:"lost_parts"`
+	Errors    []string                       `json:"errors"`
+}
+
+// RebrickableSetsSyncReport counts the sets queued for import and the copies created from the set lists of the user
+type RebrickableSetsSyncReport struct {
+	Queued      []RebrickableSyncQueuedSet `json:"queued"`
+	CopiesAdded int                        `json:"copies_added"`
+	UpToDate    int                        `json:"up_to_date"`
+}
+
+// RebrickableSyncQueuedSet is a set of the set lists of the user whose import job was queued
+type RebrickableSyncQueuedSet struct {
+	SetNum string `json:"set_num"`
+	JobID  uint   `json:"job_id"`
+}
+
+// RebrickablePartListsSyncReport counts the pieces of the part lists added to the loose parts
+type RebrickablePartListsSyncReport struct {
+	Lists       int `json:"lists"`
+	PiecesAdded int `json:"pieces_added"`
+}
+
+// RebrickableLostPartsSyncReport counts the lost parts merged with the missing parts
+type RebrickableLostPartsSyncReport struct {
+	Imported      int                          `json:"imported"`
+	Pushed        int                          `json:"pushed"`
+	UpdatedLocal  int                          `json:"updated_local"`
+	UpdatedRemote int                          `json:"updated_remote"`
+	Unchanged     int                          `json:"unchanged"`
+	Conflicts     []entity.RebrickableLostPart `json:"conflicts"`
+	Skipped       []RebrickableSyncSkipped     `json:"skipped"`
+}
+
+// RebrickableSyncSkipped is a lost part or missing part that could not be matched on the other side
+type RebrickableSyncSkipped struct {
+	SetNum  string `json:"set_num"`
+	PartNum string `json:"part_num"`
+	ColorID int    `json:"color_id"`
+	Reason  string `json:"reason"`
+}
+
+// Outcomes of the reconciliation of a lost part link
+const (
+	syncUnchanged     = "unchanged"
+	syncUpdatedLocal  = "local"
+	syncUpdatedRemote = "remote"
+	syncConflict      = "conflict"
+	syncSkipped       = "skipped"
+)
+
+// rebrickableSyncService implements RebrickableSyncService interface
+type rebrickableSyncService struct {
+	syncRepo          repository.RebrickableSyncRepository
+	setRepo           repository.SetRepository
+	setCopyRepo       repository.SetCopyRepository
+	setPartRepo       repository.SetPartRepository
+	missingPartsRepo  repository.MissingPartsRepository
+	setService        SetService
+	importJobService  ImportJobService
+	setCopyService    SetCopyService
+	loosePartService  LoosePartService
+	rebrickableClient RebrickableClient
+	transactor        repository.Transactor
+}
+
+// NewRebrickableSyncService creates a new Rebrickable sync service
+func NewRebrickableSyncService(syncRepo repository.RebrickableSyncRepository, setRepo repository.SetRepository, setCopyRepo repository.SetCopyRepository, setPartRepo repository.SetPartRepository, missingPartsRepo repository.MissingPartsRepository, setService SetService, importJobService ImportJobService, setCopyService SetCopyService, loosePartService LoosePartService, rebrickableClient RebrickableClient, transactor repository.Transactor) RebrickableSyncService {
+	return &rebrickableSyncService{
+		syncRepo:          syncRepo,
+		setRepo:           setRepo,
+		setCopyRepo:       setCopyRepo,
+		setPartRepo:       setPartRepo,
+		missingPartsRepo:  missingPartsRepo,
+		setService:        setService,
+		importJobService:  importJobService,
+		setCopyService:    setCopyService,
+		loosePartService:  loosePartService,
+		rebrickableClient: rebrickableClient,
+		transactor:        transactor,
+	}
+}
+
+// GetAccount retrieves the linked Rebrickable account
+func (s *rebrickableSyncService) GetAccount() (*entity.RebrickableAccount, error) {
+	account, err := s.syncRepo.GetAccount()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRebrickableNotLinked
+	}
+	return account, err
+}
+
+// LinkAccount links the Rebrickable account owning the token, or the credentials, of the request.
+// Linking another user forgets the state of the previous sync.
+func (s *rebrickableSyncService) LinkAccount(req RebrickableAccountRequest) (*entity.RebrickableAccount, error) {
+	token := req.UserToken
+	if token == "" {
+		if req.Username == "" || req.Password == "" {
+			return nil, fmt.Errorf("user_token, or username and password, are required: %w", ErrInvalidRebrickableSync)
+		}
+
+		var err error
+		if token, err = s.rebrickableClient.GetUserToken(req.Username, req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	profile, err := s.rebrickableClient.GetProfile(token)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.syncRepo.GetAccount()
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get rebrickable account: %w", err)
+	}
+	if account != nil && account.Username != profile.Username {
+		if err := s.syncRepo.DeleteAccount(); err != nil {
+			return nil, fmt.Errorf("failed to unlink rebrickable account: %w", err)
+		}
+		account = nil
+	}
+	if account == nil {
+		account = &entity.RebrickableAccount{}
+	}
+
+	account.Username = profile.Username
+	account.UserToken = token
+	if err := s.syncRepo.SaveAccount(account); err != nil {
+		return nil, fmt.Errorf("failed to save rebrickable account: %w", err)
+	}
+
+	return account, nil
+}
+
+// UnlinkAccount unlinks the Rebrickable account and forgets the state of its sync
+func (s *rebrickableSyncService) UnlinkAccount() error {
+	if _, err := s.GetAccount(); err != nil {
+		return err
+	}
+	return s.syncRepo.DeleteAccount()
+}
+
+// GetConflicts retrieves the lost parts changed on both sides since the last sync
+func (s *rebrickableSyncService) GetConflicts() ([]entity.RebrickableLostPart, error) {
+	return s.syncRepo.GetConflicts()
+}
+
+// Sync imports the set lists and part lists of the user, then merges their lost parts with the
+// missing parts. A lost part changed on one side only since the last sync is copied to the other
+// side; one changed on both sides is flagged as a conflict and left as is until resolved.
+func (s *rebrickableSyncService) Sync() (*RebrickableSyncReport, error) {
+	account, err := s.GetAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	run := s.newRun(account)
+	if err := run.syncSets(); err != nil {
+		return nil, err
+	}
+	if err := run.syncPartLists(); err != nil {
+		return nil, err
+	}
+	if err := run.syncLostParts(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account.LastSyncedAt = &now
+	if err := s.syncRepo.SaveAccount(account); err != nil {
+		return nil, fmt.Errorf("failed to save rebrickable account: %w", err)
+	}
+
+	return run.report, nil
+}
+
+// ResolveConflict settles a lost part conflict by keeping the local or the remote quantity, and
+// copies it to the other side
+func (s *rebrickableSyncService) ResolveConflict(id uint, keep string) (*entity.RebrickableLostPart, error) {
+	if keep != ConflictKeepLocal && keep != ConflictKeepRemote {
+		return nil, fmt.Errorf("keep must be %q or %q: %w", ConflictKeepLocal, ConflictKeepRemote, ErrInvalidRebrickableSync)
+	}
+
+	account, err := s.GetAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.syncRepo.GetLostPart(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rebrickable lost part %d: %w", id, err)
+	}
+	if !link.Conflict {
+		return nil, fmt.Errorf("rebrickable lost part %d is not in conflict: %w", id, ErrInvalidRebrickableSync)
+	}
+
+	run := s.newRun(account)
+	remote, err := run.remoteLostParts()
+	if err != nil {
+		return nil, err
+	}
+	lostPart, found := remote[link.LostPartID]
+
+	_, local, err := run.localParts(link)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteQuantity := 0
+	var remotePart *RebrickableLostPart
+	if found {
+		remoteQuantity = lostPart.LostQuantity
+		remotePart = &lostPart
+	}
+
+	// Agreeing on the quantity of the side to drop makes the side to keep the only one that changed
+	link.Conflict = false
+	link.SyncedQuantity = remoteQuantity
+	if keep == ConflictKeepRemote {
+		link.SyncedQuantity = local
+	}
+
+	if _, err := run.reconcile(link, remotePart); err != nil {
+		return nil, err
+	}
+	if err := run.assignLostPartIDs(); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// rebrickableSyncRun holds the state of a single sync
+type rebrickableSyncRun struct {
+	*rebrickableSyncService
+	token    string
+	report   *RebrickableSyncReport
+	setParts map[uint][]entity.SetPart
+	invParts map[string][]RebrickableSetPart
+	pending  []*entity.RebrickableLostPart
+}
+
+// newRun starts a sync with the account of the user
+func (s *rebrickableSyncService) newRun(account *entity.RebrickableAccount) *rebrickableSyncRun {
+	return &rebrickableSyncRun{
+		rebrickableSyncService: s,
+		token:                  account.UserToken,
+		report:                 &RebrickableSyncReport{Errors: []string{}},
+		setParts:               make(map[uint][]entity.SetPart),
+		invParts:               make(map[string][]RebrickableSetPart),
+	}
+}
+
+// syncSets queues the import of the sets of the set lists of the user that are not in the collection
+// yet, with as many copies as the user owns, and adds copies to the other sets until the collection
+// owns as many as the user
+func (r *rebrickableSyncRun) syncSets() error {
+	userSets, err := r.rebrickableClient.GetUserSets(r.token)
+	if err != nil {
+		return err
+	}
+
+	var setNums []string
+	quantities := make(map[string]int)
+	for _, userSet := range userSets {
+		if _, ok := quantities[userSet.Set.SetNum]; !ok {
+			setNums = append(setNums, userSet.Set.SetNum)
+		}
+		quantities[userSet.Set.SetNum] += userSet.Quantity
+	}
+
+	report := &r.report.Sets
+	report.Queued = []RebrickableSyncQueuedSet{}
+	for _, setNum := range setNums {
+		set, err := r.setRepo.GetBySetNum(setNum)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			job, err := r.importJobService.EnqueueSetImport(setNum, quantities[setNum])
+			if err != nil {
+				r.report.Errors = append(r.report.Errors, fmt.Sprintf("set %s: %v", setNum, err))
+				continue
+			}
+			report.Queued = append(report.Queued, RebrickableSyncQueuedSet{SetNum: setNum, JobID: job.ID})
+			continue
+		}
+		if err != nil {
+			r.report.Errors = append(r.report.Errors, fmt.Sprintf("set %s: %v", setNum, err))
+			continue
+		}
+
+		copies, err := r.setCopyRepo.GetBySetID(set.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get copies of set %s: %w", setNum, err)
+		}
+
+		added := 0
+		for owned := len(copies); owned < quantities[setNum]; owned++ {
+			if _, err := r.setCopyService.CreateCopy(set.ID, SetCopyRequest{}); err != nil {
+				r.report.Errors = append(r.report.Errors, fmt.Sprintf("set %s: %v", setNum, err))
+				break
+			}
+			added++
+		}
+		if added == 0 {
+			report.UpToDate++
+		}
+		report.CopiesAdded += added
+	}
+
+	return nil
+}
+
+// syncPartLists adds to the loose parts the pieces added to the part lists of the user since the
+// last sync
+func (r *rebrickableSyncRun) syncPartLists() error {
+	lists, err := r.rebrickableClient.GetPartLists(r.token)
+	if err != nil {
+		return err
+	}
+
+	for _, list := range lists {
+		parts, err := r.rebrickableClient.GetPartListParts(r.token, list.ID)
+		if err != nil {
+			return err
+		}
+		r.report.PartLists.Lists++
+
+		var keys []string
+		lines := make(map[string]*RebrickablePartListPart)
+		for i := range parts {
+			key := importKey(parts[i].Part.PartNum, strconv.Itoa(parts[i].Color.ID))
+			if line, ok := lines[key]; ok {
+				line.Quantity += parts[i].Quantity
+				continue
+			}
+			keys = append(keys, key)
+			lines[key] = &parts[i]
+		}
+
+		for _, key := range keys {
+			if err := r.syncPartListLine(list.ID, lines[key]); err != nil {
+				r.report.Errors = append(r.report.Errors, fmt.Sprintf("part list %q, part %s: %v", list.Name, lines[key].Part.PartNum, err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// syncPartListLine adds the pieces added to a part list line since the last sync
+func (r *rebrickableSyncRun) syncPartListLine(listID int, line *RebrickablePartListPart) error {
+	item, err := r.syncRepo.GetPartListItem(listID, line.Part.PartNum, line.Color.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		item = &entity.RebrickablePartListItem{ListID: listID, PartNum: line.Part.PartNum, ColorID: line.Color.ID}
+	} else if err != nil {
+		return err
+	}
+
+	if added := line.Quantity - item.Quantity; added > 0 {
+		colorID := line.Color.ID
+		if _, err := r.loosePartService.AddLoosePart(LoosePartRequest{PartNum: line.Part.PartNum, ColorID: &colorID, Quantity: added, Notes: rebrickableSyncNotes}); err != nil {
+			return err
+		}
+		r.report.PartLists.PiecesAdded += added
+	}
+
+	item.Quantity = line.Quantity
+	return r.syncRepo.SavePartListItem(item)
+}
+
+// syncLostParts merges the lost parts of the user with the missing parts of the collection
+func (r *rebrickableSyncRun) syncLostParts() error {
+	remote, err := r.remoteLostParts()
+	if err != nil {
+		return err
+	}
+
+	links, err := r.syncRepo.GetLostParts()
+	if err != nil {
+		return fmt.Errorf("failed to get rebrickable lost parts: %w", err)
+	}
+
+	report := &r.report.LostParts
+	report.Conflicts = []entity.RebrickableLostPart{}
+	report.Skipped = []RebrickableSyncSkipped{}
+
+	rematchLostParts(links, remote)
+
+	linked := make(map[uint]bool)
+	for i := range links {
+		link := &links[i]
+		linked[link.MissingPartID] = true
+
+		var lostPart *RebrickableLostPart
+		if remotePart, ok := remote[link.LostPartID]; ok && link.LostPartID != 0 {
+			lostPart = &remotePart
+			delete(remote, link.LostPartID)
+		}
+		if err := r.count(r.reconcile(link, lostPart)); err != nil {
+			return err
+		}
+		if err := r.markLinked(link, linked); err != nil {
+			return err
+		}
+	}
+
+	// Lost parts recorded on Rebrickable only
+	var lostPartIDs []int
+	for lostPartID := range remote {
+		lostPartIDs = append(lostPartIDs, lostPartID)
+	}
+	sort.Ints(lostPartIDs)
+	for _, lostPartID := range lostPartIDs {
+		lostPart := remote[lostPartID]
+		if err := r.importLostPart(&lostPart, linked); err != nil {
+			return err
+		}
+	}
+
+	// Missing parts recorded locally only. The copies of a set missing the same piece are pushed as a
+	// single lost part.
+	missingParts, err := r.missingPartsRepo.GetAllMissing(repository.MissingPartFilter{})
+	if err != nil {
+		return fmt.Errorf("failed to get missing parts: %w", err)
+	}
+	for i := range missingParts {
+		if linked[missingParts[i].ID] {
+			continue
+		}
+		if err := r.pushMissingPart(&missingParts[i], linked); err != nil {
+			return err
+		}
+	}
+
+	return r.assignLostPartIDs()
+}
+
+// count adds the outcome of a reconciliation to the report
+func (r *rebrickableSyncRun) count(outcome string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	switch outcome {
+	case syncUpdatedLocal:
+		r.report.LostParts.UpdatedLocal++
+	case syncUpdatedRemote:
+		r.report.LostParts.UpdatedRemote++
+	case syncUnchanged:
+		r.report.LostParts.Unchanged++
+	}
+	return nil
+}
+
+// rematchLostParts links the links whose lost part was never matched after being pushed to the
+// lost part of the same inventory line that no other link holds. A link still unmatched agrees on no
+// quantity, so that its pieces are pushed again rather than found.
+func rematchLostParts(links []entity.RebrickableLostPart, remote map[int]RebrickableLostPart) {
+	taken := make(map[int]bool)
+	for _, link := range links {
+		if _, ok := remote[link.LostPartID]; ok && link.LostPartID != 0 {
+			taken[link.LostPartID] = true
+		}
+	}
+
+	var lostPartIDs []int
+	for lostPartID := range remote {
+		lostPartIDs = append(lostPartIDs, lostPartID)
+	}
+	sort.Ints(lostPartIDs)
+
+	for i := range links {
+		link := &links[i]
+		if link.LostPartID != 0 {
+			continue
+		}
+
+		link.SyncedQuantity = 0
+		for _, lostPartID := range lostPartIDs {
+			if taken[lostPartID] || remote[lostPartID].InvPart.InvPartID != link.InvPartID {
+				continue
+			}
+			taken[lostPartID] = true
+			link.LostPartID = lostPartID
+			link.SyncedQuantity = remote[lostPartID].LostQuantity
+			break
+		}
+	}
+}
+
+// markLinked flags the missing parts behind a link as linked
+func (r *rebrickableSyncRun) markLinked(link *entity.RebrickableLostPart, linked map[uint]bool) error {
+	missingParts, _, err := r.localParts(link)
+	if err != nil {
+		return err
+	}
+	for _, missingPart := range missingParts {
+		linked[missingPart.ID] = true
+	}
+	return nil
+}
+
+// importLostPart links a lost part recorded on Rebrickable only to the matching missing part of the
+// collection, creating it on the oldest copy of the set when there is none
+func (r *rebrickableSyncRun) importLostPart(lostPart *RebrickableLostPart, linked map[uint]bool) error {
+	item := lostPart.InvPart
+	link := &entity.RebrickableLostPart{
+		LostPartID: lostPart.LostPartID,
+		InvPartID:  item.InvPartID,
+		SetNum:     item.SetNum,
+		PartNum:    item.Part.PartNum,
+		ColorID:    item.Color.ID,
+		IsSpare:    item.IsSpare,
+	}
+
+	set, setPart, reason, err := r.findSetPart(link)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		r.skip(link, reason)
+		return nil
+	}
+
+	// A missing part already recorded locally is linked with no agreed quantity, so that differing
+	// quantities are reported as a conflict
+	setCopy, err := r.setCopyService.ResolveCopy(set.ID, 0)
+	if err != nil {
+		return err
+	}
+	existing, err := r.missingPartsRepo.GetByKey(entity.MissingPart{SetID: set.ID, SetCopyID: setCopy.ID, PartID: setPart.PartID, ColorID: setPart.ColorID, IsSpare: setPart.IsSpare})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get missing part: %w", err)
+	}
+	if existing != nil && !linked[existing.ID] {
+		link.MissingPartID = existing.ID
+		linked[existing.ID] = true
+	}
+
+	outcome, err := r.reconcile(link, lostPart)
+	if err != nil {
+		return err
+	}
+	if err := r.markLinked(link, linked); err != nil {
+		return err
+	}
+	switch outcome {
+	case syncUpdatedLocal:
+		r.report.LostParts.Imported++
+	case syncUnchanged:
+		r.report.LostParts.Unchanged++
+	}
+	return nil
+}
+
+// pushMissingPart records on Rebrickable a missing part of the collection that is not linked yet,
+// along with the pieces missing from the other copies of its set
+func (r *rebrickableSyncRun) pushMissingPart(missingPart *entity.MissingPart, linked map[uint]bool) error {
+	link := &entity.RebrickableLostPart{
+		MissingPartID: missingPart.ID,
+		SetNum:        missingPart.Set.SetNum,
+		PartNum:       missingPart.Part.PartNum,
+		ColorID:       missingPart.ColorID,
+		IsSpare:       missingPart.IsSpare,
+	}
+	if missingPart.SetMinifigID != nil {
+		r.skip(link, "minifig parts cannot be recorded as lost on Rebrickable")
+		return nil
+	}
+	if missingPart.Set.ImportStatus != entity.SetImportStatusDone {
+		r.skip(link, "set is not imported yet")
+		return nil
+	}
+
+	invPartID, err := r.invPartID(link)
+	if err != nil {
+		return err
+	}
+	if invPartID == 0 {
+		r.skip(link, "part not found in the Rebrickable inventory of the set")
+		return nil
+	}
+	link.InvPartID = invPartID
+
+	outcome, err := r.reconcile(link, nil)
+	if err != nil {
+		return err
+	}
+	if err := r.markLinked(link, linked); err != nil {
+		return err
+	}
+	if outcome == syncUpdatedRemote {
+		r.report.LostParts.Pushed++
+	}
+	return nil
+}
+
+// reconcile merges a lost part link with the lost part on Rebrickable, nil when there is none,
+// and with its missing part. The side that changed since the last sync wins; when both changed the
+// link is flagged as a conflict. A link whose both sides agree on no missing piece is forgotten.
+func (r *rebrickableSyncRun) reconcile(link *entity.RebrickableLostPart, lostPart *RebrickableLostPart) (string, error) {
+	missingParts, local, err := r.localParts(link)
+	if err != nil {
+		return "", err
+	}
+
+	remoteQuantity := 0
+	if lostPart != nil {
+		remoteQuantity = lostPart.LostQuantity
+	}
+
+	var outcome string
+	switch {
+	case local == remoteQuantity:
+		outcome = syncUnchanged
+	case local == link.SyncedQuantity:
+		if err := r.applyLocal(link, missingParts, local, remoteQuantity); err != nil {
+			r.skip(link, err.Error())
+			return syncSkipped, nil
+		}
+		outcome = syncUpdatedLocal
+	case remoteQuantity == link.SyncedQuantity:
+		if err := r.applyRemote(link, lostPart, local); err != nil {
+			return "", err
+		}
+		outcome = syncUpdatedRemote
+	default:
+		link.Conflict = true
+		link.LocalQuantity = local
+		link.RemoteQuantity = remoteQuantity
+		if err := r.syncRepo.SaveLostPart(link); err != nil {
+			return "", fmt.Errorf("failed to save rebrickable lost part: %w", err)
+		}
+		r.report.LostParts.Conflicts = append(r.report.LostParts.Conflicts, *link)
+		return syncConflict, nil
+	}
+
+	agreed := remoteQuantity
+	if outcome == syncUpdatedRemote {
+		agreed = local
+	}
+	if agreed == 0 {
+		if link.ID != 0 {
+			if err := r.syncRepo.DeleteLostPart(link.ID); err != nil {
+				return "", fmt.Errorf("failed to delete rebrickable lost part: %w", err)
+			}
+		}
+		return outcome, nil
+	}
+
+	link.SyncedQuantity = agreed
+	link.Conflict = false
+	link.LocalQuantity = 0
+	link.RemoteQuantity = 0
+	link.SyncedAt = time.Now()
+	if err := r.syncRepo.SaveLostPart(link); err != nil {
+		return "", fmt.Errorf("failed to save rebrickable lost part: %w", err)
+	}
+	return outcome, nil
+}
+
+// localParts returns the missing parts behind a link, its own missing part first followed by the
+// other copies of the set missing the same piece, and the quantity they still miss in total
+func (r *rebrickableSyncRun) localParts(link *entity.RebrickableLostPart) ([]entity.MissingPart, int, error) {
+	if link.MissingPartID == 0 {
+		return nil, 0, nil
+	}
+
+	missingPart, err := r.missingPartsRepo.GetByID(link.MissingPartID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get missing part %d: %w", link.MissingPartID, err)
+	}
+
+	others, err := r.missingPartsRepo.GetMissingBySetID(missingPart.SetID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get missing parts of set %d: %w", missingPart.SetID, err)
+	}
+
+	missingParts := []entity.MissingPart{*missingPart}
+	local := 0
+	if missingPart.IsMissing {
+		local = missingPart.Quantity
+	}
+	for _, other := range others {
+		if other.ID == missingPart.ID || other.PartID != missingPart.PartID || other.ColorID != missingPart.ColorID ||
+			other.IsSpare != missingPart.IsSpare || other.SetMinifigID != nil {
+			continue
+		}
+		missingParts = append(missingParts, other)
+		local += other.Quantity
+	}
+	return missingParts, local, nil
+}
+
+// applyLocal brings the quantity missing behind a link from local to quantity, recording the changes
+// in the history of the missing parts. Pieces lost again are added to the missing part of the link;
+// pieces found are taken off the missing parts in order. The missing part is created on the oldest
+// copy of the set when there is none.
+func (r *rebrickableSyncRun) applyLocal(link *entity.RebrickableLostPart, missingParts []entity.MissingPart, local, quantity int) error {
+	if len(missingParts) == 0 {
+		if quantity == 0 {
+			return nil
+		}
+
+		set, setPart, reason, err := r.findSetPart(link)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			return errors.New(reason)
+		}
+
+		setCopy, err := r.setCopyService.ResolveCopy(set.ID, 0)
+		if err != nil {
+			return err
+		}
+
+		candidate := newSetPartCandidate(setPart, quantity, fmt.Sprintf("part %s in set %s", link.PartNum, link.SetNum))
+		candidate.missingPart.SetCopyID = setCopy.ID
+		candidate.missingPart.Notes = rebrickableSyncNotes
+		return r.transactor.Transaction(func(repos repository.TxRepositories) error {
+			created, err := upsertMissingPart(repos.MissingParts, *candidate)
+			if err != nil {
+				return err
+			}
+			link.MissingPartID = created.ID
+			return nil
+		})
+	}
+
+	return r.transactor.Transaction(func(repos repository.TxRepositories) error {
+		if quantity > local {
+			missingPart := &missingParts[0]
+			current := 0
+			if missingPart.IsMissing {
+				current = missingPart.Quantity
+			}
+			return setSyncedMissingQuantity(repos.MissingParts, missingPart, current+quantity-local)
+		}
+
+		found := local - quantity
+		for i := range missingParts {
+			missingPart := &missingParts[i]
+			if found == 0 {
+				break
+			}
+			if !missingPart.IsMissing {
+				continue
+			}
+			taken := min(found, missingPart.Quantity)
+			if err := setSyncedMissingQuantity(repos.MissingParts, missingPart, missingPart.Quantity-taken); err != nil {
+				return err
+			}
+			found -= taken
+		}
+		return nil
+	})
+}
+
+// setSyncedMissingQuantity sets the quantity still missing of a missing part, recording the change
+// in its history
+func setSyncedMissingQuantity(repo repository.MissingPartsRepository, missingPart *entity.MissingPart, quantity int) error {
+	current := 0
+	if missingPart.IsMissing {
+		current = missingPart.Quantity
+	}
+
+	recovery := &entity.MissingPartRecovery{MissingPartID: missingPart.ID, Action: entity.RecoveryActionFound, Quantity: current - quantity, Notes: rebrickableSyncNotes}
+	if quantity > current {
+		recovery.Action = entity.RecoveryActionReopened
+		recovery.Quantity = quantity - current
+	}
+
+	missingPart.Quantity = quantity
+	missingPart.IsMissing = quantity > 0
+	if err := repo.Update(missingPart); err != nil {
+		return fmt.Errorf("failed to update missing part %d: %w", missingPart.ID, err)
+	}
+	if err := repo.CreateRecovery(recovery); err != nil {
+		return fmt.Errorf("failed to record recovery: %w", err)
+	}
+	return nil
+}
+
+// applyRemote replaces the lost part on Rebrickable with quantity pieces. Rebrickable assigns the
+// new lost part an ID, which is looked up once every change was pushed.
+func (r *rebrickableSyncRun) applyRemote(link *entity.RebrickableLostPart, lostPart *RebrickableLostPart, quantity int) error {
+	if lostPart != nil {
+		if err := r.rebrickableClient.DeleteLostPart(r.token, lostPart.LostPartID); err != nil {
+			return err
+		}
+	}
+	link.LostPartID = 0
+
+	if quantity == 0 {
+		return nil
+	}
+	if err := r.rebrickableClient.AddLostPart(r.token, link.InvPartID, quantity); err != nil {
+		return err
+	}
+	r.pending = append(r.pending, link)
+	return nil
+}
+
+// assignLostPartIDs links the lost parts pushed to Rebrickable during the run to their new IDs
+func (r *rebrickableSyncRun) assignLostPartIDs() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	remote, err := r.remoteLostParts()
+	if err != nil {
+		return err
+	}
+
+	links, err := r.syncRepo.GetLostParts()
+	if err != nil {
+		return fmt.Errorf("failed to get rebrickable lost parts: %w", err)
+	}
+	taken := make(map[int]bool)
+	for _, link := range links {
+		taken[link.LostPartID] = true
+	}
+
+	var lostPartIDs []int
+	for lostPartID := range remote {
+		lostPartIDs = append(lostPartIDs, lostPartID)
+	}
+	sort.Ints(lostPartIDs)
+
+	for _, link := range r.pending {
+		for _, lostPartID := range lostPartIDs {
+			if taken[lostPartID] || remote[lostPartID].InvPart.InvPartID != link.InvPartID {
+				continue
+			}
+			taken[lostPartID] = true
+			link.LostPartID = lostPartID
+			if err := r.syncRepo.SaveLostPart(link); err != nil {
+				return fmt.Errorf("failed to save rebrickable lost part: %w", err)
+			}
+			break
+		}
+	}
+	r.pending = nil
+	return nil
+}
+
+// remoteLostParts retrieves the lost parts of the user by ID
+func (r *rebrickableSyncRun) remoteLostParts() (map[int]RebrickableLostPart, error) {
+	lostParts, err := r.rebrickableClient.GetLostParts(r.token)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[int]RebrickableLostPart, len(lostParts))
+	for _, lostPart := range lostParts {
+		remote[lostPart.LostPartID] = lostPart
+	}
+	return remote, nil
+}
+
+// findSetPart finds the inventory line of a link in the collection. It returns the reason the line
+// cannot be used when it is not found.
+func (r *rebrickableSyncRun) findSetPart(link *entity.RebrickableLostPart) (*entity.Set, *entity.SetPart, string, error) {
+	set, err := r.setRepo.GetBySetNum(link.SetNum)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, "set is not in the collection", nil
+	}
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to get set %s: %w", link.SetNum, err)
+	}
+	if set.ImportStatus != entity.SetImportStatusDone {
+		return nil, nil, "set is not imported yet", nil
+	}
+
+	setParts, ok := r.setParts[set.ID]
+	if !ok {
+		if setParts, err = r.setPartRepo.GetBySetID(set.ID); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to get parts of set %s: %w", link.SetNum, err)
+		}
+		r.setParts[set.ID] = setParts
+	}
+
+	for i := range setParts {
+		setPart := &setParts[i]
+		if setPart.Part.PartNum == link.PartNum && setPart.ColorID == link.ColorID && setPart.IsSpare == link.IsSpare {
+			return set, setPart, "", nil
+		}
+	}
+	return nil, nil, "part not found in the inventory of the set", nil
+}
+
+// invPartID finds the Rebrickable inventory line of a link, 0 when there is none
+func (r *rebrickableSyncRun) invPartID(link *entity.RebrickableLostPart) (int, error) {
+	invParts, ok := r.invParts[link.SetNum]
+	if !ok {
+		var err error
+		if invParts, err = r.rebrickableClient.GetSetParts(link.SetNum); err != nil {
+			return 0, err
+		}
+		r.invParts[link.SetNum] = invParts
+	}
+
+	for _, invPart := range invParts {
+		if invPart.Part.PartNum == link.PartNum && invPart.Color.ID == link.ColorID && invPart.IsSpare == link.IsSpare {
+			return invPart.InvPartID, nil
+		}
+	}
+	return 0, nil
+}
+
+// skip reports a lost part or missing part that could not be synced
+func (r *rebrickableSyncRun) skip(link *entity.RebrickableLostPart, reason string) {
+	r.report.LostParts.Skipped = append(r.report.LostParts.Skipped, RebrickableSyncSkipped{
+		SetNum:  link.SetNum,
+		PartNum: link.PartNum,
+		ColorID: link.ColorID,
+		Reason:  reason,
+	})
+}
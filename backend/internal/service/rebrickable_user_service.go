@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// RebrickableUserService handles the collection of a Rebrickable user, identified by a user token
+type RebrickableUserService interface {
+	GetUserToken(username, password string) (string, error)
+	GetProfile(userToken string) (*RebrickableProfile, error)
+	GetUserSets(userToken string) ([]RebrickableUserSet, error)
+	GetPartLists(userToken string) ([]RebrickablePartList, error)
+	GetPartListParts(userToken string, listID int) ([]RebrickablePartListPart, error)
+	GetLostParts(userToken string) ([]RebrickableLostPart, error)
+	AddLostPart(userToken string, invPartID, quantity int) error
+	DeleteLostPart(userToken string, lostPartID int) error
+}
+
+// RebrickableProfile is the profile of a Rebrickable user
+type RebrickableProfile struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// RebrickableUserSet is a set in one of the set lists of a Rebrickable user
+type RebrickableUserSet struct {
+	ListID        int            `json:"list_id"`
+	Quantity      int            `json:"quantity"`
+	IncludeSpares bool           `json:"include_spares"`
+	Set           RebrickableSet `json:"set"`
+}
+
+// RebrickablePartList is a part list of a Rebrickable user
+type RebrickablePartList struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	NumParts int    `json:"num_parts"`
+}
+
+// RebrickablePartListPart is a part, in a color, of a part list of a Rebrickable user
+type RebrickablePartListPart struct {
+	ListID   int              `json:"list_id"`
+	Quantity int              `json:"quantity"`
+	Part     RebrickablePart  `json:"part"`
+	Color    RebrickableColor `json:"color"`
+}
+
+// RebrickableLostPart is a part a Rebrickable user lost from one of their sets
+type RebrickableLostPart struct {
+	LostPartID   int                     `json:"lost_part_id"`
+	LostQuantity int                     `json:"lost_quantity"`
+	InvPart      RebrickableLostPartItem `json:"inv_part"`
+}
+
+// RebrickableLostPartItem is the inventory line of a set a lost part belongs to
+type RebrickableLostPartItem struct {
+	RebrickableSetPart
+	SetNum string `json:"set_num"`
+}
+
+// rebrickableUserToken is the response of the user token endpoint
+type rebrickableUserToken struct {
+	UserToken string `json:"user_token"`
+}
+
+// GetUserToken exchanges the credentials of a Rebrickable user for a user token
+func (s *rebrickableService) GetUserToken(username, password string) (string, error) {
+	form := url.Values{"username": {username}, "password": {password}}
+
+	var token rebrickableUserToken
+	if err := s.sendJSON(http.MethodPost, s.baseURL+"/users/_token/", form, &token); err != nil {
+		return "", fmt.Errorf("failed to get user token of %s: %w", username, err)
+	}
+
+	return token.UserToken, nil
+}
+
+// GetProfile retrieves the profile of the user owning userToken
+func (s *rebrickableService) GetProfile(userToken string) (*RebrickableProfile, error) {
+	var profile RebrickableProfile
+	if err := s.getJSON(s.userURL(userToken, "profile/"), &profile); err != nil {
+		return nil, fmt.Errorf("failed to fetch user profile: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// GetUserSets retrieves the sets of every set list of the user
+func (s *rebrickableService) GetUserSets(userToken string) ([]RebrickableUserSet, error) {
+	sets, err := getAllPages[RebrickableUserSet](s, s.userURL(userToken, fmt.Sprintf("sets/?page_size=%d", rebrickablePageSize)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user sets: %w", err)
+	}
+
+	return sets, nil
+}
+
+// GetPartLists retrieves the part lists of the user
+func (s *rebrickableService) GetPartLists(userToken string) ([]RebrickablePartList, error) {
+	lists, err := getAllPages[RebrickablePartList](s, s.userURL(userToken, fmt.Sprintf("partlists/?page_size=%d", rebrickablePageSize)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user part lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// GetPartListParts retrieves the parts of a part list of the user
+func (s *rebrickableService) GetPartListParts(userToken string, listID int) ([]RebrickablePartListPart, error) {
+	parts, err := getAllPages[RebrickablePartListPart](s, s.userURL(userToken, fmt.Sprintf("partlists/%d/parts/?page_size=%d", listID, rebrickablePageSize)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch parts of part list %d: %w", listID, err)
+	}
+
+	return parts, nil
+}
+
+// GetLostParts retrieves the parts the user lost from their sets
+func (s *rebrickableService) GetLostParts(userToken string) ([]RebrickableLostPart, error) {
+	lostParts, err := getAllPages[RebrickableLostPart](s, s.userURL(userToken, fmt.Sprintf("lost_parts/?page_size=%d", rebrickablePageSize)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lost parts: %w", err)
+	}
+
+	return lostParts, nil
+}
+
+// AddLostPart records quantity pieces of an inventory line as lost
+func (s *rebrickableService) AddLostPart(userToken string, invPartID, quantity int) error {
+	form := url.Values{"inv_part_id": {strconv.Itoa(invPartID)}, "lost_quantity": {strconv.Itoa(quantity)}}
+	if err := s.sendJSON(http.MethodPost, s.userURL(userToken, "lost_parts/"), form, nil); err != nil {
+		return fmt.Errorf("failed to add lost part for inventory part %d: %w", invPartID, err)
+	}
+
+	return nil
+}
+
+// DeleteLostPart removes a lost part of the user
+func (s *rebrickableService) DeleteLostPart(userToken string, lostPartID int) error {
+	if err := s.sendJSON(http.MethodDelete, s.userURL(userToken, fmt.Sprintf("lost_parts/%d/", lostPartID)), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete lost part %d: %w", lostPartID, err)
+	}
+
+	return nil
+}
+
+// userURL builds the URL of an endpoint of the user owning userToken
+func (s *rebrickableService) userURL(userToken, path string) string {
+	return fmt.Sprintf("%s/users/%s/%s", s.baseURL, url.PathEscape(userToken), path)
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrRefreshRunning is returned when a refresh is triggered while another one is in progress
+var ErrRefreshRunning = errors.New("a refresh run is already in progress")
+
+// setRefreshRequests is about how many Rebrickable requests refreshing a set takes: the set itself
+// and its inventory
+const setRefreshRequests = 2
+
+// RefreshScheduler periodically refreshes the sets that went stale from Rebrickable
+type RefreshScheduler interface {
+	Start()
+	Status() (*RefreshStatus, error)
+	Trigger() (*entity.RefreshRun, error)
+	Pause() (*RefreshStatus, error)
+	Resume() (*RefreshStatus, error)
+	GetRuns(limit int) ([]entity.RefreshRun, error)
+	GetSetRefreshes(failedOnly bool) ([]entity.SetRefresh, error)
+}
+
+// RefreshOptions configures the refresh scheduler. RequestsPerSecond is the Rebrickable rate limit
+// the runs must fit in.
+type RefreshOptions struct {
+	Interval          time.Duration
+	MaxAge            time.Duration
+	BatchSize         int
+	RequestsPerSecond float64
+}
+
+// RefreshStatus describes the refresh scheduler. NextRunAt is nil while paused or when runs are
+// only triggered manually.
+type RefreshStatus struct {
+	Paused    bool               `json:"paused"`
+	Running   bool               `json:"running"`
+	Interval  string             `json:"interval"`
+	MaxAge    string             `json:"max_age"`
+	BatchSize int                `json:"batch_size"`
+	NextRunAt *time.Time         `json:"next_run_at"`
+	LastRun   *entity.RefreshRun `json:"last_run"`
+}
+
+// refreshScheduler implements RefreshScheduler interface
+type refreshScheduler struct {
+	refreshRepo repository.RefreshRepository
+	setService  SetService
+	options     RefreshOptions
+
+	mu        sync.Mutex
+	paused    bool
+	running   bool
+	nextRunAt time.Time
+}
+
+// NewRefreshScheduler creates a new refresh scheduler. It does nothing until started.
+func NewRefreshScheduler(refreshRepo repository.RefreshRepository, setService SetService, options RefreshOptions) RefreshScheduler {
+	return &refreshScheduler{
+		refreshRepo: refreshRepo,
+		setService:  setService,
+		options:     options,
+	}
+}
+
+// Start runs the scheduler in the background. Runs left unfinished by a previous server are
+// marked as failed first.
+func (s *refreshScheduler) Start() {
+	if err := s.refreshRepo.FailRunningRuns("interrupted by a server restart"); err != nil {
+		log.Printf("Warning: failed to close interrupted refresh runs: %v", err)
+	}
+
+	if s.options.Interval <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.nextRunAt = time.Now().Add(s.options.Interval)
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(s.options.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.mu.Lock()
+			s.nextRunAt = time.Now().Add(s.options.Interval)
+			skip := s.paused || s.running
+			s.mu.Unlock()
+			if skip {
+				continue
+			}
+
+			if _, err := s.start(entity.RefreshTriggerScheduled); err != nil && !errors.Is(err, ErrRefreshRunning) {
+				log.Printf("Warning: failed to start refresh run: %v", err)
+			}
+		}
+	}()
+}
+
+// Status describes the scheduler and its last run
+func (s *refreshScheduler) Status() (*RefreshStatus, error) {
+	lastRun, err := s.refreshRepo.GetLastRun()
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get last refresh run: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &RefreshStatus{
+		Paused:    s.paused,
+		Running:   s.running,
+		Interval:  s.options.Interval.String(),
+		MaxAge:    s.options.MaxAge.String(),
+		BatchSize: s.batchSize(),
+		LastRun:   lastRun,
+	}
+	if !s.paused && s.options.Interval > 0 && !s.nextRunAt.IsZero() {
+		nextRunAt := s.nextRunAt
+		status.NextRunAt = &nextRunAt
+	}
+	return status, nil
+}
+
+// Trigger starts a run right away, even while the scheduler is paused
+func (s *refreshScheduler) Trigger() (*entity.RefreshRun, error) {
+	return s.start(entity.RefreshTriggerManual)
+}
+
+// Pause stops the scheduled runs until resumed. A run in progress finishes.
+func (s *refreshScheduler) Pause() (*RefreshStatus, error) {
+	s.mu.Lock()
+	s.paused = true
+	s.mu.Unlock()
+
+	return s.Status()
+}
+
+// Resume restarts the scheduled runs
+func (s *refreshScheduler) Resume() (*RefreshStatus, error) {
+	s.mu.Lock()
+	s.paused = false
+	s.mu.Unlock()
+
+	return s.Status()
+}
+
+// GetRuns retrieves the latest runs, newest first
+func (s *refreshScheduler) GetRuns(limit int) ([]entity.RefreshRun, error) {
+	return s.refreshRepo.GetRuns(limit)
+}
+
+// GetSetRefreshes retrieves the refresh state of the sets, with their last error
+func (s *refreshScheduler) GetSetRefreshes(failedOnly bool) ([]entity.SetRefresh, error) {
+	return s.refreshRepo.GetSetRefreshes(failedOnly)
+}
+
+// start records a new run and refreshes its sets in the background
+func (s *refreshScheduler) start(trigger string) (*entity.RefreshRun, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, ErrRefreshRunning
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	run := &entity.RefreshRun{Trigger: trigger, Status: entity.RefreshStatusRunning, StartedAt: time.Now()}
+	if err := s.refreshRepo.CreateRun(run); err != nil {
+		s.finish()
+		return nil, fmt.Errorf("failed to create refresh run: %w", err)
+	}
+
+	response := *run
+	go func() {
+		defer s.finish()
+		s.execute(run)
+	}()
+
+	return &response, nil
+}
+
+// finish marks the scheduler as idle
+func (s *refreshScheduler) finish() {
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+}
+
+// execute refreshes a batch of stale sets. A set whose refresh fails is retried by the next runs,
+// after the sets that did not fail. The run stops early when Rebrickable throttles it.
+func (s *refreshScheduler) execute(run *entity.RefreshRun) {
+	sets, err := s.refreshRepo.GetStaleSets(time.Now().Add(-s.options.MaxAge), s.batchSize())
+	if err != nil {
+		run.Error = fmt.Sprintf("failed to get stale sets: %v", err)
+	}
+
+	for _, set := range sets {
+		resync, err := s.setService.ResyncSet(set.ID, false)
+		run.SetsChecked++
+
+		setRefresh, getErr := s.refreshRepo.GetSetRefresh(set.ID)
+		if getErr != nil {
+			setRefresh = &entity.SetRefresh{SetID: set.ID}
+		}
+
+		// Stored in UTC, like the last modified times of sets, so that GetStaleSets can compare them
+		now := time.Now().UTC()
+		if err != nil {
+			run.SetsFailed++
+			setRefresh.LastError = err.Error()
+			setRefresh.LastErrorAt = &now
+			setRefresh.Failures++
+		} else {
+			if resync.Modified {
+				run.SetsModified++
+			}
+			setRefresh.CheckedAt = &now
+			setRefresh.Failures = 0
+		}
+
+		if saveErr := s.refreshRepo.SaveSetRefresh(setRefresh); saveErr != nil {
+			log.Printf("Warning: failed to save refresh state of set %s: %v", set.SetNum, saveErr)
+		}
+
+		if errors.Is(err, ErrRebrickableThrottled) {
+			run.Error = "stopped early: Rebrickable rate limit exceeded"
+			break
+		}
+	}
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = entity.RefreshStatusDone
+	if run.Error != "" {
+		run.Status = entity.RefreshStatusFailed
+	}
+	if err := s.refreshRepo.SaveRun(run); err != nil {
+		log.Printf("Warning: failed to save refresh run %d: %v", run.ID, err)
+	}
+}
+
+// batchSize is the number of sets a run refreshes: the configured batch size, lowered so that a
+// run does not need more Rebrickable requests than the rate limit allows over one interval
+func (s *refreshScheduler) batchSize() int {
+	batchSize := s.options.BatchSize
+	if s.options.Interval > 0 && s.options.RequestsPerSecond > 0 {
+		budget := int(s.options.Interval.Seconds() * s.options.RequestsPerSecond / setRefreshRequests)
+		batchSize = min(batchSize, max(budget, 1))
+	}
+	return max(batchSize, 1)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// Page sizes used by the unified search
+const (
+	DefaultSearchPageSize = 20
+	MaxSearchPageSize     = 100
+)
+
+// ErrInvalidSearch is returned for search parameters that cannot be used
+var ErrInvalidSearch = errors.New("invalid search")
+
+// SearchService searches parts and sets by number and name
+type SearchService interface {
+	Search(query string, kinds []string, offset, limit int) (*SearchResults, error)
+}
+
+// SearchResults is a ranked page of parts and sets matching a query
+type SearchResults struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+	Total   int64          `json:"total"`
+	Offset  int            `json:"offset"`
+	Limit   int            `json:"limit"`
+}
+
+// SearchResult is a part or set matching a query. Highlighted fields are HTML-escaped and wrap the
+// matched terms in <mark> tags.
+type SearchResult struct {
+	Kind      string          `json:"kind"`
+	ID        uint            `json:"id"`
+	Num       string          `json:"num"`
+	Name      string          `json:"name"`
+	Highlight SearchHighlight `json:"highlight"`
+	Score     float64         `json:"score"`
+}
+
+// SearchHighlight holds the number and name of a search result with the matched terms marked
+type SearchHighlight struct {
+	Num  string `json:"num"`
+	Name string `json:"name"`
+}
+
+// searchService implements SearchService interface
+type searchService struct {
+	searchRepo repository.SearchRepository
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(searchRepo repository.SearchRepository) SearchService {
+	return &searchService{searchRepo: searchRepo}
+}
+
+// Search returns the parts and sets matching query, best matches first. kinds restricts the
+// results to parts and/or sets; every kind is searched when it is empty.
+func (s *searchService) Search(query string, kinds []string, offset, limit int) (*SearchResults, error) {
+	for _, kind := range kinds {
+		if kind != repository.SearchKindPart && kind != repository.SearchKindSet {
+			return nil, fmt.Errorf("unknown search type %q: %w", kind, ErrInvalidSearch)
+		}
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = DefaultSearchPageSize
+	}
+	if limit > MaxSearchPageSize {
+		limit = MaxSearchPageSize
+	}
+
+	hits, total, err := s.searchRepo.Search(query, kinds, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, SearchResult{
+			Kind:      hit.Kind,
+			ID:        hit.RefID,
+			Num:       hit.Num,
+			Name:      hit.Name,
+			Highlight: SearchHighlight{Num: hit.NumHighlight, Name: hit.NameHighlight},
+			Score:     hit.Score,
+		})
+	}
+
+	return &SearchResults{Query: query, Results: results, Total: total, Offset: offset, Limit: limit}, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSetCopy is returned when a set copy request has an unknown condition or date, or
+// names a copy of another set
+var ErrInvalidSetCopy = errors.New("invalid set copy")
+
+// SetCopyService handles business logic for owned set copies
+type SetCopyService interface {
+	CreateCopy(setID uint, req SetCopyRequest) (*entity.SetCopy, error)
+	GetCopies(setID uint) ([]entity.SetCopy, error)
+	GetCopy(id uint) (*entity.SetCopy, error)
+	DeleteCopy(id uint) error
+	ResolveCopy(setID, setCopyID uint) (*entity.SetCopy, error)
+}
+
+// SetCopyRequest describes a new owned copy of a set. AcquiredAt is a date (2006-01-02) or an RFC 3339 time.
+type SetCopyRequest struct {
+	Label             string `json:"label"`
+	Condition         string `json:"condition"`
+	AcquiredAt        string `json:"acquired_at"`
+	Notes             string `json:"notes"`
+	StorageLocationID *uint  `json:"storage_location_id"`
+}
+
+// setCopyService implements SetCopyService interface
+type setCopyService struct {
+	setCopyRepo         repository.SetCopyRepository
+	setRepo             repository.SetRepository
+	storageLocationRepo repository.StorageLocationRepository
+	transactor          repository.Transactor
+}
+
+// NewSetCopyService creates a new set copy service
+func NewSetCopyService(setCopyRepo repository.SetCopyRepository, setRepo repository.SetRepository, storageLocationRepo repository.StorageLocationRepository, transactor repository.Transactor) SetCopyService {
+	return &setCopyService{
+		setCopyRepo:         setCopyRepo,
+		setRepo:             setRepo,
+		storageLocationRepo: storageLocationRepo,
+		transactor:          transactor,
+	}
+}
+
+// CreateCopy records a new owned copy of a set. Copies without a label are numbered.
+func (s *setCopyService) CreateCopy(setID uint, req SetCopyRequest) (*entity.SetCopy, error) {
+	if _, err := s.setRepo.GetByID(setID); err != nil {
+		return nil, fmt.Errorf("failed to get set %d: %w", setID, err)
+	}
+
+	switch req.Condition {
+	case "", entity.SetCopyConditionSealed, entity.SetCopyConditionNew, entity.SetCopyConditionUsed:
+	default:
+		return nil, fmt.Errorf("unknown condition %q, expected sealed, new or used: %w", req.Condition, ErrInvalidSetCopy)
+	}
+
+	acquiredAt, err := parseAcquiredAt(req.AcquiredAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStorageLocation(s.storageLocationRepo, req.StorageLocationID); err != nil {
+		return nil, err
+	}
+
+	label := req.Label
+	if label == "" {
+		copies, err := s.setCopyRepo.GetBySetID(setID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get copies of set %d: %w", setID, err)
+		}
+		label = fmt.Sprintf("Copy %d", len(copies)+1)
+	}
+
+	setCopy := &entity.SetCopy{
+		SetID:      setID,
+		Label:      label,
+		Condition:  req.Condition,
+		AcquiredAt: acquiredAt,
+		Notes:      req.Notes,
+
+		StorageLocationID: req.StorageLocationID,
+	}
+	if err := s.setCopyRepo.Create(setCopy); err != nil {
+		return nil, fmt.Errorf("failed to create set copy: %w", err)
+	}
+
+	return s.setCopyRepo.GetByID(setCopy.ID)
+}
+
+// GetCopies retrieves the copies of a set with the pieces missing from each
+func (s *setCopyService) GetCopies(setID uint) ([]entity.SetCopy, error) {
+	if _, err := s.setRepo.GetByID(setID); err != nil {
+		return nil, fmt.Errorf("failed to get set %d: %w", setID, err)
+	}
+
+	return s.setCopyRepo.GetBySetID(setID)
+}
+
+// GetCopy retrieves a copy with its missing parts and minifigs
+func (s *setCopyService) GetCopy(id uint) (*entity.SetCopy, error) {
+	return s.setCopyRepo.GetByID(id)
+}
+
+// DeleteCopy deletes a copy together with its missing parts and minifigs
+func (s *setCopyService) DeleteCopy(id uint) error {
+	if _, err := s.setCopyRepo.GetByID(id); err != nil {
+		return fmt.Errorf("failed to get set copy %d: %w", id, err)
+	}
+
+	return s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		if err := repos.MissingParts.DeleteBySetCopyID(id); err != nil {
+			return fmt.Errorf("failed to delete missing parts of set copy %d: %w", id, err)
+		}
+		if err := repos.MissingMinifigs.DeleteBySetCopyID(id); err != nil {
+			return fmt.Errorf("failed to delete missing minifigs of set copy %d: %w", id, err)
+		}
+		return repos.SetCopies.Delete(id)
+	})
+}
+
+// ResolveCopy returns the copy setCopyID of a set, or the oldest copy of the set when setCopyID is 0
+func (s *setCopyService) ResolveCopy(setID, setCopyID uint) (*entity.SetCopy, error) {
+	setCopy, err := s.setCopyRepo.GetForSet(setID, setCopyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if setCopyID != 0 {
+			return nil, fmt.Errorf("set copy %d does not belong to set %d: %w", setCopyID, setID, ErrInvalidSetCopy)
+		}
+		return nil, fmt.Errorf("set %d has no owned copy: %w", setID, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get copy of set %d: %w", setID, err)
+	}
+	return setCopy, nil
+}
+
+// parseAcquiredAt parses an acquisition date given as a date or an RFC 3339 time
+func parseAcquiredAt(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid acquired_at %q, expected YYYY-MM-DD: %w", value, ErrInvalidSetCopy)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidSetList is returned for a set list that cannot be read
+var ErrInvalidSetList = errors.New("invalid set list")
+
+// Set list formats accepted by ImportSetList
+const (
+	SetListFormatJSON = "json"
+	SetListFormatText = "text"
+	SetListFormatCSV  = "csv"
+)
+
+// Names of the set list exports of Rebrickable and BrickLink, both read as SetListFormatCSV
+const (
+	SetListFormatRebrickable = "rebrickable"
+	SetListFormatBrickLink   = "bricklink"
+)
+
+// Outcomes of the sets of an imported set list
+const (
+	SetListOutcomeQueued    = "queued"
+	SetListOutcomeExisting  = "existing"
+	SetListOutcomeDuplicate = "duplicate"
+	SetListOutcomeInvalid   = "invalid"
+	SetListOutcomeFailed    = "failed"
+)
+
+// SetListImportReport lists what became of each set of an imported set list
+type SetListImportReport struct {
+	Format   string             `json:"format"`
+	Queued   int                `json:"queued"`
+	Existing int                `json:"existing"`
+	Failed   int                `json:"failed"`
+	Sets     []SetListImportRow `json:"sets"`
+}
+
+// SetListImportRow is the outcome of one set of an imported set list. Quantity is the number of
+// copies listed, summed over the duplicates of the set. JobID is set when the set is imported by a
+// job, which creates that many copies.
+type SetListImportRow struct {
+	Line     int    `json:"line"`
+	SetNum   string `json:"set_num"`
+	Quantity int    `json:"quantity"`
+	Outcome  string `json:"outcome"`
+	JobID    *uint  `json:"job_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// setListRow is a set number read from a set list, with the number of copies listed
+type setListRow struct {
+	line     int
+	setNum   string
+	quantity int
+	err      string
+}
+
+// ImportSetList reads a list of set numbers and queues an import job for each set that is not
+// stored yet. Set numbers without a variant get the first one (-1), as on Rebrickable.
+func (s *importJobService) ImportSetList(format string, r io.Reader) (*SetListImportReport, error) {
+	var rows []setListRow
+	var err error
+	switch format {
+	case SetListFormatJSON:
+		rows, err = parseSetListJSON(r)
+	case SetListFormatText:
+		rows, err = parseSetListText(r)
+	case SetListFormatCSV:
+		rows, err = parseSetListCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported set list format %q: %w", format, ErrInvalidSetList)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s set list: %v: %w", format, err, ErrInvalidSetList)
+	}
+
+	report := &SetListImportReport{
+		Format: format,
+		Sets:   []SetListImportRow{},
+	}
+
+	// The copies listed on duplicate rows are added to the first row of the set
+	results := make([]SetListImportRow, len(rows))
+	first := make(map[string]int, len(rows))
+	for i, row := range rows {
+		results[i] = SetListImportRow{Line: row.line, SetNum: normalizeSetNum(row.setNum), Quantity: row.quantity}
+		result := &results[i]
+
+		switch {
+		case row.err != "":
+			result.Outcome = SetListOutcomeInvalid
+			result.Error = row.err
+		case result.SetNum == "":
+			result.Outcome = SetListOutcomeInvalid
+			result.Error = "missing set number"
+		default:
+			if j, ok := first[result.SetNum]; ok {
+				result.Outcome = SetListOutcomeDuplicate
+				results[j].Quantity += row.quantity
+			} else {
+				first[result.SetNum] = i
+			}
+		}
+	}
+
+	for i := range results {
+		result := &results[i]
+		if result.Outcome == "" {
+			job, err := s.EnqueueSetImport(result.SetNum, result.Quantity)
+			switch {
+			case err == nil:
+				result.Outcome = SetListOutcomeQueued
+				result.JobID = &job.ID
+				report.Queued++
+			case errors.Is(err, ErrSetAlreadyExists):
+				result.Outcome = SetListOutcomeExisting
+				report.Existing++
+			default:
+				result.Outcome = SetListOutcomeFailed
+				result.Error = err.Error()
+				report.Failed++
+			}
+		}
+
+		report.Sets = append(report.Sets, *result)
+	}
+
+	return report, nil
+}
+
+// parseSetListJSON reads a JSON array of set numbers
+func parseSetListJSON(r io.Reader) ([]setListRow, error) {
+	var setNums []string
+	if err := json.NewDecoder(r).Decode(&setNums); err != nil {
+		return nil, err
+	}
+
+	rows := make([]setListRow, 0, len(setNums))
+	for i, setNum := range setNums {
+		rows = append(rows, setListRow{line: i + 1, setNum: setNum, quantity: 1})
+	}
+	return rows, nil
+}
+
+// parseSetListText reads set numbers separated by new lines, commas or spaces. Blank lines and
+// lines starting with # are skipped.
+func parseSetListText(r io.Reader) ([]setListRow, error) {
+	var rows []setListRow
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		for _, setNum := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' || r == ' ' || r == '\t' }) {
+			rows = append(rows, setListRow{line: line, setNum: setNum, quantity: 1})
+		}
+	}
+	return rows, scanner.Err()
+}
+
+// setListColumns are the set number columns of Rebrickable (Set Number) and BrickLink (Item No or
+// ItemID) set list exports
+var setListColumns = []string{"Set Number", "set_num", "Item No", "ItemID", "Item Number"}
+
+// parseSetListCSV reads a Rebrickable or BrickLink set list export. The Quantity column, when there is
+// one, is the number of copies owned.
+func parseSetListCSV(r io.Reader) ([]setListRow, error) {
+	// The header is checked before the rows since readCSV leaves unknown columns empty
+	reader := bufio.NewReader(r)
+	header, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	columns, err := csv.NewReader(strings.NewReader(header)).Read()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	hasSetNum := false
+	for _, column := range columns {
+		hasSetNum = hasSetNum || slices.Contains(setListColumns, strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+	}
+	if !hasSetNum {
+		return nil, errors.New("missing set number column")
+	}
+
+	var rows []setListRow
+	line := 1
+	_, err = readCSV(io.MultiReader(strings.NewReader(header), reader), func(row csvRow) (setListRow, error) {
+		line++
+		result := setListRow{line: line, setNum: firstCSVValue(row, setListColumns...), quantity: 1}
+		if value := firstCSVValue(row, "Quantity", "Qty"); value != "" {
+			result.quantity, result.err = parseImportQuantity(value)
+		}
+		return result, nil
+	}, func(batch []setListRow) error {
+		rows = append(rows, batch...)
+		return nil
+	})
+	return rows, err
+}
@@ -1,48 +1,88 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/BombartSimon/MissingBrick/internal/entity"
 	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
 )
 
+// ErrSetAlreadyExists is returned when adding a set that is already stored
+var ErrSetAlreadyExists = errors.New("set already exists")
+
+// ErrSetNotStored is returned when syncing a set that is not stored yet, which is imported by a job
+var ErrSetNotStored = errors.New("set is not stored")
+
 // SetService handles business logic for sets
 type SetService interface {
-	CreateSetWithParts(setNum string) (*entity.Set, error)
+	CreateSet(setNum string, copies int) (*entity.Set, error)
 	GetSetByID(id uint) (*entity.Set, error)
 	GetSetBySetNum(setNum string) (*entity.Set, error)
-	GetAllSets() ([]entity.Set, error)
+	GetAllSets(filter SetListFilter, query repository.ListQuery) (*repository.Page[entity.Set], error)
 	UpdateSet(set *entity.Set) error
 	DeleteSet(id uint) error
 	SyncSetFromRebrickable(setNum string) (*entity.Set, error)
+	ResyncSet(id uint, force bool) (*SetResync, error)
 	GetSetWithMissingParts(id uint) (*entity.Set, error)
 	GetSetWithParts(id uint) (*entity.Set, error)
 }
 
+// SetListFilter restricts the sets returned by GetAllSets. Nil and empty fields are ignored.
+// IncludeSpares counts spare parts in the completeness of the sets.
+type SetListFilter struct {
+	YearMin         *int
+	YearMax         *int
+	ThemeID         *int
+	HasMissingParts *bool
+	IsDonor         *bool
+	Status          string
+	CompletenessMin *float64
+	CompletenessMax *float64
+	IncludeSpares   bool
+}
+
+// SetResync is the result of resyncing a set with Rebrickable. Inventory is only set when the
+// inventory was diffed.
+type SetResync struct {
+	Set                  *entity.Set     `json:"set"`
+	PreviousLastModified time.Time       `json:"previous_last_modified_dt"`
+	Modified             bool            `json:"modified"`
+	Inventory            *InventoryDrift `json:"inventory,omitempty"`
+}
+
 // setService implements SetService interface
 type setService struct {
 	setRepo            repository.SetRepository
 	setPartService     SetPartService
+	minifigService     MinifigService
+	themeService       ThemeService
 	rebrickableService RebrickableService
+	transactor         repository.Transactor
 }
 
 // NewSetService creates a new set service
-func NewSetService(setRepo repository.SetRepository, setPartService SetPartService, rebrickableService RebrickableService) SetService {
+func NewSetService(setRepo repository.SetRepository, setPartService SetPartService, minifigService MinifigService, themeService ThemeService, rebrickableService RebrickableService, transactor repository.Transactor) SetService {
 	return &setService{
 		setRepo:            setRepo,
 		setPartService:     setPartService,
+		minifigService:     minifigService,
+		themeService:       themeService,
 		rebrickableService: rebrickableService,
+		transactor:         transactor,
 	}
 }
 
-// createSet creates a new set
-func (s *setService) createSet(setNum string) (*entity.Set, error) {
+// CreateSet creates a new set with the given number of copies, at least one. Its parts and minifigs
+// are left to an import job, so the set is flagged as importing.
+func (s *setService) CreateSet(setNum string, copies int) (*entity.Set, error) {
 	// Check if set already exists
 	existingSet, err := s.setRepo.GetBySetNum(setNum)
 	if err == nil && existingSet != nil {
-		return nil, fmt.Errorf("set with number %s already exists", setNum)
+		return nil, fmt.Errorf("set with number %s: %w", setNum, ErrSetAlreadyExists)
 	}
 
 	// Fetch from Rebrickable
@@ -64,34 +104,40 @@ func (s *setService) createSet(setNum string) (*entity.Set, error) {
 		SetImageURL:  rbSet.SetImageURL,
 		SetURL:       rbSet.SetURL,
 		LastModified: lastModified,
+		ImportStatus: entity.SetImportStatusImporting,
 	}
 
-	err = s.setRepo.Create(set)
+	s.ensureTheme(set.ThemeID)
+
+	// Adding a set means owning at least one copy of it, so both are created together
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		if err := repos.Sets.Create(set); err != nil {
+			return fmt.Errorf("failed to create set: %w", err)
+		}
+		for i := 1; i <= max(copies, 1); i++ {
+			if err := repos.SetCopies.Create(&entity.SetCopy{SetID: set.ID, Label: fmt.Sprintf("Copy %d", i)}); err != nil {
+				return fmt.Errorf("failed to create copy %d of set %s: %w", i, setNum, err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create set: %w", err)
+		return nil, err
 	}
 
-	return set, nil
+	return s.setRepo.GetByID(set.ID)
 }
 
-// CreateSetWithParts creates a new set and automatically imports all its parts
-func (s *setService) CreateSetWithParts(setNum string) (*entity.Set, error) {
-	// Create the set first
-	set, err := s.createSet(setNum)
-	if err != nil {
-		return nil, err
+// ensureTheme makes sure the theme of a set is stored so it can be embedded in set responses
+func (s *setService) ensureTheme(themeID int) {
+	if s.themeService == nil || themeID == 0 {
+		return
 	}
 
-	// Import the parts for this set
-	if s.setPartService != nil {
-		err = s.setPartService.SyncSetPartsFromRebrickable(set.ID, setNum)
-		if err != nil {
-			// Log the error but don't fail the set creation
-			fmt.Printf("Warning: failed to import parts for set %s: %v\n", setNum, err)
-		}
+	if err := s.themeService.EnsureTheme(themeID); err != nil {
+		// Log the error but don't fail the set creation
+		log.Printf("Warning: failed to import theme %d: %v", themeID, err)
 	}
-
-	return set, nil
 }
 
 // GetSetByID retrieves a set by ID
@@ -104,9 +150,28 @@ func (s *setService) GetSetBySetNum(setNum string) (*entity.Set, error) {
 	return s.setRepo.GetBySetNum(setNum)
 }
 
-// GetAllSets retrieves all sets
-func (s *setService) GetAllSets() ([]entity.Set, error) {
-	return s.setRepo.GetAll()
+// GetAllSets retrieves a page of sets. A theme filter includes the sets of its sub-themes.
+func (s *setService) GetAllSets(filter SetListFilter, query repository.ListQuery) (*repository.Page[entity.Set], error) {
+	setFilter := repository.SetFilter{
+		YearMin:         filter.YearMin,
+		YearMax:         filter.YearMax,
+		HasMissingParts: filter.HasMissingParts,
+		IsDonor:         filter.IsDonor,
+		Status:          filter.Status,
+		CompletenessMin: filter.CompletenessMin,
+		CompletenessMax: filter.CompletenessMax,
+		IncludeSpares:   filter.IncludeSpares,
+	}
+
+	if filter.ThemeID != nil {
+		themeIDs, err := s.themeService.GetThemeAndSubThemeIDs(*filter.ThemeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve sub-themes of theme %d: %w", *filter.ThemeID, err)
+		}
+		setFilter.ThemeIDs = themeIDs
+	}
+
+	return s.setRepo.List(setFilter, query)
 }
 
 // UpdateSet updates a set
@@ -119,37 +184,80 @@ func (s *setService) DeleteSet(id uint) error {
 	return s.setRepo.Delete(id)
 }
 
-// SyncSetFromRebrickable syncs a set from Rebrickable API
+// SyncSetFromRebrickable syncs a stored set from Rebrickable API. Its inventory is only resynced
+// when Rebrickable modified the set since it was last synced. A set that is not stored yet is left
+// to an import job.
 func (s *setService) SyncSetFromRebrickable(setNum string) (*entity.Set, error) {
-	// Fetch from Rebrickable
-	rbSet, err := s.rebrickableService.GetSet(setNum)
+	existingSet, err := s.setRepo.GetBySetNum(setNum)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("set with number %s: %w", setNum, ErrSetNotStored)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch set from Rebrickable: %w", err)
+		return nil, fmt.Errorf("failed to get set %s: %w", setNum, err)
 	}
 
-	// Check if set exists locally
-	existingSet, err := s.setRepo.GetBySetNum(setNum)
+	resync, err := s.resyncSet(existingSet, false)
 	if err != nil {
-		// Set doesn't exist, create new one
-		return s.createSet(setNum)
+		return nil, err
+	}
+
+	return resync.Set, nil
+}
+
+// ResyncSet updates a set with fresh data from Rebrickable. Its inventory is diffed against the new
+// one when Rebrickable modified the set since it was last synced, or always when force is set.
+func (s *setService) ResyncSet(id uint, force bool) (*SetResync, error) {
+	set, err := s.setRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resyncSet(set, force)
+}
+
+// resyncSet updates a stored set with fresh data from Rebrickable
+func (s *setService) resyncSet(set *entity.Set, force bool) (*SetResync, error) {
+	// Fetch from Rebrickable
+	rbSet, err := s.rebrickableService.GetSet(set.SetNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch set from Rebrickable: %w", err)
 	}
 
-	// Update existing set
 	lastModified, _ := time.Parse(time.RFC3339, rbSet.LastModified)
-	existingSet.Name = rbSet.Name
-	existingSet.Year = rbSet.Year
-	existingSet.ThemeID = rbSet.ThemeID
-	existingSet.NumParts = rbSet.NumParts
-	existingSet.SetImageURL = rbSet.SetImageURL
-	existingSet.SetURL = rbSet.SetURL
-	existingSet.LastModified = lastModified
-
-	err = s.setRepo.Update(existingSet)
+	resync := &SetResync{
+		PreviousLastModified: set.LastModified,
+		Modified:             !lastModified.Equal(set.LastModified),
+	}
+
+	// The inventory is resynced before the set is saved, so that a failed resync is retried next time
+	if (resync.Modified || force) && s.setPartService != nil {
+		resync.Inventory, err = s.setPartService.ResyncSetParts(set.ID, set.SetNum)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	set.Name = rbSet.Name
+	set.Year = rbSet.Year
+	set.ThemeID = rbSet.ThemeID
+	set.NumParts = rbSet.NumParts
+	set.SetImageURL = rbSet.SetImageURL
+	set.SetURL = rbSet.SetURL
+	set.LastModified = lastModified
+
+	s.ensureTheme(set.ThemeID)
+
+	err = s.setRepo.Update(set)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update set: %w", err)
 	}
 
-	return existingSet, nil
+	resync.Set, err = s.setRepo.GetByID(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return resync, nil
 }
 
 // GetSetWithMissingParts retrieves a set with its missing parts
@@ -157,7 +265,7 @@ func (s *setService) GetSetWithMissingParts(id uint) (*entity.Set, error) {
 	return s.setRepo.GetWithMissingParts(id)
 }
 
-// GetSetWithParts retrieves a set with all its parts
+// GetSetWithParts retrieves a set with all its parts and minifigs
 func (s *setService) GetSetWithParts(id uint) (*entity.Set, error) {
 	set, err := s.setRepo.GetByID(id)
 	if err != nil {
@@ -172,5 +280,13 @@ func (s *setService) GetSetWithParts(id uint) (*entity.Set, error) {
 		set.SetParts = setParts
 	}
 
+	if s.minifigService != nil {
+		setMinifigs, err := s.minifigService.GetSetMinifigs(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get set minifigs: %w", err)
+		}
+		set.SetMinifigs = setMinifigs
+	}
+
 	return set, nil
 }
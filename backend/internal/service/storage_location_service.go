@@ -0,0 +1,437 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+)
+
+// ErrInvalidStorageLocation is returned when a storage location request has an unknown kind, nests a
+// location in one of the same depth or deeper, or would make a location its own ancestor
+var ErrInvalidStorageLocation = errors.New("invalid storage location")
+
+// ErrStorageLocationNotEmpty is returned when deleting a location that still holds sub-locations or items
+var ErrStorageLocationNotEmpty = errors.New("storage location is not empty")
+
+// storageLocationDepths ranks the kinds of storage location from the outermost to the innermost
+var storageLocationDepths = map[string]int{
+	entity.StorageLocationKindRoom:   0,
+	entity.StorageLocationKindShelf:  1,
+	entity.StorageLocationKindDrawer: 2,
+	entity.StorageLocationKindBin:    3,
+}
+
+// StorageLocationService handles business logic for storage locations and the items they hold
+type StorageLocationService interface {
+	GetStorageLocations() ([]entity.StorageLocation, error)
+	GetStorageLocation(id uint, recursive bool) (*StorageLocationContents, error)
+	CreateStorageLocation(req StorageLocationRequest) (*entity.StorageLocation, error)
+	UpdateStorageLocation(id uint, req StorageLocationUpdate) (*entity.StorageLocation, error)
+	DeleteStorageLocation(id uint) error
+	MoveItems(req StorageLocationMove) (*StorageLocationMoveReport, error)
+}
+
+// StorageLocationRequest describes a new storage location. Locations without a parent are at the root.
+type StorageLocationRequest struct {
+	ParentID *uint  `json:"parent_id"`
+	Name     string `json:"name" binding:"required"`
+	Kind     string `json:"kind" binding:"required"`
+	Notes    string `json:"notes"`
+}
+
+// StorageLocationUpdate changes a storage location. Nil fields are left unchanged and a parent_id of 0
+// moves the location to the root.
+type StorageLocationUpdate struct {
+	ParentID *uint   `json:"parent_id"`
+	Name     *string `json:"name"`
+	Kind     *string `json:"kind"`
+	Notes    *string `json:"notes"`
+}
+
+// StorageLocationContents is a storage location with its direct children and the items it holds
+type StorageLocationContents struct {
+	*entity.StorageLocation
+	SetCopies  []entity.SetCopy   `json:"set_copies"`
+	LooseParts []entity.LoosePart `json:"loose_parts"`
+	// Recursive tells whether the items of the sub-locations are included
+	Recursive bool `json:"recursive"`
+}
+
+// StorageLocationMove moves set copies and loose part entries to a storage location, or out of any
+// location when StorageLocationID is nil. LoosePartIDs move whole entries; LooseParts can move part
+// of an entry.
+type StorageLocationMove struct {
+	StorageLocationID *uint           `json:"storage_location_id"`
+	SetCopyIDs        []uint          `json:"set_copy_ids"`
+	LoosePartIDs      []uint          `json:"loose_part_ids"`
+	LooseParts        []LoosePartMove `json:"loose_parts"`
+}
+
+// LoosePartMove moves some pieces of a loose part entry. A zero quantity moves the whole entry.
+type LoosePartMove struct {
+	ID       uint `json:"id"`
+	Quantity int  `json:"quantity"`
+}
+
+// StorageLocationMoveReport summarizes a move
+type StorageLocationMoveReport struct {
+	StorageLocationID *uint `json:"storage_location_id"`
+	SetCopiesMoved    int   `json:"set_copies_moved"`
+	LoosePartsMoved   int   `json:"loose_parts_moved"`
+}
+
+// storageLocationService implements StorageLocationService interface
+type storageLocationService struct {
+	storageLocationRepo repository.StorageLocationRepository
+	setCopyRepo         repository.SetCopyRepository
+	loosePartRepo       repository.LoosePartRepository
+	transactor          repository.Transactor
+}
+
+// NewStorageLocationService creates a new storage location service
+func NewStorageLocationService(storageLocationRepo repository.StorageLocationRepository, setCopyRepo repository.SetCopyRepository, loosePartRepo repository.LoosePartRepository, transactor repository.Transactor) StorageLocationService {
+	return &storageLocationService{
+		storageLocationRepo: storageLocationRepo,
+		setCopyRepo:         setCopyRepo,
+		loosePartRepo:       loosePartRepo,
+		transactor:          transactor,
+	}
+}
+
+// GetStorageLocations retrieves the tree of storage locations, starting from the root ones
+func (s *storageLocationService) GetStorageLocations() ([]entity.StorageLocation, error) {
+	locations, err := s.storageLocationRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage locations: %w", err)
+	}
+
+	children := make(map[uint][]entity.StorageLocation)
+	var roots []entity.StorageLocation
+	for _, location := range locations {
+		if location.ParentID == nil {
+			roots = append(roots, location)
+			continue
+		}
+		children[*location.ParentID] = append(children[*location.ParentID], location)
+	}
+
+	var attach func(locations []entity.StorageLocation) []entity.StorageLocation
+	attach = func(locations []entity.StorageLocation) []entity.StorageLocation {
+		for i := range locations {
+			locations[i].Children = attach(children[locations[i].ID])
+		}
+		return locations
+	}
+
+	if roots == nil {
+		return []entity.StorageLocation{}, nil
+	}
+	return attach(roots), nil
+}
+
+// GetStorageLocation retrieves a storage location with the set copies and loose parts it holds. When
+// recursive is set, the items of every sub-location are included as well.
+func (s *storageLocationService) GetStorageLocation(id uint, recursive bool) (*StorageLocationContents, error) {
+	location, err := s.storageLocationRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage location %d: %w", id, err)
+	}
+
+	locationIDs := []uint{id}
+	if recursive {
+		locations, err := s.storageLocationRepo.GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get storage locations: %w", err)
+		}
+		locationIDs = append(locationIDs, descendantIDs(locations, id)...)
+	}
+
+	setCopies, err := s.setCopyRepo.GetByStorageLocationIDs(locationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get set copies in storage location %d: %w", id, err)
+	}
+	looseParts, err := s.loosePartRepo.GetByStorageLocationIDs(locationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loose parts in storage location %d: %w", id, err)
+	}
+
+	return &StorageLocationContents{
+		StorageLocation: location,
+		SetCopies:       setCopies,
+		LooseParts:      looseParts,
+		Recursive:       recursive,
+	}, nil
+}
+
+// CreateStorageLocation creates a storage location, nested in its parent when it has one
+func (s *storageLocationService) CreateStorageLocation(req StorageLocationRequest) (*entity.StorageLocation, error) {
+	location := &entity.StorageLocation{
+		ParentID: req.ParentID,
+		Name:     strings.TrimSpace(req.Name),
+		Kind:     req.Kind,
+		Notes:    req.Notes,
+	}
+
+	var parent *entity.StorageLocation
+	if req.ParentID != nil {
+		var err error
+		parent, err = s.storageLocationRepo.GetByID(*req.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get storage location %d: %w", *req.ParentID, err)
+		}
+	}
+	if err := validateStorageLocation(location, parent, nil); err != nil {
+		return nil, err
+	}
+	location.Path = storageLocationPath(parent, location.Name)
+
+	if err := s.storageLocationRepo.Create(location); err != nil {
+		return nil, fmt.Errorf("failed to create storage location: %w", err)
+	}
+
+	return s.storageLocationRepo.GetByID(location.ID)
+}
+
+// UpdateStorageLocation renames, changes or moves a storage location. The paths of its sub-locations
+// are updated in the same transaction.
+func (s *storageLocationService) UpdateStorageLocation(id uint, req StorageLocationUpdate) (*entity.StorageLocation, error) {
+	location, err := s.storageLocationRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage location %d: %w", id, err)
+	}
+
+	locations, err := s.storageLocationRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage locations: %w", err)
+	}
+
+	if req.ParentID != nil {
+		location.ParentID = req.ParentID
+		if *req.ParentID == 0 {
+			location.ParentID = nil
+		}
+	}
+	if req.Name != nil {
+		location.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Kind != nil {
+		location.Kind = *req.Kind
+	}
+	if req.Notes != nil {
+		location.Notes = *req.Notes
+	}
+
+	var parent *entity.StorageLocation
+	if location.ParentID != nil {
+		if *location.ParentID == id {
+			return nil, fmt.Errorf("storage location %d cannot be its own parent: %w", id, ErrInvalidStorageLocation)
+		}
+		for _, descendantID := range descendantIDs(locations, id) {
+			if descendantID == *location.ParentID {
+				return nil, fmt.Errorf("storage location %d cannot be moved into its sub-location %d: %w", id, descendantID, ErrInvalidStorageLocation)
+			}
+		}
+		parent, err = s.storageLocationRepo.GetByID(*location.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get storage location %d: %w", *location.ParentID, err)
+		}
+	}
+	if err := validateStorageLocation(location, parent, location.Children); err != nil {
+		return nil, err
+	}
+	location.Path = storageLocationPath(parent, location.Name)
+	location.Children = nil
+
+	err = s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		if err := repos.StorageLocations.Update(location); err != nil {
+			return fmt.Errorf("failed to update storage location %d: %w", id, err)
+		}
+		return updateDescendantPaths(repos.StorageLocations, locations, location)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storageLocationRepo.GetByID(id)
+}
+
+// DeleteStorageLocation deletes a storage location that holds no sub-location and no item
+func (s *storageLocationService) DeleteStorageLocation(id uint) error {
+	location, err := s.storageLocationRepo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get storage location %d: %w", id, err)
+	}
+	if len(location.Children) > 0 {
+		return fmt.Errorf("storage location %d has %d sub-locations: %w", id, len(location.Children), ErrStorageLocationNotEmpty)
+	}
+
+	setCopies, err := s.setCopyRepo.GetByStorageLocationIDs([]uint{id})
+	if err != nil {
+		return fmt.Errorf("failed to get set copies in storage location %d: %w", id, err)
+	}
+	looseParts, err := s.loosePartRepo.GetByStorageLocationIDs([]uint{id})
+	if err != nil {
+		return fmt.Errorf("failed to get loose parts in storage location %d: %w", id, err)
+	}
+	if len(setCopies) > 0 || len(looseParts) > 0 {
+		return fmt.Errorf("storage location %d holds %d set copies and %d loose parts: %w", id, len(setCopies), len(looseParts), ErrStorageLocationNotEmpty)
+	}
+
+	return s.storageLocationRepo.Delete(id)
+}
+
+// MoveItems moves set copies and loose part entries to a storage location in a single transaction.
+// Moved loose parts join the entry of the same part and color already kept in the location.
+func (s *storageLocationService) MoveItems(req StorageLocationMove) (*StorageLocationMoveReport, error) {
+	moves := make([]LoosePartMove, 0, len(req.LoosePartIDs)+len(req.LooseParts))
+	for _, id := range req.LoosePartIDs {
+		moves = append(moves, LoosePartMove{ID: id})
+	}
+	moves = append(moves, req.LooseParts...)
+
+	if len(req.SetCopyIDs) == 0 && len(moves) == 0 {
+		return nil, fmt.Errorf("no set copy or loose part to move: %w", ErrInvalidStorageLocation)
+	}
+	if err := checkStorageLocation(s.storageLocationRepo, req.StorageLocationID); err != nil {
+		return nil, err
+	}
+	for _, id := range req.SetCopyIDs {
+		if _, err := s.setCopyRepo.GetByID(id); err != nil {
+			return nil, fmt.Errorf("failed to get set copy %d: %w", id, err)
+		}
+	}
+	seen := make(map[uint]bool, len(moves))
+	for _, move := range moves {
+		if seen[move.ID] {
+			return nil, fmt.Errorf("loose part %d is moved twice: %w", move.ID, ErrInvalidStorageLocation)
+		}
+		seen[move.ID] = true
+
+		loosePart, err := s.loosePartRepo.GetByID(move.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get loose part %d: %w", move.ID, err)
+		}
+		if move.Quantity < 0 || move.Quantity > loosePart.Quantity {
+			return nil, fmt.Errorf("cannot move %d of the %d pieces of loose part %d: %w", move.Quantity, loosePart.Quantity, move.ID, ErrInvalidStorageLocation)
+		}
+	}
+
+	err := s.transactor.Transaction(func(repos repository.TxRepositories) error {
+		if len(req.SetCopyIDs) > 0 {
+			if err := repos.SetCopies.UpdateStorageLocation(req.SetCopyIDs, req.StorageLocationID); err != nil {
+				return fmt.Errorf("failed to move set copies: %w", err)
+			}
+		}
+		for _, move := range moves {
+			loosePart, err := repos.LooseParts.GetByID(move.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get loose part %d: %w", move.ID, err)
+			}
+			quantity := move.Quantity
+			if quantity == 0 {
+				quantity = loosePart.Quantity
+			}
+			if _, err := moveLooseParts(repos.LooseParts, loosePart, quantity, req.StorageLocationID); err != nil {
+				return fmt.Errorf("failed to move loose parts: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &StorageLocationMoveReport{
+		StorageLocationID: req.StorageLocationID,
+		SetCopiesMoved:    len(req.SetCopyIDs),
+		LoosePartsMoved:   len(moves),
+	}, nil
+}
+
+// checkStorageLocation makes sure the storage location id exists. A nil id stands for no location.
+func checkStorageLocation(repo repository.StorageLocationRepository, id *uint) error {
+	if id == nil {
+		return nil
+	}
+	if _, err := repo.GetByID(*id); err != nil {
+		return fmt.Errorf("failed to get storage location %d: %w", *id, err)
+	}
+	return nil
+}
+
+// validateStorageLocation checks the name and kind of a location, and that it is nested deeper than
+// its parent and shallower than its children
+func validateStorageLocation(location, parent *entity.StorageLocation, children []entity.StorageLocation) error {
+	if location.Name == "" {
+		return fmt.Errorf("name is required: %w", ErrInvalidStorageLocation)
+	}
+
+	depth, ok := storageLocationDepths[location.Kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q, expected room, shelf, drawer or bin: %w", location.Kind, ErrInvalidStorageLocation)
+	}
+	if parent != nil && storageLocationDepths[parent.Kind] >= depth {
+		return fmt.Errorf("a %s cannot be placed in a %s: %w", location.Kind, parent.Kind, ErrInvalidStorageLocation)
+	}
+	for _, child := range children {
+		if storageLocationDepths[child.Kind] <= depth {
+			return fmt.Errorf("a %s cannot hold the %s %q: %w", location.Kind, child.Kind, child.Name, ErrInvalidStorageLocation)
+		}
+	}
+	return nil
+}
+
+// storageLocationPath joins the path of the parent, when there is one, with the name of a location
+func storageLocationPath(parent *entity.StorageLocation, name string) string {
+	if parent == nil {
+		return name
+	}
+	return parent.Path + entity.StorageLocationPathSeparator + name
+}
+
+// storageLocationPathOf returns the path of a location, or an empty string when there is none
+func storageLocationPathOf(location *entity.StorageLocation) string {
+	if location == nil {
+		return ""
+	}
+	return location.Path
+}
+
+// descendantIDs lists the IDs of every location nested, at any depth, in the location id
+func descendantIDs(locations []entity.StorageLocation, id uint) []uint {
+	var ids []uint
+	queue := []uint{id}
+	for len(queue) > 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+		for _, location := range locations {
+			if location.ParentID != nil && *location.ParentID == parentID {
+				ids = append(ids, location.ID)
+				queue = append(queue, location.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// updateDescendantPaths recomputes the paths of the locations nested in parent, at any depth
+func updateDescendantPaths(repo repository.StorageLocationRepository, locations []entity.StorageLocation, parent *entity.StorageLocation) error {
+	for i := range locations {
+		location := &locations[i]
+		if location.ParentID == nil || *location.ParentID != parent.ID {
+			continue
+		}
+
+		location.Path = storageLocationPath(parent, location.Name)
+		if err := repo.Update(location); err != nil {
+			return fmt.Errorf("failed to update storage location %d: %w", location.ID, err)
+		}
+		if err := updateDescendantPaths(repo, locations, location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
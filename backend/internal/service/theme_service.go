@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BombartSimon/MissingBrick/internal/entity"
+	"github.com/BombartSimon/MissingBrick/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ThemeService handles business logic for themes
+type ThemeService interface {
+	SyncThemesFromRebrickable() (int, error)
+	EnsureTheme(id int) error
+	GetThemeTree() ([]entity.Theme, error)
+	GetThemeByID(id int) (*entity.Theme, error)
+	GetThemeAndSubThemeIDs(id int) ([]int, error)
+}
+
+// themeService implements ThemeService interface
+type themeService struct {
+	themeRepo          repository.ThemeRepository
+	rebrickableService RebrickableService
+}
+
+// NewThemeService creates a new theme service
+func NewThemeService(themeRepo repository.ThemeRepository, rebrickableService RebrickableService) ThemeService {
+	return &themeService{
+		themeRepo:          themeRepo,
+		rebrickableService: rebrickableService,
+	}
+}
+
+// SyncThemesFromRebrickable refreshes the themes table from Rebrickable and returns the number of themes synced
+func (s *themeService) SyncThemesFromRebrickable() (int, error) {
+	rbThemes, err := s.rebrickableService.GetThemes()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch themes from Rebrickable: %w", err)
+	}
+
+	themes := make([]entity.Theme, 0, len(rbThemes))
+	for _, rbTheme := range rbThemes {
+		themes = append(themes, themeFromRebrickable(rbTheme))
+	}
+
+	if err := s.themeRepo.UpsertBatch(themes); err != nil {
+		return 0, fmt.Errorf("failed to save themes: %w", err)
+	}
+
+	return len(themes), nil
+}
+
+// EnsureTheme fetches a theme and its ancestors from Rebrickable if they are not stored yet
+func (s *themeService) EnsureTheme(id int) error {
+	for id != 0 {
+		theme, err := s.themeRepo.GetByID(id)
+		if err == nil {
+			if theme.ParentID == nil {
+				return nil
+			}
+			id = *theme.ParentID
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get theme %d: %w", id, err)
+		}
+
+		rbTheme, err := s.rebrickableService.GetTheme(id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch theme from Rebrickable: %w", err)
+		}
+		if err := s.themeRepo.UpsertBatch([]entity.Theme{themeFromRebrickable(*rbTheme)}); err != nil {
+			return fmt.Errorf("failed to save theme %d: %w", id, err)
+		}
+
+		id = 0
+		if rbTheme.ParentID != nil {
+			id = *rbTheme.ParentID
+		}
+	}
+
+	return nil
+}
+
+// GetThemeTree returns the root themes with their sub-themes nested as children
+func (s *themeService) GetThemeTree() ([]entity.Theme, error) {
+	themes, err := s.themeRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	childrenByParent := make(map[int][]entity.Theme)
+	var roots []entity.Theme
+	for _, theme := range themes {
+		if theme.ParentID == nil {
+			roots = append(roots, theme)
+			continue
+		}
+		childrenByParent[*theme.ParentID] = append(childrenByParent[*theme.ParentID], theme)
+	}
+
+	return attachChildThemes(roots, childrenByParent), nil
+}
+
+// GetThemeByID retrieves a theme with its ancestors
+func (s *themeService) GetThemeByID(id int) (*entity.Theme, error) {
+	return s.themeRepo.GetByID(id)
+}
+
+// GetThemeAndSubThemeIDs returns the ID of a theme and of all its sub-themes
+func (s *themeService) GetThemeAndSubThemeIDs(id int) ([]int, error) {
+	return s.themeRepo.GetDescendantIDs(id)
+}
+
+// attachChildThemes recursively fills the Children of each theme
+func attachChildThemes(themes []entity.Theme, childrenByParent map[int][]entity.Theme) []entity.Theme {
+	for i := range themes {
+		if children, ok := childrenByParent[themes[i].ID]; ok {
+			themes[i].Children = attachChildThemes(children, childrenByParent)
+		}
+	}
+	return themes
+}
+
+// themeFromRebrickable converts a Rebrickable theme to a Theme entity
+func themeFromRebrickable(rbTheme RebrickableTheme) entity.Theme {
+	return entity.Theme{
+		ID:       rbTheme.ID,
+		Name:     rbTheme.Name,
+		ParentID: rbTheme.ParentID,
+	}
+}
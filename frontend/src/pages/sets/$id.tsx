@@ -32,8 +32,8 @@ export default function SetDetailsPage() {
                 setSet(setResponse.data);
 
                 try {
-                    const missingResponse = await missingPartsApi.getBySetId(setId);
-                    setMissingParts(missingResponse.data);
+                    const missingResponse = await missingPartsApi.getBySetId(setId, { limit: 1000 });
+                    setMissingParts(missingResponse.data.missing_parts.filter(mp => mp.is_missing));
                 } catch {
                     setMissingParts([]);
                 }
@@ -70,10 +70,10 @@ export default function SetDetailsPage() {
 
     const handleMarkAsFound = async (missingPartId: number) => {
         try {
-            await missingPartsApi.delete(missingPartId);
+            await missingPartsApi.markFound(missingPartId);
             if (set) {
-                const missingResponse = await missingPartsApi.getBySetId(set.id);
-                setMissingParts(missingResponse.data);
+                const missingResponse = await missingPartsApi.getBySetId(set.id, { limit: 1000 });
+                setMissingParts(missingResponse.data.missing_parts.filter(mp => mp.is_missing));
             }
         } catch (err) {
             console.error('Error marking part as found:', err);
@@ -88,10 +88,10 @@ export default function SetDetailsPage() {
             await missingPartsApi.assign({
                 set_id: set.id,
                 part_requests: [{ set_part_id: setPartId, quantity }]
-            });
+            }, crypto.randomUUID());
 
-            const missingResponse = await missingPartsApi.getBySetId(set.id);
-            setMissingParts(missingResponse.data);
+            const missingResponse = await missingPartsApi.getBySetId(set.id, { limit: 1000 });
+            setMissingParts(missingResponse.data.missing_parts.filter(mp => mp.is_missing));
         } catch (err) {
             console.error('Error adding missing part:', err);
             alert('Failed to add missing part');
@@ -266,7 +266,7 @@ export default function SetDetailsPage() {
                                                 {missingPart.part?.name}
                                             </h3>
                                             <div className="text-sm opacity-70">
-                                                #{missingPart.part?.part_num} • {missingPart.color_name}
+                                                #{missingPart.part?.part_num} • {missingPart.color?.name}
                                             </div>
                                         </div>
 
@@ -378,7 +378,7 @@ export default function SetDetailsPage() {
                                                                 #{setPart.part?.part_num}
                                                             </div>
                                                             <div className='text-sm opacity-70 flex items-center gap-2'>
-                                                                {setPart.color_name}
+                                                                {setPart.color?.name}
                                                             </div>
                                                         </div>
                                                     </div>
@@ -1,6 +1,6 @@
 import { useEffect, useState } from 'react';
 import { Link } from 'react-router-dom';
-import { setsApi } from '../../services/api';
+import { setsApi, jobsApi } from '../../services/api';
 import type { Set } from '../../types/api';
 import ImageLightbox from '../../components/ImageLightbox';
 
@@ -29,8 +29,15 @@ export default function SetsPage() {
     const fetchSets = async () => {
         try {
             setLoading(true);
-            const response = await setsApi.getAll();
-            setSets(response.data?.sets || []);
+            // Follow the cursor so that client-side filtering sees every set
+            const allSets: Set[] = [];
+            let cursor: string | undefined;
+            do {
+                const response = await setsApi.getAll({ limit: 1000, cursor });
+                allSets.push(...(response.data?.sets || []));
+                cursor = response.data?.next_cursor || undefined;
+            } while (cursor);
+            setSets(allSets);
         } catch (error) {
             console.error('Error fetching sets:', error);
             setSets([]);
@@ -56,7 +63,15 @@ export default function SetsPage() {
 
         try {
             setIsAdding(true);
-            await setsApi.create({ set_num: newSetNum.trim() });
+            let { data: job } = await setsApi.create({ set_num: newSetNum.trim() });
+            // Wait until the set itself is imported, unless it failed and awaits a retry; its parts may still be importing
+            while (job.set_id === null && (job.status === 'queued' || job.status === 'running') && job.next_attempt_at === null) {
+                await new Promise(resolve => setTimeout(resolve, 1000));
+                ({ data: job } = await jobsApi.get(job.id));
+            }
+            if (job.set_id === null) {
+                throw new Error(job.error);
+            }
             setNewSetNum('');
             await fetchSets();
         } catch (error) {
@@ -205,6 +220,12 @@ export default function SetsPage() {
                                             <span className="text-sm text-base-content/70">
                                                 {set.num_parts} parts
                                             </span>
+                                            <div
+                                                className={`badge ${set.status === 'complete' ? 'badge-success' : set.status === 'incomplete' ? 'badge-warning' : 'badge-ghost'}`}
+                                                title={`${set.missing_lots} missing lots`}
+                                            >
+                                                {set.status === 'unchecked' ? 'unchecked' : `${set.completeness}%`}
+                                            </div>
                                             <div className="ml-auto flex-shrink-0">
                                                 <a
                                                     href={`https://www.lego.com/en-be/service/building-instructions/${encodeURIComponent(set.set_num.replace(/-1$/, ''))}`}
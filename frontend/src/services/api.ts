@@ -5,8 +5,41 @@ import type {
     MissingPart,
     CreateSetRequest,
     AssignMissingPartsRequest,
+    RecoveryRequest,
     ApiResponse,
-    SetPart
+    SetPart,
+    ListPage,
+    SetListParams,
+    PartListParams,
+    MissingPartListParams,
+    SetCopy,
+    CreateSetCopyRequest,
+    LoosePart,
+    LoosePartListParams,
+    AddLoosePartRequest,
+    UpdateLoosePartRequest,
+    LoosePartsImportReport,
+    FulfillmentOptions,
+    FulfillmentPlan,
+    Buildability,
+    BuildableSet,
+    BuildableSetsParams,
+    StorageLocation,
+    StorageLocationContents,
+    CreateStorageLocationRequest,
+    UpdateStorageLocationRequest,
+    MoveToStorageLocationRequest,
+    StorageLocationMoveReport,
+    RebrickableAccount,
+    LinkRebrickableAccountRequest,
+    RebrickableLostPart,
+    RebrickableSyncReport,
+    SetResync,
+    RefreshStatus,
+    RefreshRun,
+    SetRefresh,
+    ImportJob,
+    SetListImportReport
 } from '../types/api';
 
 // Get API base URL from environment variable or fallback to default
@@ -30,25 +63,113 @@ const healthClient = axios.create({
 
 // Sets API
 export const setsApi = {
-    getAll: () => apiv1.get<{ sets: Set[] }>('/sets'),
+    getAll: (params?: SetListParams) => apiv1.get<{ sets: Set[] } & ListPage>('/sets', { params }),
     getById: (id: number) => apiv1.get<Set>(`/sets/${id}`),
     getByIdWithParts: (id: number) => apiv1.get<SetWithParts>(`/sets/${id}/with-parts`),
     getBySetNum: (setNum: string) => apiv1.get<Set>(`/sets/by-num/${setNum}`),
-    create: (data: CreateSetRequest) => apiv1.post<Set>('/sets', data),
+    create: (data: CreateSetRequest) => apiv1.post<ImportJob>('/sets', data),
     update: (id: number, data: Partial<Set>) => apiv1.put<Set>(`/sets/${id}`, data),
     delete: (id: number) => apiv1.delete(`/sets/${id}`),
+    resync: (id: number, force = false) => apiv1.post<SetResync>(`/sets/${id}/resync`, null, { params: { force } }),
+    bulkAdd: (setNums: string[]) => apiv1.post<SetListImportReport>('/sets/bulk', setNums),
+};
+
+// Set Copies API
+export const setCopiesApi = {
+    getBySetId: (setId: number) => apiv1.get<{ copies: SetCopy[] }>(`/sets/${setId}/copies`),
+    getById: (copyId: number) => apiv1.get<SetCopy>(`/copies/${copyId}`),
+    create: (setId: number, data: CreateSetCopyRequest) => apiv1.post<SetCopy>(`/sets/${setId}/copies`, data),
+    delete: (copyId: number) => apiv1.delete(`/copies/${copyId}`),
 };
 
 // Set Parts Api
 export const setPartsApi = {
-    getBySetId: (setId: number) => apiv1.get<{ set_parts: SetPart[] }>(`/set-parts/${setId}`),
+    getBySetId: (setId: number, params?: PartListParams) =>
+        apiv1.get<{ set_parts: SetPart[] } & ListPage>(`/set-parts/${setId}`, { params }),
 };
 
 // Missing Parts API
 export const missingPartsApi = {
-    getBySetId: (setId: number) => apiv1.get<MissingPart[]>(`/missing-parts/${setId}`),
-    assign: (data: AssignMissingPartsRequest) => apiv1.post<ApiResponse<string>>('/missing-parts', data),
+    getBySetId: (setId: number, params?: MissingPartListParams) =>
+        apiv1.get<{ missing_parts: MissingPart[] } & ListPage>(`/missing-parts/${setId}`, { params }),
+    assign: (data: AssignMissingPartsRequest, idempotencyKey?: string) =>
+        apiv1.post<ApiResponse<string>>('/missing-parts', data, {
+            headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
+        }),
+    markFound: (missingPartId: number, data: RecoveryRequest = {}) =>
+        apiv1.put<MissingPart>(`/missing-parts/${missingPartId}/found`, data),
+    reopen: (missingPartId: number, data: RecoveryRequest = {}) =>
+        apiv1.put<MissingPart>(`/missing-parts/${missingPartId}/reopen`, data),
     delete: (missingPartId: number) => apiv1.delete(`/missing-parts/${missingPartId}`),
+    getFulfillmentPlan: (options: FulfillmentOptions = {}) =>
+        apiv1.get<FulfillmentPlan>('/missing-parts/fulfillment', {
+            params: {
+                set_id: options.set_ids,
+                missing_part_id: options.missing_part_ids,
+                donor_set_id: options.donor_set_ids,
+                alternates: options.alternates,
+            },
+            paramsSerializer: { indexes: null },
+        }),
+    applyFulfillment: (options: FulfillmentOptions) =>
+        apiv1.post<FulfillmentPlan>('/missing-parts/fulfillment/apply', options),
+};
+
+// Loose Parts API
+export const loosePartsApi = {
+    getAll: (params?: LoosePartListParams) =>
+        apiv1.get<{ loose_parts: LoosePart[] } & ListPage>('/loose-parts', { params }),
+    getById: (id: number) => apiv1.get<LoosePart>(`/loose-parts/${id}`),
+    add: (data: AddLoosePartRequest) => apiv1.post<LoosePart>('/loose-parts', data),
+    update: (id: number, data: UpdateLoosePartRequest) => apiv1.put<LoosePart>(`/loose-parts/${id}`, data),
+    delete: (id: number) => apiv1.delete(`/loose-parts/${id}`),
+    import: (items: AddLoosePartRequest[], storageLocationId?: number) =>
+        apiv1.post<LoosePartsImportReport>('/loose-parts/import', items, { params: { storage_location_id: storageLocationId } }),
+};
+
+// Storage Locations API
+export const storageLocationsApi = {
+    getTree: () => apiv1.get<{ locations: StorageLocation[] }>('/locations'),
+    getById: (id: number, recursive = false) =>
+        apiv1.get<StorageLocationContents>(`/locations/${id}`, { params: { recursive } }),
+    create: (data: CreateStorageLocationRequest) => apiv1.post<StorageLocation>('/locations', data),
+    update: (id: number, data: UpdateStorageLocationRequest) => apiv1.put<StorageLocation>(`/locations/${id}`, data),
+    delete: (id: number) => apiv1.delete(`/locations/${id}`),
+    move: (data: MoveToStorageLocationRequest) => apiv1.post<StorageLocationMoveReport>('/locations/move', data),
+};
+
+// Buildability API
+export const buildabilityApi = {
+    getBySetNum: (setNum: string, includeSets = true) =>
+        apiv1.get<Buildability>(`/buildability/${encodeURIComponent(setNum)}`, { params: { include_sets: includeSets } }),
+    rank: (params?: BuildableSetsParams) =>
+        apiv1.get<{ sets: BuildableSet[]; limit: number }>('/buildability', { params }),
+};
+
+// Rebrickable account sync API
+export const rebrickableSyncApi = {
+    getAccount: () => apiv1.get<RebrickableAccount>('/rebrickable/account'),
+    linkAccount: (data: LinkRebrickableAccountRequest) => apiv1.put<RebrickableAccount>('/rebrickable/account', data),
+    unlinkAccount: () => apiv1.delete('/rebrickable/account'),
+    sync: () => apiv1.post<RebrickableSyncReport>('/rebrickable/sync'),
+    getConflicts: () => apiv1.get<{ conflicts: RebrickableLostPart[] }>('/rebrickable/conflicts'),
+    resolveConflict: (id: number, keep: 'local' | 'remote') =>
+        apiv1.post<RebrickableLostPart>(`/rebrickable/conflicts/${id}/resolve`, { keep }),
+};
+
+// Background refresh API
+export const refreshApi = {
+    getStatus: () => apiv1.get<RefreshStatus>('/refresh'),
+    trigger: () => apiv1.post<RefreshRun>('/refresh/trigger'),
+    pause: () => apiv1.post<RefreshStatus>('/refresh/pause'),
+    resume: () => apiv1.post<RefreshStatus>('/refresh/resume'),
+    getRuns: (limit?: number) => apiv1.get<{ runs: RefreshRun[]; limit: number }>('/refresh/runs', { params: { limit } }),
+    getSets: (failed?: boolean) => apiv1.get<{ sets: SetRefresh[] }>('/refresh/sets', { params: { failed } }),
+};
+
+// Import jobs API
+export const jobsApi = {
+    get: (id: number) => apiv1.get<ImportJob>(`/jobs/${id}`),
 };
 
 // Health Check (uses base URL without /api/v1)
@@ -1,17 +1,151 @@
 // API Types based on your Go backend
 
+export interface Theme {
+    id: number;
+    name: string;
+    parent_id: number | null;
+    parent?: Theme;
+    children?: Theme[];
+}
+
 export interface Set {
     id: number;
     set_num: string;
     name: string;
     year: number;
     theme_id: number;
+    theme?: Theme;
     num_parts: number;
     set_img_url: string;
     set_url: string;
     last_modified_dt: string;
+    checked_at?: string | null;
+    is_donor?: boolean;
+    import_status: 'importing' | 'failed' | 'done';
     created_at: string;
     updated_at: string;
+    copies: number;
+    pieces_required: number;
+    pieces_missing: number;
+    missing_lots: number;
+    completeness: number;
+    status: SetStatus;
+}
+
+export type SetStatus = 'complete' | 'incomplete' | 'unchecked';
+
+export type SetCopyCondition = '' | 'sealed' | 'new' | 'used';
+
+export interface SetCopy {
+    id: number;
+    set_id: number;
+    label: string;
+    condition: SetCopyCondition;
+    acquired_at: string | null;
+    notes: string;
+    created_at: string;
+    updated_at: string;
+    pieces_missing: number;
+    missing_lots: number;
+    storage_location_id: number | null;
+    storage_location?: StorageLocation;
+    set?: Set;
+    missing_parts?: MissingPart[];
+    missing_minifigs?: MissingMinifig[];
+}
+
+export interface CreateSetCopyRequest {
+    label?: string;
+    condition?: SetCopyCondition;
+    acquired_at?: string;
+    notes?: string;
+    storage_location_id?: number;
+}
+
+export type StorageLocationKind = 'room' | 'shelf' | 'drawer' | 'bin';
+
+export interface StorageLocation {
+    id: number;
+    parent_id: number | null;
+    name: string;
+    kind: StorageLocationKind;
+    path: string;
+    notes: string;
+    created_at: string;
+    updated_at: string;
+    children?: StorageLocation[];
+}
+
+export interface StorageLocationContents extends StorageLocation {
+    set_copies: SetCopy[];
+    loose_parts: LoosePart[];
+    recursive: boolean;
+}
+
+export interface CreateStorageLocationRequest {
+    name: string;
+    kind: StorageLocationKind;
+    parent_id?: number;
+    notes?: string;
+}
+
+export interface UpdateStorageLocationRequest {
+    name?: string;
+    kind?: StorageLocationKind;
+    parent_id?: number;
+    notes?: string;
+}
+
+export interface MoveToStorageLocationRequest {
+    storage_location_id: number | null;
+    set_copy_ids?: number[];
+    loose_part_ids?: number[];
+    loose_parts?: LoosePartMove[];
+}
+
+export interface LoosePartMove {
+    id: number;
+    quantity: number;
+}
+
+export interface StorageLocationMoveReport {
+    storage_location_id: number | null;
+    set_copies_moved: number;
+    loose_parts_moved: number;
+}
+
+export interface LoosePart {
+    id: number;
+    part_id: number;
+    color_id: number;
+    quantity: number;
+    storage_location_id: number | null;
+    notes: string;
+    created_at: string;
+    updated_at: string;
+    part?: Part;
+    color: Color;
+    storage_location?: StorageLocation;
+}
+
+export interface AddLoosePartRequest {
+    part_num: string;
+    color_id: number;
+    quantity: number;
+    storage_location_id?: number;
+    notes?: string;
+}
+
+export interface UpdateLoosePartRequest {
+    quantity?: number;
+    storage_location_id?: number;
+    notes?: string;
+}
+
+export interface LoosePartsImportReport {
+    format: string;
+    applied: LoosePart[];
+    unmatched: MissingPartsImportRow[];
 }
 
 export interface Part {
@@ -27,6 +161,44 @@ export interface Part {
     updated_at: string;
 }
 
+export interface PartColorUsage {
+    color_id: number;
+    name: string;
+    rgb: string;
+    quantity: number;
+}
+
+export interface PartSetUsage {
+    set_id: number;
+    set_num: string;
+    name: string;
+    quantity: number;
+    colors: PartColorUsage[];
+}
+
+export interface PartWithSets extends Part {
+    colors: PartColorUsage[];
+    sets: PartSetUsage[];
+}
+
+export interface PartPage {
+    parts: PartWithSets[];
+    total: number;
+    offset: number;
+    limit: number;
+}
+
+export interface Color {
+    id: number;
+    name: string;
+    rgb: string;
+    is_trans: boolean;
+    bricklink_id: number | null;
+    ldraw_id: number | null;
+    lego_id: number | null;
+    external_ids: string;
+}
+
 export interface SetPart {
     id: number;
     set_id: number;
@@ -38,26 +210,475 @@ export interface SetPart {
     created_at: string;
     updated_at: string;
     part?: Part;
+    color?: Color;
 }
 
 export interface MissingPart {
     id: number;
     set_id: number;
+    set_copy_id: number;
     part_id: number;
     color_id: number;
-    color_name: string;
-    color_hex: string;
     quantity: number;
     is_missing: boolean;
+    is_spare: boolean;
+    set_minifig_id?: number;
+    orphaned: boolean;
     notes: string;
     created_at: string;
     updated_at: string;
     set?: Set;
+    set_copy?: SetCopy;
     part?: Part;
+    color?: Color;
+    recoveries?: MissingPartRecovery[];
+}
+
+export interface MissingPartRecovery {
+    id: number;
+    missing_part_id: number;
+    action: 'found' | 'reopened';
+    quantity: number;
+    notes: string;
+    created_at: string;
+}
+
+export interface RecoveryRequest {
+    quantity?: number;
+    notes?: string;
+}
+
+export interface Minifig {
+    id: number;
+    fig_num: string;
+    name: string;
+    num_parts: number;
+    img_url: string;
+    url: string;
+    created_at: string;
+    updated_at: string;
+    parts?: MinifigPart[];
+}
+
+export interface MinifigPart {
+    id: number;
+    minifig_id: number;
+    part_id: number;
+    color_id: number;
+    quantity: number;
+    is_spare: boolean;
+    part?: Part;
+    color?: Color;
+}
+
+export interface SetMinifig {
+    id: number;
+    set_id: number;
+    minifig_id: number;
+    quantity: number;
+    created_at: string;
+    updated_at: string;
+    minifig?: Minifig;
+}
+
+export interface MissingMinifig {
+    id: number;
+    set_id: number;
+    set_copy_id: number;
+    set_minifig_id: number;
+    minifig_id: number;
+    quantity: number;
+    is_missing: boolean;
+    notes: string;
+    created_at: string;
+    updated_at: string;
+    minifig?: Minifig;
+}
+
+export interface MissingPartsSummarySet {
+    set_id: number;
+    set_num: string;
+    name: string;
+    quantity: number;
+}
+
+export interface MissingPartsSummaryItem {
+    part: Part;
+    color: Color;
+    quantity: number;
+    sets: MissingPartsSummarySet[];
+}
+
+export interface MissingMinifigsSummaryItem {
+    minifig: Minifig;
+    quantity: number;
+    sets: MissingPartsSummarySet[];
+}
+
+export interface MissingPartsSummary {
+    items: MissingPartsSummaryItem[];
+    minifigs: MissingMinifigsSummaryItem[];
+    total_lots: number;
+    total_quantity: number;
+}
+
+export interface UnmappedExportRow {
+    part_num: string;
+    part_name: string;
+    color_id: number;
+    color_name: string;
+    quantity: number;
+    reason: string;
+}
+
+export interface MissingPartsExport {
+    format: string;
+    content_type: string;
+    file_name: string;
+    content: string;
+    rows: number;
+    unmapped: UnmappedExportRow[];
+}
+
+export interface MissingPartsImportRow {
+    line: number;
+    part_num: string;
+    color: string;
+    quantity: number;
+    available?: number;
+    imported?: number;
+    reason: string;
+}
+
+export interface MissingPartsImportReport {
+    format: string;
+    applied: MissingPart[];
+    unmatched: MissingPartsImportRow[];
+    over_quantity: MissingPartsImportRow[];
+}
+
+export interface FulfillmentOptions {
+    set_ids?: number[];
+    missing_part_ids?: number[];
+    donor_set_ids?: number[];
+    alternates?: boolean;
+}
+
+export interface FulfillmentSource {
+    type: 'loose' | 'donor';
+    loose_part_id?: number;
+    storage_location_id?: number;
+    location?: string;
+    donor_set_id?: number;
+    donor_set_num?: string;
+    donor_set_copy_id?: number;
+    donor_set_part_id?: number;
+    part_num: string;
+    alternate: boolean;
+    quantity: number;
+}
+
+export interface FulfillmentItem {
+    missing_part_id: number;
+    set_id: number;
+    set_num: string;
+    set_copy_id: number;
+    part: Part;
+    color: Color;
+    missing: number;
+    covered: number;
+    sources: FulfillmentSource[];
+}
+
+export interface FulfillmentPlan {
+    items: FulfillmentItem[];
+    pieces_missing: number;
+    pieces_covered: number;
+    fully_covered: number;
+}
+
+export interface BuildabilityPart {
+    part_num: string;
+    part_name: string;
+    part_img_url: string;
+    color_id: number;
+    color_name: string;
+    required: number;
+    owned: number;
+    missing: number;
+}
+
+export interface Buildability {
+    set_num: string;
+    name: string;
+    year: number;
+    set_img_url: string;
+    owned: boolean;
+    pieces_required: number;
+    pieces_owned: number;
+    completeness: number;
+    lots: number;
+    missing_lots: number;
+    missing: BuildabilityPart[];
+}
+
+export interface BuildableSet {
+    set_num: string;
+    name: string;
+    year: number;
+    theme_id: number;
+    img_url: string;
+    pieces_required: number;
+    pieces_owned: number;
+    completeness: number;
+    owned: boolean;
+}
+
+export interface BuildableSetsParams {
+    limit?: number;
+    min_completeness?: number;
+    include_sets?: boolean;
+}
+
+export interface RebrickableAccount {
+    id: number;
+    username: string;
+    last_synced_at: string | null;
+    created_at: string;
+    updated_at: string;
+}
+
+export interface LinkRebrickableAccountRequest {
+    user_token?: string;
+    username?: string;
+    password?: string;
+}
+
+export interface RebrickableLostPart {
+    id: number;
+    lost_part_id: number;
+    inv_part_id: number;
+    missing_part_id: number;
+    set_num: string;
+    part_num: string;
+    color_id: number;
+    is_spare: boolean;
+    synced_quantity: number;
+    conflict: boolean;
+    local_quantity: number;
+    remote_quantity: number;
+    synced_at: string;
+    created_at: string;
+    updated_at: string;
+}
+
+export interface RebrickableSyncReport {
+    sets: {
+        queued: {
+            set_num: string;
+            job_id: number;
+        }[];
+        copies_added: number;
+        up_to_date: number;
+    };
+    part_lists: {
+        lists: number;
+        pieces_added: number;
+    };
+    lost_parts: {
+        imported: number;
+        pushed: number;
+        updated_local: number;
+        updated_remote: number;
+        unchanged: number;
+        conflicts: RebrickableLostPart[];
+        skipped: {
+            set_num: string;
+            part_num: string;
+            color_id: number;
+            reason: string;
+        }[];
+    };
+    errors: string[];
+}
+
+export interface RefreshRun {
+    id: number;
+    trigger: 'scheduled' | 'manual';
+    status: 'running' | 'done' | 'failed';
+    sets_checked: number;
+    sets_modified: number;
+    sets_failed: number;
+    error?: string;
+    started_at: string;
+    finished_at: string | null;
+}
+
+export interface RefreshStatus {
+    paused: boolean;
+    running: boolean;
+    interval: string;
+    max_age: string;
+    batch_size: number;
+    next_run_at: string | null;
+    last_run: RefreshRun | null;
+}
+
+export interface SetRefresh {
+    id: number;
+    set_id: number;
+    checked_at: string | null;
+    last_error?: string;
+    last_error_at: string | null;
+    failures: number;
+    updated_at: string;
+    set?: Set;
+}
+
+export interface ImportJob {
+    id: number;
+    set_num: string;
+    set_id: number | null;
+    status: 'queued' | 'running' | 'failed' | 'done';
+    step: 'set' | 'parts' | 'minifigs';
+    copies: number;
+    progress: number;
+    attempts: number;
+    max_attempts: number;
+    error?: string;
+    next_attempt_at: string | null;
+    started_at: string | null;
+    finished_at: string | null;
+    created_at: string;
+    updated_at: string;
+    set?: Set;
+}
+
+export interface SetListImportReport {
+    format: 'json' | 'text' | 'csv';
+    queued: number;
+    existing: number;
+    failed: number;
+    sets: {
+        line: number;
+        set_num: string;
+        quantity: number;
+        outcome: 'queued' | 'existing' | 'duplicate' | 'invalid' | 'failed';
+        job_id?: number;
+        error?: string;
+    }[];
+}
+
+export interface SearchResult {
+    kind: 'part' | 'set';
+    id: number;
+    num: string;
+    name: string;
+    highlight: {
+        num: string;
+        name: string;
+    };
+    score: number;
+}
+
+export interface SearchResults {
+    query: string;
+    results: SearchResult[];
+    total: number;
+    offset: number;
+    limit: number;
+}
+
+export interface ListPage {
+    total: number;
+    limit: number;
+    next_cursor: string;
+}
+
+export interface ListParams {
+    limit?: number;
+    cursor?: string;
+    sort?: string;
+    order?: 'asc' | 'desc';
+}
+
+export interface SetListParams extends ListParams {
+    year_min?: number;
+    year_max?: number;
+    theme_id?: number;
+    has_missing_parts?: boolean;
+    is_donor?: boolean;
+    status?: SetStatus;
+    completeness_min?: number;
+    completeness_max?: number;
+    include_spares?: boolean;
+}
+
+export interface PartListParams extends ListParams {
+    color_id?: number;
+    is_spare?: boolean;
+}
+
+export interface MissingPartListParams extends PartListParams {
+    set_copy_id?: number;
+    orphaned?: boolean;
+}
+
+export interface LoosePartListParams extends ListParams {
+    part_num?: string;
+    color_id?: number;
+    storage_location_id?: number;
+}
+
+export interface SetPartDrift {
+    set_part_id: number;
+    part_num: string;
+    color_id: number;
+    is_spare: boolean;
+    old_quantity: number;
+    new_quantity: number;
+}
+
+export interface MissingPartRemap {
+    missing_part_id: number;
+    from_part_num: string;
+    to_part_num: string;
+    color_id: number;
+    from_spare: boolean;
+    to_spare: boolean;
+}
+
+export interface MissingPartClamp {
+    missing_part_id: number;
+    part_num: string;
+    color_id: number;
+    is_spare: boolean;
+    old_quantity: number;
+    new_quantity: number;
+}
+
+export interface InventoryDrift {
+    set_id: number;
+    set_num: string;
+    added: SetPartDrift[];
+    removed: SetPartDrift[];
+    quantity_changed: SetPartDrift[];
+    unchanged: number;
+    remapped: MissingPartRemap[];
+    clamped: MissingPartClamp[];
+    orphaned: MissingPart[];
+}
+
+export interface SetResync {
+    set: Set;
+    previous_last_modified_dt: string;
+    modified: boolean;
+    inventory?: InventoryDrift;
 }
 
 export interface SetWithParts extends Set {
     set_parts?: SetPart[];
+    set_minifigs?: SetMinifig[];
 }
 
 export interface CreateSetRequest {
@@ -66,12 +687,24 @@ export interface CreateSetRequest {
 
 export interface AssignMissingPartsRequest {
     set_id: number;
+    set_copy_id?: number;
     part_requests: {
-        set_part_id: number;
+        set_part_id?: number;
+        set_minifig_id?: number;
+        minifig_part_id?: number;
         quantity: number;
     }[];
 }
 
+export interface AssignMissingMinifigsRequest {
+    set_id: number;
+    set_copy_id?: number;
+    minifig_requests: {
+        set_minifig_id: number;
+        quantity?: number;
+    }[];
+}
+
 export interface ApiResponse<T> {
     data: T;
     message?: string;